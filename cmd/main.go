@@ -15,6 +15,8 @@ import (
 	// "gorm.io/gorm" // 如果需要进行 map 值类型断言，请显式导入 gorm
 
 	"myGin/internal/bootstrap"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/plugin"
 	// 使用匿名导入来解决 "imported and not used" 的 Linter 错误
 	// 这表明我们需要包的类型定义，即使不直接引用包名。
 	_ "myGin/internal/conf"
@@ -22,12 +24,37 @@ import (
 
 func main() {
 	// 1. 加载配置
-	cfg, err := bootstrap.LoadConfig() // LoadConfig 返回 *conf.Config, err
+	// 支持 --config、--env、--set key=value、--print-config 和 --print-errors 参数，以及 APP_ 前缀的环境变量
+	opts, err := bootstrap.ParseConfigFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2) // flag 包已输出错误和用法
+	}
+	if opts.PrintErrors {
+		// 打印错误码目录并退出，不需要加载配置
+		out, err := errs.CatalogJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: Failed to dump error catalog: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	cfg, err := bootstrap.LoadConfigWithOptions(opts) // 返回 *conf.Config, err
 	if err != nil {
 		// 日志记录器尚未初始化，直接输出到 stderr 并退出
 		fmt.Fprintf(os.Stderr, "FATAL: Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
+	if opts.PrintConfig {
+		// 打印合并后的生效配置 (敏感字段已脱敏) 并退出
+		out, err := bootstrap.DumpConfig(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: Failed to dump configuration: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(out)
+		return
+	}
 
 	// 2. 初始化日志记录器
 	// 传递 cfg.Logger (类型为 conf.LoggerConfig) 而不是整个 cfg
@@ -71,31 +98,72 @@ func main() {
 	bootstrap.AttachCoreMiddleware(engine, cfg)
 
 	// 7. 准备插件依赖项
-	dependencies := make(map[string]interface{})
-	dependencies["logger"] = bootstrap.GetLogger() // 传递 logger 实例
+	dependencies := plugin.Deps{}
+	dependencies[plugin.DepLogger] = bootstrap.GetLogger() // 传递 logger 实例
 	if db != nil {
-		dependencies["db"] = db // 只在成功初始化时传递 DB
+		dependencies[plugin.DepDB] = db // 只在成功初始化时传递 DB
 	}
 	if rdb != nil {
-		dependencies["redis"] = rdb // 只在成功初始化时传递 Redis
+		dependencies[plugin.DepRedis] = rdb // 只在成功初始化时传递 Redis
 	}
 	// 可以添加其他共享依赖项...
 
 	// 8. 附加可选插件
-	// 使用新的签名传递依赖 map
-	bootstrap.AttachPlugins(engine, cfg, dependencies)
+	// 插件在各自包的 init 中通过 plugin.Register 注册，这里按依赖顺序加载启用的插件
+	plugins, err := bootstrap.AttachPlugins(engine, cfg, dependencies)
+	if err != nil {
+		bootstrap.GetLogger().Fatal("Failed to attach plugins", zap.Error(err))
+	}
+
+	// 9. 初始化健康检查并注册路由 (步骤编号顺延)
+	checks := bootstrap.InitHealth(cfg, db, rdb)
+	bootstrap.RegisterRoutes(engine, cfg, checks)
 
-	// 9. 注册路由 (步骤编号顺延)
-	bootstrap.RegisterRoutes(engine, cfg)
+	// 管理端口 (pprof、路由表、插件状态等)，在所有路由注册完成后创建
+	var admin *bootstrap.AdminServer
+	if cfg.Admin.Enable {
+		admin, err = bootstrap.NewAdminServer(cfg, engine, plugins)
+		if err != nil {
+			bootstrap.GetLogger().Fatal("Failed to create admin server", zap.Error(err))
+		}
+	}
+
+	// 配置热重载: 配置文件变更后重新加载并校验，校验失败时保留旧配置
+	if cfg.App.HotReload {
+		watcher, err := bootstrap.NewConfigWatcher(opts, cfg, bootstrap.GetLogger())
+		if err == nil {
+			watcher.Subscribe(bootstrap.LoggerConfigSubscriber) // logger.level 实时生效
+			bootstrap.SubscribePlugins(watcher, plugins)        // 例如限流插件的 rate/burst
+			if admin != nil {
+				bootstrap.SubscribeAdmin(watcher, admin) // /admin/config 输出最新的生效配置
+			}
+			err = watcher.Start()
+		}
+		if err != nil {
+			bootstrap.GetLogger().Error("Failed to start config watcher, hot-reload disabled", zap.Error(err))
+		} else {
+			defer watcher.Stop()
+		}
+	}
 
 	// 10. 创建 HTTP 服务器 (步骤编号顺延)
-	srv := &http.Server{
-		Addr:    cfg.Server.Addr, // 从配置中获取监听地址
-		Handler: engine,
+	// 应用 server 配置中的读写超时、空闲超时和请求头限制
+	// 启用 TLS 时还会加载证书 (支持热重载) 并按需启动 HTTP→HTTPS 重定向
+	srv, err := bootstrap.NewHTTPServer(cfg.Server, engine)
+	if err != nil {
+		bootstrap.GetLogger().Fatal("Failed to create HTTP server", zap.Error(err))
 	}
 
-	// 11. 启动 HTTP 服务器 (goroutine)
-	bootstrap.GetLogger().Info("Server starting", zap.String("address", srv.Addr)) // 使用 GetLogger()
+	// 11. 启动插件的后台任务 (Start 钩子)，然后启动 HTTP 服务器 (goroutine)
+	if err := plugins.Start(context.Background()); err != nil {
+		bootstrap.GetLogger().Fatal("Failed to start plugins", zap.Error(err))
+	}
+	if admin != nil {
+		if err := admin.Start(); err != nil {
+			bootstrap.GetLogger().Fatal("Failed to start admin server", zap.Error(err))
+		}
+	}
+	bootstrap.GetLogger().Info("Server starting", zap.String("address", srv.Server.Addr), zap.Bool("tls", cfg.Server.TLS.Enable)) // 使用 GetLogger()
 	go func() {
 		// 服务连接
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -103,7 +171,7 @@ func main() {
 		}
 	}()
 
-	// 12. 等待中断信号以优雅地关闭服务器（设置 10 秒超时）
+	// 12. 等待中断信号以优雅地关闭服务器（超时由 server.shutdownTimeout 配置，默认 10 秒）
 	quit := make(chan os.Signal, 1) // 创建一个接收信号的通道
 	/*第一种关停方式：使用 Ctrl+C
 	第二种：使用 ps aux | grep main  查找main.go 进程
@@ -119,8 +187,17 @@ func main() {
 	<-quit                                               // 阻塞，直到接收到信号
 	bootstrap.GetLogger().Info("Shutting down server...") // 使用 GetLogger()
 
-	// 创建一个 10 秒超时的 context
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// 先将就绪检查置为失败，等待编排系统 (例如 k8s) 摘除流量后再关闭服务器
+	checks.SetShuttingDown()
+	if cfg.Health.ShutdownDelay != "" {
+		if delay, err := time.ParseDuration(cfg.Health.ShutdownDelay); err == nil && delay > 0 {
+			bootstrap.GetLogger().Info("Readiness set to false, waiting before shutdown", zap.Duration("delay", delay))
+			time.Sleep(delay)
+		}
+	}
+
+	// 创建一个带关停超时的 context
+	ctx, cancel := context.WithTimeout(context.Background(), srv.Settings.ShutdownTimeout)
 	defer cancel() // 确保在 main 函数退出前调用 cancel，释放资源
 
 	// 调用 Shutdown 进行优雅关停
@@ -129,5 +206,16 @@ func main() {
 		bootstrap.GetLogger().Fatal("Server forced to shutdown:", zap.Error(err)) // 使用 GetLogger()
 	}
 
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			bootstrap.GetLogger().Error("Failed to shutdown admin server", zap.Error(err))
+		}
+	}
+
+	// 服务器停止接收请求后，按依赖逆序停止插件 (Stop 钩子)，共用关停超时
+	if err := plugins.Stop(ctx); err != nil {
+		bootstrap.GetLogger().Error("Failed to stop plugins", zap.Error(err))
+	}
+
 	bootstrap.GetLogger().Info("Server exiting") // 使用 GetLogger() 记录服务器成功退出的信息
 }
\ No newline at end of file
@@ -0,0 +1,184 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myGin/internal/conf"
+	"myGin/internal/pkg/buildinfo"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// publishBuildInfo 保证构建信息只发布到 expvar 一次 (expvar.Publish 重复发布同名变量会 panic)。
+var publishBuildInfo sync.Once
+
+// AdminServer 是独立监听的管理端口，端点均需要 Bearer token:
+//   - /debug/pprof/*: net/http/pprof (admin.pprof 为 true 时)；
+//   - /debug/vars: expvar，包含 cmdline、memstats 和构建信息；
+//   - GET /admin/routes: 主服务的路由表；
+//   - GET /admin/plugins: 启用的插件及其状态；
+//   - GET /admin/config: 当前生效的配置 (敏感字段已脱敏)；
+//   - GET /admin/buildinfo: 通过 ldflags 注入的版本信息；
+//   - GET/PUT /admin/loglevel: 查看或修改全局日志级别。
+type AdminServer struct {
+	Handler *gin.Engine
+	cfg     atomic.Pointer[conf.Config]
+	server  *http.Server
+}
+
+// AdminRoute 是 /admin/routes 输出的一条路由 (gin.RouteInfo 中的处理函数无法序列化为 JSON)。
+type AdminRoute struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// logLevelRequest 是 PUT /admin/loglevel 的请求体。
+type logLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// NewAdminServer 创建管理端口。engine 是主服务的 Gin 引擎，应在所有路由注册完成后调用；plugins 可以为 nil。
+func NewAdminServer(cfg *conf.Config, engine *gin.Engine, plugins *PluginManager) (*AdminServer, error) {
+	if cfg.Admin.Token == "" {
+		return nil, errors.New("admin token is required")
+	}
+	s := &AdminServer{Handler: gin.New()}
+	s.cfg.Store(cfg)
+
+	publishBuildInfo.Do(func() {
+		expvar.Publish("buildinfo", expvar.Func(func() interface{} { return buildinfo.Get() }))
+	})
+
+	r := s.Handler
+	r.Use(RecoveryWithZap(GetLogger()), adminAuth(cfg.Admin.Token))
+
+	if cfg.Admin.Pprof {
+		r.GET("/debug/pprof/*name", gin.WrapF(servePprof))
+		r.POST("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+	}
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+
+	r.GET("/admin/routes", func(c *gin.Context) {
+		routes := engine.Routes()
+		list := make([]AdminRoute, 0, len(routes))
+		for _, route := range routes {
+			list = append(list, AdminRoute{Method: route.Method, Path: route.Path, Handler: route.Handler})
+		}
+		c.JSON(http.StatusOK, list)
+	})
+	r.GET("/admin/plugins", func(c *gin.Context) {
+		if plugins == nil {
+			c.JSON(http.StatusOK, []PluginStatus{})
+			return
+		}
+		c.JSON(http.StatusOK, plugins.Status(s.cfg.Load()))
+	})
+	r.GET("/admin/config", func(c *gin.Context) {
+		c.JSON(http.StatusOK, RedactedConfig(s.cfg.Load()))
+	})
+	r.GET("/admin/buildinfo", func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get())
+	})
+	r.GET("/admin/loglevel", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": LogLevel().String()})
+	})
+	r.PUT("/admin/loglevel", func(c *gin.Context) {
+		var req logLevelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			validation.Error(c, err).JSON(c)
+			return
+		}
+		old := LogLevel()
+		if err := SetLogLevel(req.Level); err != nil {
+			errs.BadRequest.WrapWithMessage(err, "无效的日志级别: %s", req.Level).JSON(c)
+			return
+		}
+		GetLogger().Warn("Log level changed via admin endpoint",
+			zap.String("from", old.String()), zap.String("to", LogLevel().String()))
+		c.JSON(http.StatusOK, gin.H{"level": LogLevel().String()})
+	})
+	return s, nil
+}
+
+// SetConfig 替换 /admin/config 和 /admin/plugins 输出的配置，用于配置热重载。
+func (s *AdminServer) SetConfig(cfg *conf.Config) {
+	s.cfg.Store(cfg)
+}
+
+// SubscribeAdmin 在配置热重载时更新管理端口输出的生效配置。
+// 注意 logger.level 热重载后会覆盖通过 /admin/loglevel 设置的级别。
+func SubscribeAdmin(watcher *ConfigWatcher, admin *AdminServer) {
+	watcher.Subscribe(func(_, new *conf.Config) {
+		admin.SetConfig(new)
+	})
+}
+
+// Start 监听 admin.addr 并在后台提供服务，监听失败时直接返回错误。
+func (s *AdminServer) Start() error {
+	addr := s.cfg.Load().Admin.Addr
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("admin server listen on %s failed: %w", addr, err)
+	}
+	// 不设置 WriteTimeout: /debug/pprof/profile 和 trace 需要持续 seconds 参数指定的时间
+	s.server = &http.Server{Handler: s.Handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			GetLogger().Error("Admin server stopped unexpectedly", zap.Error(err))
+		}
+	}()
+	GetLogger().Info("Admin server started", zap.String("address", ln.Addr().String()))
+	return nil
+}
+
+// Shutdown 优雅关闭管理端口，未启动时直接返回。
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
+
+// adminAuth 校验 Authorization: Bearer <token>，使用常量时间比较。
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			errs.Unauthorized.JSON(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// servePprof 将 /debug/pprof/<name> 分发到 net/http/pprof 的处理函数，
+// 其余名称 (heap、goroutine 等) 由 pprof.Index 按路径处理。
+func servePprof(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
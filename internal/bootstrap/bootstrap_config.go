@@ -1,54 +1,107 @@
 package bootstrap
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath" // 确保导入
+	"reflect"
+	"strings"
 
 	"github.com/spf13/viper"
 	"myGin/internal/conf" // 使用 go.mod 中定义的模块路径 tongcheng
 )
 
-// LoadConfig 从指定路径加载配置
+// defaultEnvPrefix 是覆盖配置项的环境变量前缀，例如 APP_DATABASE_DSN 覆盖 database.dsn。
+const defaultEnvPrefix = "APP"
+
+// ConfigOptions 控制配置的加载方式，通常由命令行参数解析得到。
+type ConfigOptions struct {
+	ConfigFile  string   // --config: 基础配置文件路径，为空时在默认搜索路径中查找 config.yml
+	Env         string   // --env: 运行环境，决定额外加载的 config.<env>.yml
+	Overrides   []string // --set: key=value 形式的覆盖项，优先级最高
+	EnvPrefix   string   // 环境变量前缀，默认 "APP"
+	PrintConfig bool     // --print-config: 打印脱敏后的生效配置并退出
+	PrintErrors bool     // --print-errors: 以 JSON 打印错误码目录并退出
+}
+
+// overrideFlag 实现 flag.Value，支持重复使用 --set。
+type overrideFlag []string
+
+func (o *overrideFlag) String() string { return strings.Join(*o, ",") }
+
+func (o *overrideFlag) Set(value string) error {
+	if _, _, ok := strings.Cut(value, "="); !ok {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// ParseConfigFlags 从命令行参数 (不含程序名) 中解析配置选项。
+func ParseConfigFlags(args []string) (ConfigOptions, error) {
+	var opts ConfigOptions
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config", "", "基础配置文件路径 (默认在 ./configs, ../configs, ../../configs 中查找 config.yml)")
+	fs.StringVar(&opts.Env, "env", "", "运行环境，额外加载 config.<env>.yml (默认取 APP_ENV 或 app.env)")
+	fs.Var((*overrideFlag)(&opts.Overrides), "set", "覆盖单个配置项，格式 key=value，可重复，例如 --set server.addr=:9090")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "打印合并后的生效配置 (敏感字段已脱敏) 并退出")
+	fs.BoolVar(&opts.PrintErrors, "print-errors", false, "以 JSON 打印错误码目录 (供客户端 SDK 生成错误枚举) 并退出")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// LoadConfig 使用默认选项加载配置
 // 默认尝试从运行目录下的 ./configs/config.yml 加载
 func LoadConfig() (*conf.Config, error) {
-	v := viper.New()
-	v.SetConfigName("config") // 配置文件名 (不带扩展名)
-	v.SetConfigType("yaml")   // 文件类型
+	return LoadConfigWithOptions(ConfigOptions{})
+}
 
-	// 添加搜索路径，viper 会按顺序查找
-	v.AddConfigPath("./configs")      // 运行目录下的 configs
-	v.AddConfigPath("../configs")     // 上一级目录的 configs (例如从 cmd/server 运行)
-	v.AddConfigPath("../../configs") // 再上一级目录的 configs
+// LoadConfigWithOptions 按以下优先级 (后者覆盖前者) 加载配置:
+//  1. 基础配置文件 config.yml
+//  2. 与基础配置文件同目录的 config.<env>.yml (不存在时跳过)
+//  3. 带前缀的环境变量，例如 APP_DATABASE_DSN、APP_SERVER_READTIMEOUT
+//  4. --set key=value 覆盖项
+//
+// 合并后解析所有字符串中的密钥引用 (见 resolveSecretRefs)，再执行校验。
+func LoadConfigWithOptions(opts ConfigOptions) (*conf.Config, error) {
+	cfg, _, err := loadConfig(opts)
+	return cfg, err
+}
 
-	// 尝试读取配置文件
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// 配置文件未找到
-			return nil, fmt.Errorf("config file 'config.yaml' not found in search paths: %w", err)
-		}
-		// 其他读取错误
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+// loadConfig 加载并校验配置，同时返回实际使用的基础配置文件路径 (供 ConfigWatcher 监听)。
+func loadConfig(opts ConfigOptions) (*conf.Config, string, error) {
+	v, baseFile, err := newConfigViper(opts)
+	if err != nil {
+		return nil, "", err
 	}
 
-	// 设置默认值 (viper 会在 Unmarshal 前应用它们，如果文件中未指定)
-	// v.SetDefault("server.addr", ":8080")
-	// ...
-
 	var cfg conf.Config
 	// 将配置 unmarshal 到结构体
 	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	// 解析 ${env:...}、${file:...}、${base64:...} 密钥引用，无法解析时启动失败
+	if err := resolveSecretRefs(&cfg); err != nil {
+		return nil, "", err
 	}
 
-	// 可选: 验证配置项 (例如，确保某些必需字段不为空)
+	// 验证配置项 (规则声明在 conf 结构体的 validate 标签上)
+	// 一次性返回所有无效字段，而不是在各插件 Init 时逐个失败
+	if err := ValidateConfig(&cfg); err != nil {
+		return nil, "", err
+	}
 
 	// 创建日志文件所在的目录（如果需要且不存在）
 	if cfg.Logger.File != "" {
 		logDir := filepath.Dir(cfg.Logger.File)
-        // 检查目录是否存在，不存在则创建
+		// 检查目录是否存在，不存在则创建
 		if _, statErr := os.Stat(logDir); os.IsNotExist(statErr) {
-            // 0750 权限: user=rwx, group=rx, other=---
+			// 0750 权限: user=rwx, group=rx, other=---
 			if mkErr := os.MkdirAll(logDir, 0750); mkErr != nil {
 				// 这里暂时打印警告，后续应使用 logger
 				fmt.Fprintf(os.Stderr, "Warning: could not create log directory '%s': %v\n", logDir, mkErr)
@@ -56,6 +109,83 @@ func LoadConfig() (*conf.Config, error) {
 		}
 	}
 
+	return &cfg, baseFile, nil
+}
+
+// newConfigViper 创建 viper 实例并依次合并所有配置层，返回 viper 实例和基础配置文件路径。
+func newConfigViper(opts ConfigOptions) (*viper.Viper, string, error) {
+	v := viper.New()
+	v.SetConfigType("yaml") // 文件类型
+
+	if opts.ConfigFile != "" {
+		v.SetConfigFile(opts.ConfigFile)
+	} else {
+		v.SetConfigName("config") // 配置文件名 (不带扩展名)
+		// 添加搜索路径，viper 会按顺序查找
+		v.AddConfigPath("./configs")      // 运行目录下的 configs
+		v.AddConfigPath("../configs")     // 上一级目录的 configs (例如从 cmd/server 运行)
+		v.AddConfigPath("../../configs") // 再上一级目录的 configs
+	}
+
+	// 设置默认值 (viper 会在 Unmarshal 前应用它们，如果文件中未指定)
+	// 默认值声明在 conf 结构体的 default 标签上
+	registerDefaults(v, reflect.TypeOf(conf.Config{}), "")
+
+	// 1. 读取基础配置文件
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			// 配置文件未找到
+			return nil, "", fmt.Errorf("config file 'config.yml' not found in search paths: %w", err)
+		}
+		// 其他读取错误
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	baseFile := v.ConfigFileUsed()
+
+	prefix := opts.EnvPrefix
+	if prefix == "" {
+		prefix = defaultEnvPrefix
+	}
+
+	// 2. 合并环境专属配置文件
+	env := opts.Env
+	if env == "" {
+		env = os.Getenv(prefix + "_ENV")
+	}
+	if env == "" {
+		env = v.GetString("app.env")
+	}
+	profileFile := filepath.Join(filepath.Dir(baseFile), "config."+env+filepath.Ext(baseFile))
+	if _, err := os.Stat(profileFile); err == nil {
+		v.SetConfigFile(profileFile)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, "", fmt.Errorf("failed to merge profile config %s: %w", profileFile, err)
+		}
+	}
+	v.Set("app.env", env)
+
+	// 3. 环境变量覆盖，键路径中的 "." 替换为 "_"
+	v.SetEnvPrefix(prefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnvs(v, reflect.TypeOf(conf.Config{}), "")
+
+	// 4. 命令行覆盖
+	for _, kv := range opts.Overrides {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, "", fmt.Errorf("invalid --set override %q, expected key=value", kv)
+		}
+		v.Set(key, value)
+	}
+
+	return v, baseFile, nil
+}
 
-	return &cfg, nil
-}
\ No newline at end of file
+// bindEnvs 为配置结构体中的每个叶子键绑定环境变量。
+// viper 的 AutomaticEnv 只对已知的键生效，显式绑定可以覆盖配置文件中未出现的键。
+func bindEnvs(v *viper.Viper, t reflect.Type, prefix string) {
+	walkConfigKeys(t, prefix, func(key string, _ reflect.StructField) {
+		_ = v.BindEnv(key) // 仅在 key 为空时返回错误
+	})
+}
@@ -1,6 +1,8 @@
 package bootstrap
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"myGin/internal/conf"
 	"myGin/internal/plugin"
@@ -9,69 +11,320 @@ import (
 	"go.uber.org/zap"
 )
 
-// PluginFactory 定义了创建插件实例的函数签名。
-type PluginFactory func() plugin.Plugin
+// activePlugin 是成功初始化并注册的插件实例。
+type activePlugin struct {
+	name     string // 实例名称: 默认实例为插件名，具名实例为 "<插件名>/<实例名>"
+	instance string // 具名实例的名称，默认实例为空
+	reg      *plugin.Registration
+	plugin   plugin.Plugin
+	scope    *scopedMiddleware // 插件未提供中间件时为 nil
+	started  bool              // Start 已成功调用
+	stopped  bool              // Stop 已调用，避免重复停止
+}
+
+// PluginManager 按依赖顺序加载插件，并管理它们的 Start/Stop 生命周期。
+// 插件通过 plugin.Register 在自己的包中注册，新增插件无需修改 bootstrap。
+type PluginManager struct {
+	registry *plugin.Registry
+	active   []*activePlugin // 按依赖顺序排列
+	failed   []PluginStatus  // 已启用但加载失败而被跳过的插件
+}
+
+// 插件状态，见 PluginStatus.Status。
+const (
+	PluginStatusLoaded  = "loaded"  // 已加载，未实现 plugin.Starter 或尚未调用 Start
+	PluginStatusRunning = "running" // Start 已成功调用
+	PluginStatusStopped = "stopped" // Stop 已调用
+	PluginStatusFailed  = "failed"  // 已启用但加载失败，Error 为失败原因
+)
+
+// PluginStatus 是插件实例的状态，由 PluginManager.Status 返回。
+type PluginStatus struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	Middleware bool     `json:"middleware"`          // 是否挂载了中间件
+	Include    []string `json:"include,omitempty"`   // 中间件作用范围
+	Exclude    []string `json:"exclude,omitempty"`
+	DependsOn  []string `json:"dependsOn,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// NewPluginManager 创建使用指定注册表的插件管理器。
+func NewPluginManager(registry *plugin.Registry) *PluginManager {
+	return &PluginManager{registry: registry}
+}
 
-// registry 保存插件名称到工厂函数的映射。
-var registry = map[string]PluginFactory{
-	"ratelimit": plugin.NewRateLimitPlugin,
-	"auth":      plugin.NewAuthPlugin,
-	// "swagger":   plugin.NewSwagger,
+// AttachPlugins 使用全局注册表加载配置中启用的插件，是 NewPluginManager(plugin.Default()).Attach 的快捷方式。
+func AttachPlugins(engine *gin.Engine, cfg *conf.Config, dependencies plugin.Deps) (*PluginManager, error) {
+	m := NewPluginManager(plugin.Default())
+	if err := m.Attach(engine, cfg, dependencies); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-// AttachPlugins 遍历配置中启用的模块，创建、初始化并注册插件。
-func AttachPlugins(engine *gin.Engine, cfg *conf.Config, dependencies map[string]interface{}) {
+// Attach 遍历注册表中启用的插件，按依赖拓扑顺序加载:
+//  1. 创建并初始化每个插件实例 (包括 WithInstances 声明的具名实例)；
+//  2. 按相同顺序挂载插件中间件，只作用于配置的 include/exclude 范围；
+//  3. 调用 Register 注册插件自己的路由 (这些路由同样受前面挂载的中间件作用范围控制)。
+//
+// 单个插件创建或初始化失败只会跳过该插件 (以及强依赖它的插件)；存在循环依赖时返回错误。
+// Register 失败时中间件已经挂载，为了不让认证、限流等中间件失效 (放行全部请求)，同样返回错误使启动失败。
+func (m *PluginManager) Attach(engine *gin.Engine, cfg *conf.Config, dependencies plugin.Deps) error {
 	GetLogger().Info("Initializing and registering enabled plugins...") // 使用 GetLogger()
-	enabledCount := 0
-	registeredCount := len(registry)
 
-	// 确保 logger 在依赖项中
-	if _, ok := dependencies["logger"]; !ok {
+	// 确保 logger 和总配置在依赖项中
+	if !dependencies.Has(plugin.DepLogger) {
 		GetLogger().Warn("Logger not found in dependencies map, adding default Nop logger.") // 使用 GetLogger()
-		dependencies["logger"] = zap.NewNop() // 保留 zap.NewNop() 作为后备
+		dependencies[plugin.DepLogger] = zap.NewNop() // 保留 zap.NewNop() 作为后备
 	}
-	// 注意：config 不再需要作为通用依赖传递给 Init，因为每个插件会接收其特定的配置部分。
+	dependencies[plugin.DepConfig] = cfg
 
-	// --- 速率限制插件 ---
-	if cfg.Modules.RateLimit.Enable {
-		// 传递 RateLimitConfig 部分
-		handlePluginLifecycle(engine, "ratelimit", &cfg.Modules.RateLimit, dependencies, &enabledCount)
-	} else {
-		GetLogger().Debug("插件在配置中被禁用", zap.String("pluginName", "ratelimit")) // 使用 GetLogger()
+	// 1. 筛选启用的插件
+	var enabled []string
+	for _, name := range m.registry.Names() {
+		reg, _ := m.registry.Lookup(name)
+		if _, on := pluginConfig(reg, cfg); !on {
+			GetLogger().Debug("插件在配置中被禁用", zap.String("pluginName", name)) // 使用 GetLogger()
+			continue
+		}
+		enabled = append(enabled, name)
 	}
 
-	// --- 认证插件 ---
-	if cfg.Modules.Auth.Enable {
-		// 传递 AuthConfig 部分
-		handlePluginLifecycle(engine, "auth", &cfg.Modules.Auth, dependencies, &enabledCount)
-	} else {
-		GetLogger().Debug("插件在配置中被禁用", zap.String("pluginName", "auth")) // 使用 GetLogger()
+	// 2. 按依赖声明排序
+	ordered, err := m.registry.Sort(enabled)
+	if err != nil {
+		return fmt.Errorf("failed to order plugins: %w", err)
 	}
 
-	// --- 类似地添加其他插件 ---
-	// if cfg.Modules.Swagger.Enable {
-	//     handlePluginLifecycle(engine, "swagger", &cfg.Modules.Swagger, dependencies, &enabledCount)
-	// } else {
-	//     GetLogger().Debug("插件在配置中被禁用", zap.String("pluginName", "swagger")) // 使用 GetLogger()
-	// }
+	// 3. 依次创建并初始化每个插件实例
+	var loaded []*activePlugin
+	for _, reg := range ordered {
+		if err := m.checkRequirements(reg, dependencies); err != nil {
+			GetLogger().Error("插件依赖不满足，跳过。", zap.String("pluginName", reg.Name), zap.Error(err))
+			m.fail(reg.Name, reg, err)
+			continue
+		}
+		for _, inst := range pluginInstances(reg, cfg) {
+			ap := &activePlugin{name: instanceName(reg.Name, inst.Name), instance: inst.Name, reg: reg}
+			ap.plugin = initPlugin(ap.name, reg.Factory, inst.Config, dependencies)
+			if ap.plugin == nil {
+				m.fail(ap.name, reg, errors.New("plugin creation or initialization failed"))
+				continue
+			}
+			if provider, ok := ap.plugin.(plugin.MiddlewareProvider); ok {
+				ap.scope, err = newScopedMiddleware(scopeOf(inst.Config), provider.Middleware())
+				if err != nil {
+					GetLogger().Error("插件作用范围无效，跳过。", zap.String("pluginName", ap.name), zap.Error(err))
+					m.fail(ap.name, reg, err)
+					continue
+				}
+			}
+			dependencies[plugin.PluginDepKey(ap.name)] = ap.plugin
+			loaded = append(loaded, ap)
+		}
+	}
 
-	if enabledCount == 0 {
+	// 4. 挂载中间件 (仅在作用范围内执行)
+	for _, ap := range loaded {
+		if ap.scope != nil {
+			engine.Use(ap.scope.Handle)
+			scope := scopeOf(pluginInstanceConfig(ap, cfg))
+			GetLogger().Info("Plugin middleware attached",
+				zap.String("pluginName", ap.name),
+				zap.Strings("include", scope.Include),
+				zap.Strings("exclude", scope.Exclude))
+		}
+	}
+
+	// 5. 注册插件路由
+	for _, ap := range loaded {
+		if err := registerPlugin(engine, ap.name, ap.plugin); err != nil {
+			return fmt.Errorf("failed to register plugin %s: %w", ap.name, err)
+		}
+		m.active = append(m.active, ap)
+	}
+
+	if len(m.active) == 0 {
 		GetLogger().Info("No plugins were enabled or registered.") // 使用 GetLogger()
 	} else {
-		GetLogger().Info("Finished initializing and registering plugins", zap.Int("registeredCount", enabledCount), zap.Int("availableCount", registeredCount)) // 使用 GetLogger()
+		GetLogger().Info("Finished initializing and registering plugins",
+			zap.Strings("order", m.Names()),
+			zap.Int("registeredCount", len(m.active)),
+			zap.Int("availableCount", len(m.registry.Names()))) // 使用 GetLogger()
 	}
+	return nil
 }
 
-// handlePluginLifecycle 处理单个插件的创建、初始化和注册。
-// moduleCfg 是该插件特定的配置结构指针 (e.g., *conf.AuthConfig)。
-func handlePluginLifecycle(engine *gin.Engine, name string, moduleCfg interface{}, dependencies map[string]interface{}, enabledCount *int) {
-	factory, exists := registry[name]
-	if !exists {
-		GetLogger().Warn("插件在配置中启用，但在静态注册表中未找到工厂函数，跳过。", // 使用 GetLogger()
-			zap.String("pluginName", name))
-		return
+// instanceName 返回插件实例名称。
+func instanceName(pluginName, instance string) string {
+	if instance == "" {
+		return pluginName
 	}
+	return pluginName + "/" + instance
+}
 
+// pluginInstances 返回启用插件的默认实例和通过 WithInstances 声明的具名实例。
+func pluginInstances(reg *plugin.Registration, cfg *conf.Config) []plugin.Instance {
+	moduleCfg, _ := pluginConfig(reg, cfg)
+	instances := []plugin.Instance{{Config: moduleCfg}}
+	if reg.Instances != nil {
+		instances = append(instances, reg.Instances(cfg)...)
+	}
+	return instances
+}
+
+// pluginInstanceConfig 从 cfg 中取出指定插件实例的配置，实例已从配置中删除时返回 nil。
+func pluginInstanceConfig(ap *activePlugin, cfg *conf.Config) interface{} {
+	for _, inst := range pluginInstances(ap.reg, cfg) {
+		if inst.Name == ap.instance {
+			return inst.Config
+		}
+	}
+	return nil
+}
+
+// checkRequirements 检查插件的强依赖插件和共享依赖项是否都已就绪。
+func (m *PluginManager) checkRequirements(reg *plugin.Registration, deps plugin.Deps) error {
+	for _, name := range reg.DependsOn {
+		if !deps.Has(plugin.PluginDepKey(name)) {
+			return fmt.Errorf("depends on plugin %q which is not enabled or failed to load", name)
+		}
+	}
+	for _, name := range reg.Requires {
+		if !deps.Has(name) {
+			return fmt.Errorf("requires dependency %q which is not available", name)
+		}
+	}
+	return nil
+}
+
+// pluginConfig 通过注册时声明的 ConfigFunc 获取插件配置，未声明时插件总是启用。
+func pluginConfig(reg *plugin.Registration, cfg *conf.Config) (interface{}, bool) {
+	if reg.Config == nil {
+		return nil, true
+	}
+	return reg.Config(cfg)
+}
+
+// Names 返回已加载插件的名称，按初始化顺序排列。
+func (m *PluginManager) Names() []string {
+	names := make([]string, 0, len(m.active))
+	for _, p := range m.active {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// fail 记录加载失败的插件，供 Status 输出。
+func (m *PluginManager) fail(name string, reg *plugin.Registration, err error) {
+	m.failed = append(m.failed, PluginStatus{Name: name, Status: PluginStatusFailed, DependsOn: reg.DependsOn, Error: err.Error()})
+}
+
+// Status 返回配置中启用的插件实例的状态: 先是已加载的插件 (按依赖顺序)，然后是加载失败的插件。
+// cfg 用于读取中间件当前的作用范围，为 nil 时不输出作用范围。
+func (m *PluginManager) Status(cfg *conf.Config) []PluginStatus {
+	list := make([]PluginStatus, 0, len(m.active)+len(m.failed))
+	for _, p := range m.active {
+		st := PluginStatus{Name: p.name, Status: PluginStatusLoaded, Middleware: p.scope != nil, DependsOn: p.reg.DependsOn}
+		switch {
+		case p.stopped:
+			st.Status = PluginStatusStopped
+		case p.started:
+			st.Status = PluginStatusRunning
+		}
+		if p.scope != nil && cfg != nil {
+			scope := scopeOf(pluginInstanceConfig(p, cfg))
+			st.Include, st.Exclude = scope.Include, scope.Exclude
+		}
+		list = append(list, st)
+	}
+	return append(list, m.failed...)
+}
+
+// Get 返回已加载的插件实例，name 为插件名或 "<插件名>/<实例名>"。
+func (m *PluginManager) Get(name string) (plugin.Plugin, bool) {
+	for _, p := range m.active {
+		if p.name == name {
+			return p.plugin, true
+		}
+	}
+	return nil, false
+}
+
+// Start 按依赖顺序调用实现了 plugin.Starter 的插件。
+// 任一插件启动失败时，会逆序停止已启动的插件并返回错误。
+func (m *PluginManager) Start(ctx context.Context) error {
+	for _, p := range m.active {
+		starter, ok := p.plugin.(plugin.Starter)
+		if !ok {
+			continue
+		}
+		if err := starter.Start(ctx); err != nil {
+			stopErr := m.Stop(ctx)
+			return errors.Join(fmt.Errorf("plugin %s start failed: %w", p.name, err), stopErr)
+		}
+		p.started = true
+		GetLogger().Info("Plugin started", zap.String("pluginName", p.name))
+	}
+	return nil
+}
+
+// Stop 按依赖的逆序调用已启动的、实现了 plugin.Stopper 的插件，汇总所有错误。
+func (m *PluginManager) Stop(ctx context.Context) error {
+	var errList []error
+	for i := len(m.active) - 1; i >= 0; i-- {
+		p := m.active[i]
+		stopper, ok := p.plugin.(plugin.Stopper)
+		_, isStarter := p.plugin.(plugin.Starter)
+		if !ok || p.stopped || (isStarter && !p.started) {
+			continue
+		}
+		p.stopped = true
+		if err := stopper.Stop(ctx); err != nil {
+			errList = append(errList, fmt.Errorf("plugin %s stop failed: %w", p.name, err))
+			continue
+		}
+		GetLogger().Info("Plugin stopped", zap.String("pluginName", p.name))
+	}
+	return errors.Join(errList...)
+}
+
+// Reconfigure 将热重载后的配置传递给实现了 plugin.Reconfigurable 的插件，并更新中间件的作用范围。
+// 新增或删除插件实例需要重启才能生效。
+func (m *PluginManager) Reconfigure(cfg *conf.Config) {
+	for _, p := range m.active {
+		moduleCfg := pluginInstanceConfig(p, cfg)
+		if moduleCfg == nil {
+			GetLogger().Warn("插件实例已从配置中移除，需要重启后生效", zap.String("pluginName", p.name))
+			continue
+		}
+		if p.scope != nil {
+			if err := p.scope.setScope(scopeOf(moduleCfg)); err != nil {
+				GetLogger().Error("插件作用范围无效，继续使用旧的作用范围", zap.String("pluginName", p.name), zap.Error(err))
+			}
+		}
+		r, ok := p.plugin.(plugin.Reconfigurable)
+		if !ok {
+			continue
+		}
+		if err := r.Reconfigure(moduleCfg); err != nil {
+			GetLogger().Error("插件应用新配置失败，继续使用旧配置", zap.String("pluginName", p.name), zap.Error(err))
+		}
+	}
+}
+
+// SubscribePlugins 在配置热重载时，将新的模块配置传递给实现了 plugin.Reconfigurable 的插件。
+func SubscribePlugins(watcher *ConfigWatcher, plugins *PluginManager) {
+	watcher.Subscribe(func(_, new *conf.Config) {
+		plugins.Reconfigure(new)
+	})
+}
+
+// initPlugin 创建并初始化单个插件实例，捕获 panic。
+// moduleCfg 是该插件特定的配置结构指针 (e.g., *conf.AuthConfig)。
+// 成功时返回插件实例，任一阶段失败时返回 nil。
+func initPlugin(name string, factory plugin.Factory, moduleCfg interface{}, dependencies plugin.Deps) plugin.Plugin {
 	GetLogger().Info("Processing plugin...", zap.String("pluginName", name)) // 使用 GetLogger()
 
 	var pluginInstance plugin.Plugin
@@ -94,7 +347,7 @@ func handlePluginLifecycle(engine *gin.Engine, name string, moduleCfg interface{
 
 	if pluginInstance == nil {
 		GetLogger().Error("创建尝试后插件实例为 nil，跳过。", zap.String("pluginName", name)) // 使用 GetLogger()
-		return
+		return nil
 	}
 
 	// 2. 初始化插件
@@ -118,11 +371,14 @@ func handlePluginLifecycle(engine *gin.Engine, name string, moduleCfg interface{
 			zap.String("pluginName", name),
 			zap.Error(err),
 		)
-		return
+		return nil
 	}
 	GetLogger().Debug("插件初始化成功", zap.String("pluginName", name)) // 使用 GetLogger()
+	return pluginInstance
+}
 
-	// 3. 注册插件 (例如，注册中间件或路由)
+// registerPlugin 调用插件的 Register 注册路由，捕获 panic。
+func registerPlugin(engine *gin.Engine, name string, pluginInstance plugin.Plugin) (err error) {
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -142,9 +398,9 @@ func handlePluginLifecycle(engine *gin.Engine, name string, moduleCfg interface{
 			zap.String("pluginName", name),
 			zap.Error(err),
 		)
-		return // 注册失败，不增加计数
+		return err // 注册失败，Attach 返回错误中止启动
 	}
 
-	*enabledCount++
 	GetLogger().Info("成功初始化并注册插件", zap.String("pluginName", name)) // 使用 GetLogger()
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,70 @@
+package bootstrap
+
+import (
+	"fmt"
+	"reflect"
+
+	"myGin/internal/conf" // 模块路径
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue 是脱敏后敏感字段的占位符。
+const redactedValue = "******"
+
+// RedactedConfig 将配置转换为以配置键为 key 的嵌套 map，
+// 带有 secret:"true" 标签或由密钥引用解析得到的非空字段会被替换为 "******"。
+// 结果可直接序列化为 YAML 或 JSON，用于打印或通过接口输出生效配置。
+func RedactedConfig(cfg *conf.Config) map[string]interface{} {
+	return redactStruct(reflect.ValueOf(*cfg), "", secretPathSet(cfg))
+}
+
+// DumpConfig 返回脱敏后的生效配置 (YAML 格式)。
+func DumpConfig(cfg *conf.Config) (string, error) {
+	out, err := yaml.Marshal(RedactedConfig(cfg))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// redactStruct 递归地将结构体转换为 map，并对 secrets 中的配置路径脱敏。
+func redactStruct(v reflect.Value, prefix string, secrets map[string]struct{}) map[string]interface{} {
+	t := v.Type()
+	out := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, ok := configFieldName(f)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if squash {
+			for k, val := range redactStruct(fv, prefix, secrets) {
+				out[k] = val
+			}
+			continue
+		}
+		key := joinConfigKey(prefix, name)
+
+		switch {
+		case isSecretPath(secrets, key):
+			if fv.IsZero() {
+				out[name] = fv.Interface() // 空值不算泄露，保留以便排查 "未配置" 问题
+			} else {
+				out[name] = redactedValue
+			}
+		case fv.Kind() == reflect.Struct:
+			out[name] = redactStruct(fv, key, secrets)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			items := make([]interface{}, fv.Len())
+			for j := 0; j < fv.Len(); j++ {
+				items[j] = redactStruct(fv.Index(j), fmt.Sprintf("%s[%d]", key, j), secrets)
+			}
+			out[name] = items
+		default:
+			out[name] = fv.Interface()
+		}
+	}
+	return out
+}
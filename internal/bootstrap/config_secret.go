@@ -0,0 +1,184 @@
+package bootstrap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"myGin/internal/conf" // 模块路径
+)
+
+// secretRefPattern 匹配配置字符串中的密钥引用，例如 ${env:JWT_SECRET}、${file:/run/secrets/db_dsn}、${base64:cGFzcw==}。
+// 引用可以是完整的值，也可以嵌入在字符串中，例如 "user:${env:DB_PASSWORD}@tcp(db:3306)/app"。
+var secretRefPattern = regexp.MustCompile(`\$\{([a-zA-Z0-9_]+):([^}]*)\}`)
+
+// secretResolvers 保存引用前缀到解析函数的映射。
+var secretResolvers = map[string]func(arg string) (string, error){
+	"env":    resolveEnvRef,
+	"file":   resolveFileRef,
+	"base64": resolveBase64Ref,
+}
+
+// resolveEnvRef 读取环境变量，未设置时返回错误 (设置为空字符串视为有效值)。
+func resolveEnvRef(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveFileRef 读取文件内容，并去掉末尾的换行符 (Docker/Kubernetes secret 文件通常以换行结尾)。
+func resolveFileRef(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveBase64Ref 解码标准 base64 编码的值。
+func resolveBase64Ref(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	return string(data), nil
+}
+
+// resolveSecretRefs 解析配置中所有字符串 (包括字符串切片和结构体切片) 里的密钥引用，
+// 并将被解析的配置路径记录到 cfg.SecretPaths，使打印或输出配置时这些值被脱敏。
+// 任一引用无法解析时返回 *ConfigError，列出所有失败的配置路径。
+func resolveSecretRefs(cfg *conf.Config) error {
+	cfg.SecretPaths = nil
+	cfgErr := &ConfigError{}
+
+	walkConfigStrings(reflect.ValueOf(cfg).Elem(), "", func(path string, s reflect.Value) {
+		if !strings.Contains(s.String(), "${") {
+			return
+		}
+		resolved, changed, err := resolveSecretString(s.String())
+		if err != nil {
+			cfgErr.Fields = append(cfgErr.Fields, FieldError{Path: path, Message: err.Error()})
+			return
+		}
+		if changed {
+			s.SetString(resolved)
+			cfg.SecretPaths = append(cfg.SecretPaths, path)
+		}
+	})
+
+	if len(cfgErr.Fields) > 0 {
+		return cfgErr
+	}
+	return nil
+}
+
+// resolveSecretString 替换字符串中的所有引用，changed 表示是否存在引用。
+func resolveSecretString(s string) (resolved string, changed bool, err error) {
+	resolved = secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		m := secretRefPattern.FindStringSubmatch(ref)
+		resolver, ok := secretResolvers[m[1]]
+		if !ok {
+			err = fmt.Errorf("unknown secret reference %q, supported: ${env:NAME}, ${file:PATH}, ${base64:VALUE}", ref)
+			return ref
+		}
+		value, rerr := resolver(m[2])
+		if rerr != nil {
+			err = fmt.Errorf("cannot resolve %s: %w", ref, rerr)
+			return ref
+		}
+		changed = true
+		return value
+	})
+	return resolved, changed, err
+}
+
+// walkConfigStrings 递归遍历配置结构体中所有可修改的字符串值。
+// 字符串切片的元素使用字段路径 (例如 "server.tls.cipherSuites")，
+// 结构体切片的元素使用带下标的路径 (例如 "logger.access.sampling[0].route")。
+func walkConfigStrings(v reflect.Value, prefix string, fn func(path string, s reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, ok := configFieldName(f)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if squash {
+			walkConfigStrings(fv, prefix, fn)
+			continue
+		}
+		key := joinConfigKey(prefix, name)
+
+		switch fv.Kind() {
+		case reflect.String:
+			fn(key, fv)
+		case reflect.Struct:
+			walkConfigStrings(fv, key, fn)
+		case reflect.Slice:
+			for j := 0; j < fv.Len(); j++ {
+				elem := fv.Index(j)
+				switch elem.Kind() {
+				case reflect.String:
+					fn(key, elem)
+				case reflect.Struct:
+					walkConfigStrings(elem, fmt.Sprintf("%s[%d]", key, j), fn)
+				}
+			}
+		}
+	}
+}
+
+// joinConfigKey 拼接配置键路径。
+func joinConfigKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// secretPathSet 返回需要脱敏的配置路径: 带 secret:"true" 标签的字段和由密钥引用解析得到的字段。
+// 结构体切片中的字段不带下标 (例如 "modules.auth.users.passwordHash")，对所有元素生效。
+func secretPathSet(cfg *conf.Config) map[string]struct{} {
+	set := make(map[string]struct{}, len(cfg.SecretPaths))
+	var collect func(key string, f reflect.StructField)
+	collect = func(key string, f reflect.StructField) {
+		if f.Tag.Get("secret") == "true" {
+			set[key] = struct{}{}
+		}
+		if f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct {
+			walkConfigKeys(f.Type.Elem(), key, collect)
+		}
+	}
+	walkConfigKeys(reflect.TypeOf(*cfg), "", collect)
+	for _, p := range cfg.SecretPaths {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+// sliceIndexPattern 匹配配置路径中的切片下标，例如 "users[0]" 中的 "[0]"。
+var sliceIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// isSecretPath 判断配置路径是否需要脱敏，切片元素路径 (例如 "a.b[0]") 按所属字段判断，
+// 结构体切片元素的字段 (例如 "a.users[1].passwordHash") 按去掉下标的字段路径判断。
+func isSecretPath(set map[string]struct{}, path string) bool {
+	if _, ok := set[path]; ok {
+		return true
+	}
+	if i := strings.LastIndexByte(path, '['); i > 0 && strings.HasSuffix(path, "]") {
+		if _, ok := set[path[:i]]; ok {
+			return true
+		}
+	}
+	_, ok := set[sliceIndexPattern.ReplaceAllString(path, "")]
+	return ok
+}
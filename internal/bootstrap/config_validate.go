@@ -0,0 +1,223 @@
+package bootstrap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"myGin/internal/conf" // 模块路径
+	"myGin/internal/pkg/pathmatch"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+)
+
+// FieldError 描述单个无效的配置项。
+type FieldError struct {
+	Path    string // 配置键路径, 例如 "modules.auth.secret"
+	Message string // 错误说明
+}
+
+// ConfigError 汇总所有无效的配置项，便于一次性修正。
+type ConfigError struct {
+	Fields []FieldError
+}
+
+// Error 实现了 error 接口，每个无效字段占一行。
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d error(s)):", len(e.Fields))
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "\n  - %s: %s", f.Path, f.Message)
+	}
+	return b.String()
+}
+
+// configValidator 是配置专用的校验器，与 gin 的请求参数校验器相互独立。
+var configValidator = newConfigValidator()
+
+// newConfigValidator 创建校验器并注册自定义规则。
+func newConfigValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// 使用 mapstructure 标签作为字段名，使错误路径与 config.yml 中的键一致
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, squash, ok := configFieldName(f)
+		switch {
+		case squash:
+			return squashedFieldPrefix + f.Name // 由 configPath 从错误路径中去掉
+		case !ok:
+			return f.Name
+		}
+		return name
+	})
+
+	// duration: 非负且可被 time.ParseDuration 解析
+	_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		d, err := time.ParseDuration(fl.Field().String())
+		return err == nil && d >= 0
+	})
+
+	// cipher_suite: tls.CipherSuites() 中的安全密码套件名称
+	secureSuites := make(map[string]struct{})
+	for _, cs := range tls.CipherSuites() {
+		secureSuites[cs.Name] = struct{}{}
+	}
+	_ = v.RegisterValidation("cipher_suite", func(fl validator.FieldLevel) bool {
+		_, ok := secureSuites[fl.Field().String()]
+		return ok
+	})
+
+	// path_pattern: pathmatch 可编译的路径模式
+	_ = v.RegisterValidation("path_pattern", func(fl validator.FieldLevel) bool {
+		_, err := pathmatch.Compile(fl.Field().String())
+		return err == nil
+	})
+
+	return v
+}
+
+// ValidateConfig 按 conf 结构体上的 validate 标签校验配置。
+// 返回 *ConfigError，其中列出所有无效字段的配置路径。
+func ValidateConfig(cfg *conf.Config) error {
+	err := configValidator.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	secrets := secretPathSet(cfg)
+	cfgErr := &ConfigError{}
+	for _, fe := range verrs {
+		path := configPath(fe.Namespace())
+		cfgErr.Fields = append(cfgErr.Fields, FieldError{
+			Path:    path,
+			Message: describeFieldError(fe, isSecretPath(secrets, path)),
+		})
+	}
+	return cfgErr
+}
+
+// squashedFieldPrefix 标记嵌入 (mapstructure ",squash") 的结构体字段，它们不占用配置路径层级。
+const squashedFieldPrefix = "~"
+
+// configPath 将校验器的命名空间 (例如 "Config.modules.auth.secret") 转换为配置路径。
+func configPath(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	kept := parts[:0]
+	for i, part := range parts {
+		if i == 0 || strings.HasPrefix(part, squashedFieldPrefix) {
+			continue // 去掉顶层结构体名称和嵌入结构体名称
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, ".")
+}
+
+// describeFieldError 将校验失败的规则转换为可读的错误说明。
+// redact 为 true 时 (敏感字段) 不在说明中输出字段值。
+func describeFieldError(fe validator.FieldError, redact bool) string {
+	var value interface{} = fe.Value()
+	if redact {
+		value = redactedValue
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		// 参数为 "Field1 value1 Field2 value2 ..."，所有条件同时满足时必填
+		params := strings.Fields(fe.Param())
+		var conds []string
+		for i := 0; i+1 < len(params); i += 2 {
+			conds = append(conds, fmt.Sprintf("%s is %q", lowerFirst(params[i]), params[i+1]))
+		}
+		return "is required when " + strings.Join(conds, " and ")
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", lowerFirst(fe.Param()))
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", lowerFirst(fe.Param()))
+	case "startswith":
+		return fmt.Sprintf("must start with %q, got %q", fe.Param(), value)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s, got %v", fe.Param(), value)
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s, got %v", fe.Param(), value)
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s, got %v", fe.Param(), value)
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fe.Param(), value)
+	case "duration":
+		return fmt.Sprintf("must be a non-negative duration such as \"15s\" or \"1m\", got %q", value)
+	case "path_pattern":
+		return fmt.Sprintf("invalid path pattern %q, expected e.g. \"/api/v1/**\" or \"/users/:id\"", value)
+	case "cipher_suite":
+		return fmt.Sprintf("unknown or insecure cipher suite %q", value)
+	default:
+		return fmt.Sprintf("failed on rule %q", fe.Tag())
+	}
+}
+
+// lowerFirst 将 Go 字段名转换为配置键风格 (例如 "ClientAuth" -> "clientAuth")。
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// registerDefaults 遍历配置结构体，将 default 标签注册为 viper 默认值。
+// prefix 是当前结构体在配置中的键路径，顶层调用时传入空字符串。
+func registerDefaults(v *viper.Viper, t reflect.Type, prefix string) {
+	walkConfigKeys(t, prefix, func(key string, f reflect.StructField) {
+		if def, ok := f.Tag.Lookup("default"); ok {
+			v.SetDefault(key, def) // viper 在 Unmarshal 时会将字符串转换为目标类型
+		}
+	})
+}
+
+// configFieldName 解析字段的 mapstructure 标签。
+// squash 为 true 表示嵌入结构体 (mapstructure:",squash")，其字段直接属于外层配置节；
+// ok 为 false 表示字段不对应配置键。
+func configFieldName(f reflect.StructField) (name string, squash, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+	if name == "" && f.Anonymous && opts == "squash" && f.Type.Kind() == reflect.Struct {
+		return "", true, true
+	}
+	if name == "" || name == "-" {
+		return "", false, false
+	}
+	return name, false, true
+}
+
+// walkConfigKeys 递归遍历配置结构体的叶子字段，回调参数为完整键路径 (例如 "server.tls.certFile")。
+// 切片和 map 视为叶子字段，不展开。
+func walkConfigKeys(t reflect.Type, prefix string, fn func(key string, f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash, ok := configFieldName(f)
+		if !ok {
+			continue
+		}
+		if squash {
+			walkConfigKeys(f.Type, prefix, fn)
+			continue
+		}
+		key := joinConfigKey(prefix, name)
+
+		if f.Type.Kind() == reflect.Struct {
+			walkConfigKeys(f.Type, key, fn)
+			continue
+		}
+		fn(key, f)
+	}
+}
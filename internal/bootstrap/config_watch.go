@@ -0,0 +1,185 @@
+package bootstrap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myGin/internal/conf" // 模块路径
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// configReloadDebounce 合并短时间内的多次文件事件 (编辑器保存、k8s ConfigMap 原子替换都会产生多个事件)。
+const configReloadDebounce = 200 * time.Millisecond
+
+// ConfigSubscriber 在配置成功重载后被调用，old 和 new 均已通过校验且不会被修改。
+type ConfigSubscriber func(old, new *conf.Config)
+
+// ConfigWatcher 监听配置文件变更并重新加载配置。
+// 重载使用与启动时相同的 ConfigOptions (环境变量和 --set 覆盖仍然生效)；
+// 新配置校验失败时保留旧配置，只记录错误日志。
+type ConfigWatcher struct {
+	opts     ConfigOptions
+	baseFile string
+	logger   *zap.Logger
+
+	current atomic.Pointer[conf.Config]
+
+	mu          sync.Mutex // 保护 subscribers，并串行化重载
+	subscribers []ConfigSubscriber
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewConfigWatcher 创建配置监听器，initial 是启动时由 LoadConfigWithOptions(opts) 加载的配置。
+// 创建后需调用 Start 开始监听文件变更。
+func NewConfigWatcher(opts ConfigOptions, initial *conf.Config, logger *zap.Logger) (*ConfigWatcher, error) {
+	_, baseFile, err := newConfigViper(opts)
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	w := &ConfigWatcher{opts: opts, baseFile: baseFile, logger: logger}
+	w.current.Store(initial)
+	return w, nil
+}
+
+// Current 返回当前生效的配置。
+func (w *ConfigWatcher) Current() *conf.Config {
+	return w.current.Load()
+}
+
+// Subscribe 注册配置变更回调，回调按注册顺序同步执行。
+func (w *ConfigWatcher) Subscribe(fn ConfigSubscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start 开始监听基础配置文件所在目录。
+// 监听目录而不是文件本身，以便支持原子替换 (rename) 和环境专属配置文件 config.<env>.yml。
+func (w *ConfigWatcher) Start() error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	dir := filepath.Dir(w.baseFile)
+	if err := fw.Add(dir); err != nil {
+		_ = fw.Close()
+		return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+	}
+	w.watcher = fw
+	w.done = make(chan struct{})
+	go w.loop()
+
+	w.logger.Info("Config hot-reload enabled", zap.String("dir", dir))
+	return nil
+}
+
+// Stop 停止监听。
+func (w *ConfigWatcher) Stop() {
+	if w.watcher == nil {
+		return
+	}
+	_ = w.watcher.Close()
+	<-w.done
+	w.watcher = nil
+}
+
+// loop 处理文件事件，去抖后触发重载。
+func (w *ConfigWatcher) loop() {
+	defer close(w.done)
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.isConfigFile(ev.Name) || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(configReloadDebounce, func() { _ = w.Reload() })
+			} else {
+				timer.Reset(configReloadDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warn("Config watcher error", zap.Error(err))
+		}
+	}
+}
+
+// isConfigFile 判断事件文件是否为基础配置文件或其环境专属配置文件 (config.<env>.yml)。
+func (w *ConfigWatcher) isConfigFile(name string) bool {
+	base := filepath.Base(w.baseFile)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	file := filepath.Base(name)
+	return file == base || (strings.HasPrefix(file, stem+".") && strings.HasSuffix(file, ext))
+}
+
+// Reload 立即重新加载配置。加载或校验失败时返回错误并保留当前配置；
+// 成功时替换当前配置并依次通知所有订阅者。
+func (w *ConfigWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, _, err := loadConfig(w.opts)
+	if err != nil {
+		w.logger.Error("配置重载失败，继续使用旧配置", zap.Error(err))
+		return err
+	}
+
+	old := w.current.Swap(next)
+	w.logger.Info("Config reloaded", zap.String("file", w.baseFile))
+	for _, fn := range w.subscribers {
+		w.notify(fn, old, next)
+	}
+	return nil
+}
+
+// notify 调用单个订阅者，panic 不会影响其他订阅者。
+func (w *ConfigWatcher) notify(fn ConfigSubscriber, old, next *conf.Config) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("Panic recovered in config subscriber", zap.Any("panicValue", r), zap.Stack("stacktrace"))
+		}
+	}()
+	fn(old, next)
+}
+
+// LoggerConfigSubscriber 在 logger.level 变化时调整全局日志级别。
+// 日志文件、切割等其他 logger 配置需要重启才能生效。
+func LoggerConfigSubscriber(old, new *conf.Config) {
+	if old.Logger.Level != new.Logger.Level {
+		if err := SetLogLevel(new.Logger.Level); err != nil {
+			GetLogger().Error("Failed to apply log level", zap.Error(err))
+			return
+		}
+		GetLogger().Info("Log level changed", zap.String("from", old.Logger.Level), zap.String("to", new.Logger.Level))
+	}
+
+	o, n := old.Logger, new.Logger
+	if o.File != n.File || o.MaxSize != n.MaxSize || o.MaxBackups != n.MaxBackups || o.MaxAge != n.MaxAge || o.Compress != n.Compress {
+		GetLogger().Warn("logger 配置中除 level 外的变更需要重启后生效")
+	}
+}
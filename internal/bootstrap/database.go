@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"myGin/internal/conf" // 模块路径
+	"myGin/internal/pkg/tracing"
 
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql" // 初始假设使用 MySQL
@@ -55,7 +56,7 @@ func InitDB(cfg conf.DatabaseConfig) (*gorm.DB, func(), error) {
 		IgnoreRecordNotFoundError: true,                   // 不将 'record not found' 错误记录为 Error 级别
 		Colorful:                  false,                  // 禁用 JSON/结构化日志的彩色输出
 	}
-	gormZapLogger := newZapGormLogger(GetLogger(), gormLogCfg) // 使用 GetLogger()
+	gormZapLogger := NewGormLogger(GetLogger(), gormLogCfg) // 使用 GetLogger()
 
 	// 打开数据库连接
 	db, err := gorm.Open(dialector, &gorm.Config{
@@ -125,8 +126,8 @@ type zapGormLogger struct {
 	slowThreshold time.Duration
 }
 
-// newZapGormLogger 创建一个新的 GORM 日志记录器实例。
-func newZapGormLogger(logger *zap.Logger, config gormlogger.Config) gormlogger.Interface { // 更新了签名以接受配置
+// NewGormLogger 创建一个新的 GORM 日志记录器实例，日志带有 ctx 中的 trace_id / span_id。
+func NewGormLogger(logger *zap.Logger, config gormlogger.Config) gormlogger.Interface { // 更新了签名以接受配置
 	return &zapGormLogger{
 		zapLogger:     logger.WithOptions(zap.AddCallerSkip(4)), // 调整跳过的调用层级以获取准确的调用者信息
 		gormLogLevel:  config.LogLevel,
@@ -145,24 +146,32 @@ func (l *zapGormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface
 // Info 记录信息性消息。
 func (l *zapGormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
 	if l.gormLogLevel >= gormlogger.Info {
-		l.zapLogger.Sugar().Infow(msg, data...)
+		l.withTrace(ctx).Sugar().Infow(msg, data...)
 	}
 }
 
 // Warn 记录警告消息。
 func (l *zapGormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
 	if l.gormLogLevel >= gormlogger.Warn {
-		l.zapLogger.Sugar().Warnw(msg, data...)
+		l.withTrace(ctx).Sugar().Warnw(msg, data...)
 	}
 }
 
 // Error 记录错误消息。
 func (l *zapGormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
 	if l.gormLogLevel >= gormlogger.Error {
-		l.zapLogger.Sugar().Errorw(msg, data...)
+		l.withTrace(ctx).Sugar().Errorw(msg, data...)
 	}
 }
 
+// withTrace 返回附带 ctx 中 trace_id / span_id 的 logger，ctx 中没有 span 时返回原 logger。
+func (l *zapGormLogger) withTrace(ctx context.Context) *zap.Logger {
+	if fields := tracing.LogFields(ctx); len(fields) > 0 {
+		return l.zapLogger.With(fields...)
+	}
+	return l.zapLogger
+}
+
 // Trace 记录 SQL 查询和执行详情。
 func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	if l.gormLogLevel <= gormlogger.Silent {
@@ -176,6 +185,7 @@ func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 		zap.Int64("rows", rows),
 		zap.String("sql", sql),
 	}
+	fields = append(fields, tracing.LogFields(ctx)...)
 
 	// 记录错误（除非级别为 Info，否则排除 ErrRecordNotFound）或慢查询
 	// 检查初始化时传递的 GORM 配置中的 IgnoreRecordNotFoundError（尽管目前在 InitDB 中是硬编码的）
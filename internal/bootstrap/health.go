@@ -0,0 +1,121 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"myGin/internal/conf"       // 模块路径
+	"myGin/internal/pkg/health" // 健康检查注册表
+	"myGin/internal/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// InitHealth 根据配置创建健康检查注册表，并为已启用的依赖注册检查器。
+// db 或 rdb 为 nil (未启用或初始化失败) 时的处理：
+//   - 未启用的依赖不注册，不影响就绪状态；
+//   - 已启用但初始化失败的依赖注册一个始终失败的检查器，使就绪检查为 false。
+func InitHealth(cfg *conf.Config, db *gorm.DB, rdb *redis.Client) *health.Registry {
+	timeout := parseDurationOr(cfg.Health.Timeout, 2*time.Second, "health.timeout")
+	cacheTTL := parseDurationOr(cfg.Health.CacheTTL, 0, "health.cacheTTL")
+	registry := health.NewRegistry(timeout, cacheTTL)
+
+	if cfg.Database.Enable {
+		registry.Register(health.NewChecker("database", func(ctx context.Context) error {
+			if db == nil {
+				return errDependencyUnavailable
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}))
+	}
+
+	if cfg.Redis.Enable {
+		registry.Register(health.NewChecker("redis", func(ctx context.Context) error {
+			if rdb == nil {
+				return errDependencyUnavailable
+			}
+			return rdb.Ping(ctx).Err()
+		}))
+	}
+
+	GetLogger().Info("Health checks initialized",
+		zap.Strings("checkers", registry.Names()),
+		zap.Duration("timeout", timeout),
+		zap.Duration("cacheTTL", cacheTTL),
+	)
+	return registry
+}
+
+// errDependencyUnavailable 表示依赖已启用但在启动时未能成功初始化。
+var errDependencyUnavailable = errors.New("dependency enabled but not initialized")
+
+// RegisterHealthRoutes 注册 /livez、/readyz 和 /healthz 端点。
+//   - /livez: 存活检查，进程能响应即返回 200，不探测依赖；
+//   - /readyz: 就绪检查，任一依赖异常或服务正在关闭时返回 503；
+//   - /healthz: 完整健康报告，包含每个依赖的状态明细。
+func RegisterHealthRoutes(engine *gin.Engine, registry *health.Registry) {
+	engine.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+	})
+
+	engine.GET("/readyz", func(c *gin.Context) {
+		report, ready := registry.Ready(c.Request.Context())
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+
+	engine.GET("/healthz", func(c *gin.Context) {
+		report := registry.Check(c.Request.Context())
+		report.ShuttingDown = registry.IsShuttingDown()
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+
+	statusOnly := struct {
+		Status health.Status `json:"status"`
+	}{}
+	reports := map[int]openapi.Reply{
+		http.StatusOK:                 {Description: "所有依赖正常", Body: health.Report{}},
+		http.StatusServiceUnavailable: {Description: "依赖异常或服务正在关闭", Body: health.Report{}},
+	}
+	openapi.Register(
+		openapi.Route{Method: http.MethodGet, Path: "/livez", Tags: []string{"Health"}, Summary: "存活检查",
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "进程存活", Body: statusOnly}}},
+		openapi.Route{Method: http.MethodGet, Path: "/readyz", Tags: []string{"Health"}, Summary: "就绪检查", Responses: reports},
+		openapi.Route{Method: http.MethodGet, Path: "/healthz", Tags: []string{"Health"}, Summary: "健康报告", Responses: reports},
+	)
+
+	GetLogger().Debug("Registered health check routes: GET /livez, /readyz, /healthz")
+}
+
+// parseDurationOr 解析持续时间字符串，为空或无效时返回默认值并记录警告。
+func parseDurationOr(value string, def time.Duration, key string) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		GetLogger().Warn("解析持续时间配置失败，使用默认值",
+			zap.String("key", key),
+			zap.String("value", value),
+			zap.Duration("default", def),
+			zap.Error(err))
+		return def
+	}
+	return d
+}
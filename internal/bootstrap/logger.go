@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"fmt"
 	"os"
 	"sync"
 	"time" // 确保导入 time 包，如果 encoderConfig 中使用了时间相关的设置
@@ -16,6 +17,8 @@ import (
 var (
 	logInstance *zap.Logger
 	once        sync.Once
+	// logLevel 是所有 core 共享的动态日志级别，配置热重载时通过 SetLogLevel 修改，无需重建 logger
+	logLevel = zap.NewAtomicLevel()
 )
 
 // InitializeLogger 使用提供的配置初始化全局日志记录器单例
@@ -39,6 +42,21 @@ func GetLogger() *zap.Logger {
 	return logInstance
 }
 
+// SetLogLevel 在运行时修改全局日志级别 (例如 "debug", "info")，立即对所有日志生效。
+func SetLogLevel(level string) error {
+	var l zapcore.Level
+	if err := l.Set(level); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	logLevel.SetLevel(l)
+	return nil
+}
+
+// LogLevel 返回当前的全局日志级别。
+func LogLevel() zapcore.Level {
+	return logLevel.Level()
+}
+
 // initLoggerInternal 根据提供的配置初始化 zap logger (内部使用)
 func initLoggerInternal(cfg conf.LoggerConfig) *zap.Logger {
 	// 配置 lumberjack 进行日志切割
@@ -64,12 +82,11 @@ func initLoggerInternal(cfg conf.LoggerConfig) *zap.Logger {
 	// 创建 JSON encoder
 	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
 
-	// 解析日志级别字符串
-	logLevel := zapcore.InfoLevel // 默认级别
-	if err := logLevel.Set(cfg.Level); err != nil {
+	// 解析日志级别字符串，写入共享的 AtomicLevel 以支持运行时调整
+	if err := SetLogLevel(cfg.Level); err != nil {
 		// 如果级别设置无效，使用默认 Info 级别并打印警告
 		println("Warning: invalid log level '"+cfg.Level+"' configured, using default 'info'. Error: ", err.Error())
-		logLevel = zapcore.InfoLevel
+		logLevel.SetLevel(zapcore.InfoLevel)
 	}
 
 	// 创建 core：同时写入文件和控制台
@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"math/rand/v2"
 	"net" // 用于 *net.OpError 检查
 	"net/http"
 	"net/http/httputil"
@@ -10,20 +11,27 @@ import (
 	"time"
 	"myGin/internal/conf" // 模块路径
 	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/requestid"
 	"fmt" // 引入 fmt 包用于格式化错误信息
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	// "github.com/gin-contrib/cors" // 如果后续需要 CORS，请取消注释
 )
 
 // AttachCoreMiddleware 将核心中间件附加到 Gin 引擎。
-// 包括 Gin 的默认 Logger 和一个基于 Zap 的自定义 Recovery 中间件。
+// 包括基于 Zap 的访问日志中间件和自定义 Recovery 中间件。
 func AttachCoreMiddleware(engine *gin.Engine, cfg *conf.Config) {
-	// 使用 Gin 的默认 Logger 中间件
-	// 将请求详细信息记录到标准输出。如果需要，可以考虑替换为 ZapLogger。
-	engine.Use(gin.Logger())
-	GetLogger().Debug("Attached gin.Logger middleware") // 使用 GetLogger()
+	// 请求 ID 中间件必须最先执行，后续中间件、错误响应和 service 层都依赖它
+	engine.Use(requestid.Middleware())
+	GetLogger().Debug("Attached requestid middleware") // 使用 GetLogger()
+
+	// 使用基于 Zap 的访问日志中间件，替代 gin.Logger()
+	// 日志通过 GetLogger() 以 JSON 格式写入 lumberjack 文件和控制台。
+	// 放在其余中间件之前，以便它们都能从上下文中取到带 request_id 的 logger。
+	engine.Use(ZapLogger(GetLogger(), cfg.Logger.Access))
+	GetLogger().Debug("Attached ZapLogger middleware") // 使用 GetLogger()
 
 	// 使用带有 Zap 日志记录的自定义 Recovery 中间件
 	engine.Use(RecoveryWithZap(GetLogger())) // 使用 GetLogger()
@@ -45,7 +53,7 @@ func AttachCoreMiddleware(engine *gin.Engine, cfg *conf.Config) {
 	       AllowAllOrigins:  true, // 允许所有源 (安全性较低，用于开发)
 	       AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 	       AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With"},
-	       ExposeHeaders:    []string{"Content-Length"},
+	       ExposeHeaders:    []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, // 浏览器端需要读取限流响应头
 	       AllowCredentials: true,
 	       MaxAge:           12 * time.Hour,
 	   }
@@ -53,7 +61,7 @@ func AttachCoreMiddleware(engine *gin.Engine, cfg *conf.Config) {
 	   GetLogger().Debug("Attached CORS middleware") // 使用 GetLogger()
 	*/
 
-	GetLogger().Info("Attached core middleware (Logger, Recovery)") // 使用 GetLogger()
+	GetLogger().Info("Attached core middleware (RequestID, ZapLogger, Recovery, ErrorLogger)") // 使用 GetLogger()
 }
 
 // RecoveryWithZap 返回一个中间件，该中间件从任何 panic 中恢复并使用 Zap 记录它们。
@@ -62,6 +70,9 @@ func RecoveryWithZap(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// 使用请求级 logger (带 request_id)，找不到时回退到传入的 logger
+				logger := LoggerFromContext(c, logger)
+
 				// 检查连接是否断开，因为这并非真正的服务器错误。
 				var brokenPipe bool
 				if ne, ok := err.(*net.OpError); ok {
@@ -102,98 +113,168 @@ func RecoveryWithZap(logger *zap.Logger) gin.HandlerFunc {
 }
 
 
-// ErrorLoggerMiddleware 创建一个中间件，用于记录请求处理过程中可能出现的非 panic 错误。
-// 它检查 c.Errors 和最终的 HTTP 状态码。
+// ErrorLoggerMiddleware 创建一个中间件，用于记录请求处理过程中通过 c.Error 附加的非 panic 错误。
+// 失败请求的状态码、方法、路径和客户端信息已由 ZapLogger 的访问日志记录，这里只补充错误详情 (两条日志通过 request_id 关联)。
 func ErrorLoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 先执行后续的处理函数和中间件
 		c.Next()
 
-		// c.Next() 执行完毕后，检查是否有错误或失败的状态码
-		logErrorsIfExist(c, logger)
+		// c.Next() 执行完毕后，检查是否有附加的错误
+		// 优先使用 ZapLogger 放入上下文的请求级 logger，以便日志带上 request_id
+		logErrorsIfExist(c, LoggerFromContext(c, logger))
 	}
 }
 
-// logErrorsIfExist 辅助函数，检查并记录错误
+// logErrorsIfExist 辅助函数，检查并记录 c.Errors；没有附加错误时不记录 (失败的状态码已在访问日志中)
 func logErrorsIfExist(c *gin.Context, logger *zap.Logger) {
+	if len(c.Errors) == 0 {
+		return
+	}
+
+	// 构造错误信息字符串
+	var errorMessages strings.Builder
+	for i, ginErr := range c.Errors {
+		if i > 0 {
+			errorMessages.WriteString("; ")
+		}
+		// 尝试获取更具体的错误信息
+		metaMsg := ""
+		if ginErr.Meta != nil {
+			metaMsg = fmt.Sprintf(" (Meta: %v)", ginErr.Meta) // 使用 fmt 将 Meta 格式化
+		}
+		errorMessages.WriteString(fmt.Sprintf("Error #%d: %s%s", i+1, ginErr.Error(), metaMsg)) // 使用 fmt
+	}
 	status := c.Writer.Status()
-	hasErrors := len(c.Errors) > 0
+	fields := []zap.Field{
+		zap.Int("status", status),
+		zap.String("errors", errorMessages.String()),
+	}
+
+	// 根据状态码选择日志级别
+	if status >= 500 {
+		logger.Error("Request completed with errors", fields...)
+	} else {
+		logger.Warn("Request completed with errors", fields...)
+	}
+}
+
+
+// RequestIDHeader 是用于传递请求 ID 的 HTTP 头。
+const RequestIDHeader = requestid.HeaderName
+
+// ContextKeyLogger 是请求级 logger 在 gin.Context 中的键。
+// 与 errs.APIError.JSON 中查找 logger 使用的键保持一致。
+const ContextKeyLogger = "logger"
+
+// ZapLogger 返回一个使用 Zap 记录请求的访问日志中间件。
+// 它会：
+//   - 通过 requestid.Ensure 沿用入站的请求 ID 或生成新的 ID；
+//   - 将带有 request_id 字段的子 logger 存入上下文 ("logger" 键)；
+//   - 在请求结束后记录延迟、状态码、字节数、路由模板、客户端 IP 和 User-Agent；
+//   - 跳过 SkipPaths 中的路径，并按 Sampling 规则对成功请求采样。
+func ZapLogger(logger *zap.Logger, cfg conf.AccessLogConfig) gin.HandlerFunc {
+	skip := newPathMatcher(cfg.SkipPaths)
+	sampling := make(map[string]float64, len(cfg.Sampling))
+	for _, rule := range cfg.Sampling {
+		sampling[rule.Route] = rule.Rate
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		// 通常已由 requestid.Middleware 设置，这里保证单独使用 ZapLogger 时也有请求 ID
+		requestID := requestid.Ensure(c)
+
+		reqLogger := logger.With(zap.String("request_id", requestID))
+		c.Set(ContextKeyLogger, reqLogger)
+
+		// 处理请求
+		c.Next()
+
+		path := c.Request.URL.Path
+		route := c.FullPath() // 未匹配到路由时为空字符串
+		if skip(path, route) {
+			return
+		}
+
+		status := c.Writer.Status()
+		if status < http.StatusBadRequest {
+			if rate, ok := sampling[route]; ok && rand.Float64() >= rate {
+				return
+			}
+		}
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0 // 未写入响应体时 gin 返回 -1
+		}
 
-	// 只记录客户端错误 (4xx) 或服务器错误 (5xx)
-	// 注意：5xx panic 错误通常已被 RecoveryWithZap 处理，但这里可以捕获未 panic 的 5xx
-	if status >= 400 || hasErrors {
-		// 准备日志字段
 		fields := []zap.Field{
 			zap.Int("status", status),
 			zap.String("method", c.Request.Method),
-			zap.String("path", c.Request.URL.Path),
+			zap.String("path", path),
+			zap.String("route", route),
 			zap.String("query", c.Request.URL.RawQuery),
 			zap.String("ip", c.ClientIP()),
 			zap.String("user_agent", c.Request.UserAgent()),
-			// zap.Duration("latency", latency), // 如果需要延迟，可以在中间件开始时记录时间
+			zap.Int("bytes", size),
+			zap.Duration("latency", time.Since(start)),
 		}
 
-		// 构造错误信息字符串
-		var errorMessages strings.Builder
-		if hasErrors {
-			for i, ginErr := range c.Errors {
-				if i > 0 {
-					errorMessages.WriteString("; ")
-				}
-				// 尝试获取更具体的错误信息
-				metaMsg := ""
-				if ginErr.Meta != nil {
-					metaMsg = fmt.Sprintf(" (Meta: %v)", ginErr.Meta) // 使用 fmt 将 Meta 格式化
-				}
-				errorMessages.WriteString(fmt.Sprintf("Error #%d: %s%s", i+1, ginErr.Error(), metaMsg)) // 使用 fmt
-			}
-		} else {
-			// 如果没有 c.Errors，但状态码是 4xx/5xx，记录一个通用消息
-			errorMessages.WriteString(fmt.Sprintf("Request failed with status %d", status))
-		}
-		fields = append(fields, zap.String("errors", errorMessages.String()))
+		// 后续中间件可能替换了上下文中的 logger (例如 tracing 插件添加 trace_id)
+		reqLogger = LoggerFromContext(c, reqLogger)
 
+		// 5xx 使用 Error 级别，便于与客户端错误分开告警；访问日志的堆栈只有中间件调用链，因此不附带堆栈
+		switch {
+		case status >= http.StatusInternalServerError:
+			reqLogger.WithOptions(zap.AddStacktrace(zapcore.FatalLevel)).Error("HTTP request", fields...)
+		case status >= http.StatusBadRequest:
+			reqLogger.Warn("HTTP request", fields...)
+		default:
+			reqLogger.Info("HTTP request", fields...)
+		}
+	}
+}
 
-		// 根据状态码选择日志级别
-		if status >= 500 {
-			logger.Error("Request completed with server error", fields...)
-		} else { // status >= 400
-			// 如果是绑定错误，我们可能已经在 handler 记过 Warn 了，但这里提供统一入口
-			// 可以考虑增加逻辑避免重复，但多记一次通常问题不大
-			logger.Warn("Request completed with client error", fields...)
+// LoggerFromContext 返回 ZapLogger 存入上下文的请求级 logger。
+// 如果上下文中没有 logger，返回 fallback。
+func LoggerFromContext(c *gin.Context, fallback *zap.Logger) *zap.Logger {
+	if v, ok := c.Get(ContextKeyLogger); ok {
+		if l, ok := v.(*zap.Logger); ok {
+			return l
 		}
 	}
+	return fallback
 }
 
+// newPathMatcher 根据跳过列表构造匹配函数。
+// 以 "/*" 结尾的条目按前缀匹配，其余条目与 URL 路径或路由模板精确匹配。
+func newPathMatcher(patterns []string) func(path, route string) bool {
+	exact := make(map[string]struct{}, len(patterns))
+	var prefixes []string
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/*") {
+			prefixes = append(prefixes, strings.TrimSuffix(p, "*"))
+			continue
+		}
+		exact[p] = struct{}{}
+	}
 
-// 可选：ZapLogger 返回一个使用 Zap 记录请求的中间件。
-// func ZapLogger(logger *zap.Logger) gin.HandlerFunc {
-// 	return func(c *gin.Context) {
-// 		start := time.Now()
-// 		path := c.Request.URL.Path
-// 		query := c.Request.URL.RawQuery
-
-// 		// 处理请求
-// 		c.Next()
-
-// 		// 记录请求详细信息
-// 		end := time.Now()
-// 		latency := end.Sub(start)
-// 		if len(c.Errors) > 0 {
-// 			// 如果这是一个错误的请求，则附加错误字段。
-// 			for _, e := range c.Errors.Errors() {
-// 				logger.Error(e)
-// 			}
-// 		} else {
-// 			logger.Info(path,
-// 				zap.Int("status", c.Writer.Status()),
-// 				zap.String("method", c.Request.Method),
-// 				zap.String("path", path),
-// 				zap.String("query", query),
-// 				zap.String("ip", c.ClientIP()),
-// 				zap.String("user-agent", c.Request.UserAgent()),
-// 				zap.Duration("latency", latency),
-// 			)
-// 		}
-// 	}
-// }
\ No newline at end of file
+	return func(path, route string) bool {
+		if _, ok := exact[path]; ok {
+			return true
+		}
+		if route != "" {
+			if _, ok := exact[route]; ok {
+				return true
+			}
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
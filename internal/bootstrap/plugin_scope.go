@@ -0,0 +1,54 @@
+package bootstrap
+
+import (
+	"sync/atomic"
+
+	"myGin/internal/conf" // 模块路径
+	"myGin/internal/pkg/pathmatch"
+	"myGin/internal/plugin"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scopedMiddleware 只在请求路径位于插件作用范围内时执行插件中间件。
+// 作用范围可以在配置热重载时替换。
+type scopedMiddleware struct {
+	handler gin.HandlerFunc
+	matcher atomic.Pointer[pathmatch.Matcher]
+}
+
+// newScopedMiddleware 根据作用范围包装插件中间件。
+func newScopedMiddleware(scope conf.RouteScope, handler gin.HandlerFunc) (*scopedMiddleware, error) {
+	s := &scopedMiddleware{handler: handler}
+	if err := s.setScope(scope); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// setScope 编译并替换作用范围，模式无效时保留旧的作用范围。
+func (s *scopedMiddleware) setScope(scope conf.RouteScope) error {
+	m, err := pathmatch.NewMatcher(scope.Include, scope.Exclude)
+	if err != nil {
+		return err
+	}
+	s.matcher.Store(m)
+	return nil
+}
+
+// Handle 是挂载到 Gin 引擎上的中间件。
+func (s *scopedMiddleware) Handle(c *gin.Context) {
+	if !s.matcher.Load().Match(c.Request.URL.Path) {
+		c.Next()
+		return
+	}
+	s.handler(c)
+}
+
+// scopeOf 返回插件配置声明的作用范围，未实现 plugin.Scoped 时作用于全部路由。
+func scopeOf(moduleCfg interface{}) conf.RouteScope {
+	if s, ok := moduleCfg.(plugin.Scoped); ok {
+		return s.Scope()
+	}
+	return conf.RouteScope{}
+}
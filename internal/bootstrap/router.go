@@ -5,6 +5,8 @@ import (
 
 	"myGin/internal/conf"    // 模块路径
 	"myGin/internal/handler" // 导入 handler 包
+	"myGin/internal/pkg/health"
+	"myGin/internal/pkg/openapi"
 	"myGin/internal/service" // 导入 service 包
 
 	"github.com/gin-gonic/gin"
@@ -12,19 +14,17 @@ import (
 )
 
 // RegisterRoutes 初始化并注册所有应用程序路由。
-func RegisterRoutes(engine *gin.Engine, cfg *conf.Config) {
+// checks 是由 InitHealth 创建的健康检查注册表。
+func RegisterRoutes(engine *gin.Engine, cfg *conf.Config, checks *health.Registry) {
 	// 使用 bootstrap.GetLogger() 获取 logger 实例
 	// 不再需要检查或从 zap.L() 获取
 	logger := GetLogger() // 直接调用包内函数
 
 	logger.Info("Registering application routes...")
 
-	// // 1. 健康检查端点（对监控至关重要）
-	// engine.GET("/healthz", func(c *gin.Context) {
-	// 	// 在实际应用中，这里可能会检查数据库/Redis 连接。
-	// 	c.String(http.StatusOK, "OK")
-	// })
-	logger.Debug("Registered health check route: GET /healthz")
+	// 1. 健康检查端点（对监控至关重要）
+	// /livez、/readyz、/healthz 会探测已启用的数据库和 Redis 连接。
+	RegisterHealthRoutes(engine, checks)
 
 	// 2. API 版本分组（良好实践）
 	apiV1 := engine.Group("/api/v1")
@@ -54,6 +54,10 @@ func RegisterRoutes(engine *gin.Engine, cfg *conf.Config) {
 			c.JSON(http.StatusOK, gin.H{"message": "pong v1"})
 		})
 		logger.Debug("Registered ping route: GET /api/v1/ping")
+		openapi.Register(openapi.Route{
+			Method: http.MethodGet, Path: apiV1.BasePath() + "/ping", Tags: []string{"Health"}, Summary: "API v1 连通性测试",
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "pong", Body: map[string]string{}}},
+		})
 
 		// --- 业务逻辑路由结束 ---
 	} // apiV1 分组结束
@@ -0,0 +1,302 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"myGin/internal/conf" // 模块路径
+	"myGin/internal/pkg/tlsutil"
+
+	"go.uber.org/zap"
+)
+
+// defaultShutdownTimeout 是未配置 server.shutdownTimeout 时优雅关停的等待时间。
+const defaultShutdownTimeout = 10 * time.Second
+
+// defaultCertReloadInterval 是未配置 server.tls.reloadInterval 时检查证书变更的间隔。
+const defaultCertReloadInterval = time.Minute
+
+// ServerSettings 是从 conf.ServerConfig 解析出的 HTTP 服务器参数。
+type ServerSettings struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+
+	// 以下字段仅在启用 TLS 时有效
+	TLSMinVersion      uint16
+	TLSCipherSuites    []uint16
+	TLSClientAuth      tls.ClientAuthType
+	CertReloadInterval time.Duration
+}
+
+// tlsVersions 是 server.tls.minVersion 支持的取值。
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthTypes 是 server.tls.clientAuth 支持的取值。
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// ParseServerConfig 解析并校验服务器配置中的持续时间、数值和 TLS 参数。
+// 返回的错误会列出所有无效的字段，而不仅仅是第一个。
+func ParseServerConfig(cfg conf.ServerConfig) (ServerSettings, error) {
+	var settings ServerSettings
+	var errList []error
+
+	parse := func(key, value string, def time.Duration) time.Duration {
+		if value == "" {
+			return def
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errList = append(errList, fmt.Errorf("server.%s: invalid duration %q: %w", key, value, err))
+			return def
+		}
+		if d < 0 {
+			errList = append(errList, fmt.Errorf("server.%s: duration must not be negative, got %q", key, value))
+			return def
+		}
+		return d
+	}
+
+	settings.ReadTimeout = parse("readTimeout", cfg.ReadTimeout, 0)
+	settings.ReadHeaderTimeout = parse("readHeaderTimeout", cfg.ReadHeaderTimeout, 0)
+	settings.WriteTimeout = parse("writeTimeout", cfg.WriteTimeout, 0)
+	settings.IdleTimeout = parse("idleTimeout", cfg.IdleTimeout, 0)
+	settings.ShutdownTimeout = parse("shutdownTimeout", cfg.ShutdownTimeout, defaultShutdownTimeout)
+
+	if cfg.MaxHeaderBytes < 0 {
+		errList = append(errList, fmt.Errorf("server.maxHeaderBytes: must not be negative, got %d", cfg.MaxHeaderBytes))
+	}
+	settings.MaxHeaderBytes = cfg.MaxHeaderBytes
+
+	if cfg.TLS.Enable {
+		settings.CertReloadInterval = parse("tls.reloadInterval", cfg.TLS.ReloadInterval, defaultCertReloadInterval)
+		errList = append(errList, parseTLSSettings(cfg.TLS, &settings)...)
+	}
+
+	return settings, errors.Join(errList...)
+}
+
+// parseTLSSettings 校验 TLS 配置并将解析结果写入 settings。
+func parseTLSSettings(cfg conf.TLSConfig, settings *ServerSettings) []error {
+	var errList []error
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		errList = append(errList, errors.New("server.tls: certFile and keyFile are required when TLS is enabled"))
+	}
+
+	settings.TLSMinVersion = tls.VersionTLS12
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			errList = append(errList, fmt.Errorf("server.tls.minVersion: unsupported version %q, expected \"1.2\" or \"1.3\"", cfg.MinVersion))
+		} else {
+			settings.TLSMinVersion = v
+		}
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		known := make(map[string]uint16)
+		for _, cs := range tls.CipherSuites() { // 仅包含安全的套件，不安全套件一律拒绝
+			known[cs.Name] = cs.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := known[name]
+			if !ok {
+				errList = append(errList, fmt.Errorf("server.tls.cipherSuites: unknown or insecure cipher suite %q", name))
+				continue
+			}
+			settings.TLSCipherSuites = append(settings.TLSCipherSuites, id)
+		}
+	}
+
+	settings.TLSClientAuth = tls.NoClientCert
+	if cfg.ClientAuth != "" {
+		ca, ok := clientAuthTypes[cfg.ClientAuth]
+		if !ok {
+			errList = append(errList, fmt.Errorf("server.tls.clientAuth: unsupported value %q", cfg.ClientAuth))
+		} else {
+			settings.TLSClientAuth = ca
+		}
+	}
+	needsCA := settings.TLSClientAuth == tls.VerifyClientCertIfGiven || settings.TLSClientAuth == tls.RequireAndVerifyClientCert
+	if needsCA && cfg.ClientCAFile == "" {
+		errList = append(errList, fmt.Errorf("server.tls.clientCAFile: required when clientAuth is %q", cfg.ClientAuth))
+	}
+
+	return errList
+}
+
+// HTTPServer 封装了主 http.Server 以及可选的 TLS 证书重载器和 HTTP→HTTPS 重定向服务器。
+type HTTPServer struct {
+	Server   *http.Server
+	Settings ServerSettings
+
+	tlsEnabled bool
+	certs      *tlsutil.Reloader // 仅在启用 TLS 时非 nil
+	redirect   *http.Server      // 仅在配置了 redirectAddr 时非 nil
+}
+
+// NewHTTPServer 根据服务器配置创建 HTTPServer，并应用所有超时、请求头限制和 TLS 参数。
+// 未配置的超时为 0，遵循 net/http 的语义 (ReadHeaderTimeout 和 IdleTimeout 回退到 ReadTimeout)。
+func NewHTTPServer(cfg conf.ServerConfig, handler http.Handler) (*HTTPServer, error) {
+	settings, err := ParseServerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr, // 从配置中获取监听地址
+		Handler:           handler,
+		ReadTimeout:       settings.ReadTimeout,
+		ReadHeaderTimeout: settings.ReadHeaderTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+		MaxHeaderBytes:    settings.MaxHeaderBytes, // 0 时 net/http 使用 DefaultMaxHeaderBytes
+	}
+	hs := &HTTPServer{Server: srv, Settings: settings, tlsEnabled: cfg.TLS.Enable}
+
+	if cfg.TLS.Enable {
+		certs, err := tlsutil.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile, GetLogger())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+		hs.certs = certs
+		srv.TLSConfig = newTLSConfig(settings, certs, cfg.TLS.ClientCAFile != "")
+
+		if cfg.TLS.RedirectAddr != "" {
+			hs.redirect = &http.Server{
+				Addr:              cfg.TLS.RedirectAddr,
+				Handler:           RedirectToHTTPS(cfg.Addr),
+				ReadHeaderTimeout: settings.ReadHeaderTimeout,
+				IdleTimeout:       settings.IdleTimeout,
+			}
+		}
+	} else if cfg.H2C {
+		// 明文 HTTP/2 (h2c)，仅建议在内网服务间调用时启用
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		srv.Protocols = protocols
+	}
+
+	GetLogger().Info("HTTP server configured",
+		zap.String("addr", srv.Addr),
+		zap.Duration("readTimeout", settings.ReadTimeout),
+		zap.Duration("readHeaderTimeout", settings.ReadHeaderTimeout),
+		zap.Duration("writeTimeout", settings.WriteTimeout),
+		zap.Duration("idleTimeout", settings.IdleTimeout),
+		zap.Int("maxHeaderBytes", settings.MaxHeaderBytes),
+		zap.Duration("shutdownTimeout", settings.ShutdownTimeout),
+		zap.Bool("tls", cfg.TLS.Enable),
+		zap.Bool("h2c", cfg.H2C && !cfg.TLS.Enable),
+	)
+	return hs, nil
+}
+
+// newTLSConfig 构造服务端 tls.Config，证书和客户端 CA 均从 Reloader 动态获取。
+func newTLSConfig(settings ServerSettings, certs *tlsutil.Reloader, withClientCA bool) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     settings.TLSMinVersion,
+		CipherSuites:   settings.TLSCipherSuites,
+		ClientAuth:     settings.TLSClientAuth,
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"}, // 显式声明，保证 GetConfigForClient 返回的配置也支持 HTTP/2
+	}
+	if withClientCA {
+		// 每次握手使用最新的 CA 证书包，CA 轮换同样无需重启
+		base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			cfg.GetConfigForClient = nil
+			cfg.ClientCAs = certs.ClientCAs()
+			return cfg, nil
+		}
+	}
+	return base
+}
+
+// ListenAndServe 在配置的地址上监听并开始服务，同时启动重定向服务器和证书重载。
+// 与 http.Server 一致，正常关闭时返回 http.ErrServerClosed。
+func (s *HTTPServer) ListenAndServe() error {
+	addr := s.Server.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.tlsEnabled {
+			addr = ":https"
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve 在给定的 listener 上开始服务，测试中可传入随机端口的 listener。
+func (s *HTTPServer) Serve(ln net.Listener) error {
+	if s.redirect != nil {
+		go func() {
+			GetLogger().Info("HTTP→HTTPS redirect server starting", zap.String("address", s.redirect.Addr))
+			if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				GetLogger().Error("Redirect server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	if !s.tlsEnabled {
+		return s.Server.Serve(ln)
+	}
+	s.certs.Watch(s.Settings.CertReloadInterval)
+	return s.Server.ServeTLS(ln, "", "") // 证书由 TLSConfig.GetCertificate 提供
+}
+
+// Shutdown 优雅关闭主服务器和重定向服务器，并停止证书重载。
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	var errList []error
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			errList = append(errList, fmt.Errorf("redirect server: %w", err))
+		}
+	}
+	if err := s.Server.Shutdown(ctx); err != nil {
+		errList = append(errList, err)
+	}
+	if s.certs != nil {
+		s.certs.Stop()
+	}
+	return errors.Join(errList...)
+}
+
+// RedirectToHTTPS 返回一个将所有请求永久重定向到 HTTPS 的处理器。
+// httpsAddr 是 HTTPS 服务的监听地址，用于确定目标端口 (443 时省略端口)。
+func RedirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -1,68 +1,326 @@
 package conf
 
+// 结构体标签约定:
+//   - mapstructure: 配置键名，viper 通过它将 config.yml 映射到结构体 (键名不区分大小写)；
+//   - default: 配置文件中未指定时使用的默认值，由 bootstrap.LoadConfig 在读取前注册到 viper；
+//   - validate: 校验规则 (go-playground/validator 语法)，由 bootstrap.ValidateConfig 执行。
+//     额外支持 duration (time.ParseDuration 可解析)、cipher_suite (安全的 TLS 密码套件名称)
+//     和 path_pattern (pathmatch 路径模式, 例如 "/api/v1/**")。
+//   - secret:"true": 敏感字段，打印或输出生效配置时会被脱敏。
+//
+// 任意字符串配置都可以使用密钥引用，在加载时解析: ${env:NAME}、${file:/run/secrets/x}、${base64:...}。
+// 通过引用解析得到的值同样会被脱敏。
+
 // Config 应用总配置
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Modules  ModulesConfig  `yaml:"modules"` // 使用结构体替代 map 来支持更复杂的模块配置
-	Logger   LoggerConfig   `yaml:"logger"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
+	App      AppConfig      `mapstructure:"app"`
+	Server   ServerConfig   `mapstructure:"server"`
+	Modules  ModulesConfig  `mapstructure:"modules"` // 使用结构体替代 map 来支持更复杂的模块配置
+	Logger   LoggerConfig   `mapstructure:"logger"`
+	Database DatabaseConfig `mapstructure:"database"`
+	Redis    RedisConfig    `mapstructure:"redis"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+
+	// SecretPaths 由密钥引用解析得到的配置路径 (例如 "modules.auth.secret")，由 bootstrap 在加载时填充，不从配置文件读取。
+	SecretPaths []string `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+// AppConfig 应用基础信息
+type AppConfig struct {
+	// Env 运行环境 (例如 development, test, production)，决定额外加载的 config.<env>.yml。
+	// 优先级: --env 参数 > APP_ENV 环境变量 > 配置文件中的 app.env。
+	Env  string `mapstructure:"env" default:"development" validate:"required"`
+	Name string `mapstructure:"name" default:"my-gin-skeleton"`
+	// HotReload 是否监听配置文件变更并热重载。
+	// 目前 logger.level 和 modules.ratelimit 的 rate/burst 可以在运行时生效，其他配置仍需重启。
+	HotReload bool `mapstructure:"hotReload" default:"true"`
 }
 
 // ServerConfig 服务器配置
+// 所有持续时间均为 time.ParseDuration 可解析的字符串，为空表示使用默认值。
 type ServerConfig struct {
-	Addr         string `yaml:"addr"`
-	ReadTimeout  string `yaml:"readTimeout"`  // 例如: "15s"
-	WriteTimeout string `yaml:"writeTimeout"` // 例如: "15s"
+	Addr              string `mapstructure:"addr" default:":8080" validate:"required"`
+	ReadTimeout       string `mapstructure:"readTimeout" validate:"omitempty,duration"`                   // 读取整个请求 (含请求体) 的超时, 例如: "15s"
+	ReadHeaderTimeout string `mapstructure:"readHeaderTimeout" validate:"omitempty,duration"`             // 读取请求头的超时, 为空时与 ReadTimeout 相同
+	WriteTimeout      string `mapstructure:"writeTimeout" validate:"omitempty,duration"`                  // 写入响应的超时, 例如: "15s"
+	IdleTimeout       string `mapstructure:"idleTimeout" validate:"omitempty,duration"`                   // keep-alive 空闲连接超时, 为空时与 ReadTimeout 相同
+	MaxHeaderBytes    int    `mapstructure:"maxHeaderBytes" validate:"gte=0"`                             // 请求头最大字节数, 0 表示使用 http.DefaultMaxHeaderBytes (1MB)
+	ShutdownTimeout   string `mapstructure:"shutdownTimeout" default:"10s" validate:"omitempty,duration"` // 优雅关停的最长等待时间
+
+	H2C bool      `mapstructure:"h2c"` // 未启用 TLS 时是否允许明文 HTTP/2 (h2c)，适用于内网服务间调用
+	TLS TLSConfig `mapstructure:"tls"` // TLS 配置，启用后 Addr 上监听 HTTPS 并自动支持 HTTP/2
+}
+
+// TLSConfig TLS 与 mTLS 配置
+type TLSConfig struct {
+	Enable       bool     `mapstructure:"enable"`
+	CertFile     string   `mapstructure:"certFile" validate:"required_if=Enable true"`                 // PEM 证书 (可包含证书链)
+	KeyFile      string   `mapstructure:"keyFile" validate:"required_if=Enable true"`                  // PEM 私钥
+	MinVersion   string   `mapstructure:"minVersion" default:"1.2" validate:"omitempty,oneof=1.2 1.3"` // 最低 TLS 版本
+	CipherSuites []string `mapstructure:"cipherSuites" validate:"dive,cipher_suite"`                   // 允许的密码套件名称 (仅作用于 TLS 1.2)，为空时使用 Go 默认安全套件
+	// ClientAuth 客户端证书校验策略
+	ClientAuth string `mapstructure:"clientAuth" default:"none" validate:"omitempty,oneof=none request require verify_if_given require_and_verify"`
+	// ClientCAFile 校验客户端证书使用的 CA 证书包 (PEM)，clientAuth 为 verify_if_given 或 require_and_verify 时必填
+	ClientCAFile   string `mapstructure:"clientCAFile" validate:"required_if=Enable true ClientAuth verify_if_given,required_if=Enable true ClientAuth require_and_verify"`
+	ReloadInterval string `mapstructure:"reloadInterval" default:"1m" validate:"omitempty,duration"` // 检查证书文件变更的间隔, "0s" 表示不自动重载
+	RedirectAddr   string `mapstructure:"redirectAddr"`                                              // HTTP→HTTPS 重定向监听地址, 例如 ":80"，为空表示不启用
 }
 
 // ModulesConfig 模块配置集合
 type ModulesConfig struct {
-	RateLimit RateLimitConfig `yaml:"ratelimit"`
-	Auth      AuthConfig      `yaml:"auth"` // 保留 Auth 配置结构以备将来使用
+	RateLimit RateLimitConfig `mapstructure:"ratelimit"`
+	Auth      AuthConfig      `mapstructure:"auth"` // 保留 Auth 配置结构以备将来使用
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Swagger   SwaggerConfig   `mapstructure:"swagger"`
 	// 在此添加其他模块的配置结构
 }
 
+// RouteScope 插件中间件的作用范围，嵌入到插件配置中使用。
+// 路径匹配任一 Include 模式 (为空表示全部路径) 且不匹配任何 Exclude 模式时，中间件才会执行。
+// 模式语法见 internal/pkg/pathmatch: "**" 匹配任意层级, "*" 或 ":id" 匹配单个路径段。
+type RouteScope struct {
+	Include []string `mapstructure:"include" validate:"dive,path_pattern"` // 例如 ["/api/v1/**"]
+	Exclude []string `mapstructure:"exclude" validate:"dive,path_pattern"` // 例如 ["/api/v1/ping"]，优先于 Include
+}
+
+// Scope 返回作用范围，嵌入 RouteScope 的插件配置借此实现 plugin.Scoped 接口。
+func (s RouteScope) Scope() RouteScope {
+	return s
+}
+
 // RateLimitConfig 限流插件配置
 type RateLimitConfig struct {
-	Enable bool    `yaml:"enable"` // 是否启用插件
-	Rate   float64 `yaml:"rate"`   // 每秒允许的请求数 (令牌生成速率)
-	Burst  int     `yaml:"burst"`  // 令牌桶的容量 (允许的瞬时突发量)
+	Enable bool    `mapstructure:"enable"`                                         // 是否启用插件
+	Rate   float64 `mapstructure:"rate" validate:"required_if=Enable true,gte=0"`  // 每秒允许的请求数 (令牌生成速率)，启用时必须 > 0
+	Burst  int     `mapstructure:"burst" validate:"required_if=Enable true,gte=0"` // 令牌桶的容量 (允许的瞬时突发量)，启用时必须 > 0
+
+	// Backend 为 redis 时限流状态保存在 Redis 中，多个实例共享同一配额；Redis 未启用或请求 Redis 失败时降级为进程内限流。
+	Backend   string `mapstructure:"backend" default:"memory" validate:"omitempty,oneof=memory redis"`
+	Algorithm string `mapstructure:"algorithm" default:"token_bucket" validate:"omitempty,oneof=token_bucket sliding_window"` // sliding_window 需要 redis 后端
+	Window    string `mapstructure:"window" default:"1s" validate:"omitempty,duration"`                                       // 滑动窗口长度，窗口内最多允许 rate × window 个请求
+	KeyPrefix string `mapstructure:"keyPrefix" default:"ratelimit:"`                                                          // Redis key 前缀
+
+	// 进程内限流器和每日配额计数器 (backend 为 memory 或 Redis 降级时) 各自的 key 存储上限: 超过 maxKeys 时淘汰最久未使用的 key，
+	// 限流器的 key 空闲超过 idleTTL 后被清除 (每日配额计数保留到当日结束)。idleTTL 应不小于令牌桶回满的时间 (burst / rate)。
+	MaxKeys int    `mapstructure:"maxKeys" default:"100000" validate:"gte=0"`           // 0 表示不限
+	IdleTTL string `mapstructure:"idleTTL" default:"10m" validate:"omitempty,duration"` // "0s" 表示不按空闲时间清除
+
+	RouteScope `mapstructure:",squash"` // 默认实例的作用范围
+
+	// Rules 按路由、方法和调用方维度 (IP、用户、API Key 或请求头) 设置独立的配额。
+	// 请求匹配多个规则时使用最具体的规则，都不匹配时按顶层 rate/burst 以 IP 限流。
+	Rules     []RateLimitRuleConfig `mapstructure:"rules" validate:"dive"`
+	Allowlist []string              `mapstructure:"allowlist"` // 不限流的调用方: IP、CIDR、"user:<id>" 或 "apikey:<id>"
+	DryRun    bool                  `mapstructure:"dryRun"`    // 只记录将被拒绝的请求而不拒绝，用于上线新规则前观察
+
+	// Groups 为特定路由组创建独立的限流实例 (各自的速率和限流器)，与默认实例相互独立。
+	// 例如为登录接口单独设置更严格的限制，并在默认实例的 exclude 中排除它。
+	Groups []RateLimitGroupConfig `mapstructure:"groups" validate:"dive"`
+
+	// Group 为路由组实例的组名，由限流插件在创建组实例时填充 (默认实例为空)，不从配置文件读取。
+	Group string `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+// RateLimitRuleConfig 限流规则。规则的具体程度依次由 path 的具体程度、是否声明 methods、permissions 的数量决定，
+// 同样具体时配置中靠前的规则优先。调用方缺少规则的 key 维度 (例如未登录时的 user) 时该规则不匹配。
+type RateLimitRuleConfig struct {
+	Name        string   `mapstructure:"name" validate:"required"`               // 规则名称，用于日志和限流 key
+	Path        string   `mapstructure:"path" validate:"omitempty,path_pattern"` // 为空表示全部路径
+	Methods     []string `mapstructure:"methods"`                                // 为空表示全部方法
+	Permissions []string `mapstructure:"permissions"`                            // 只对拥有全部这些权限的调用方生效，例如按套餐区分配额 "tier:premium"
+
+	Key    string `mapstructure:"key" validate:"omitempty,oneof=ip user apikey header"` // 限流维度，默认 ip
+	Header string `mapstructure:"header" validate:"required_if=Key header"`             // key 为 header 时使用的请求头
+
+	Rate   float64 `mapstructure:"rate" validate:"gte=0"`                        // 0 表示只限制 daily
+	Burst  int     `mapstructure:"burst" validate:"required_with=Rate,gte=0"`    // rate > 0 时必填
+	Window string  `mapstructure:"window" validate:"omitempty,duration"`         // 滑动窗口长度，默认使用顶层 window
+	Daily  int64   `mapstructure:"daily" validate:"required_without=Rate,gte=0"` // 每个 key 每天 (UTC) 最多请求数，0 表示不限
+	DryRun bool    `mapstructure:"dryRun"`                                       // 只记录将被拒绝的请求
+}
+
+// RateLimitGroupConfig 按路由组覆盖的限流配置
+type RateLimitGroupConfig struct {
+	Name  string  `mapstructure:"name" validate:"required"`        // 实例名称，用于日志和热重载匹配
+	Rate  float64 `mapstructure:"rate" validate:"omitempty,gt=0"`  // 为 0 时使用顶层 rate
+	Burst int     `mapstructure:"burst" validate:"omitempty,gt=0"` // 为 0 时使用顶层 burst
+
+	RouteScope `mapstructure:",squash"`
+}
+
+// MetricsConfig Prometheus 指标插件配置
+type MetricsConfig struct {
+	Enable    bool   `mapstructure:"enable"`
+	Path      string `mapstructure:"path" default:"/metrics" validate:"omitempty,startswith=/"` // 指标接口路径
+	Addr      string `mapstructure:"addr"`                                                      // 单独的管理端口监听地址，例如 ":9090"；为空时在主服务上注册指标接口
+	Namespace string `mapstructure:"namespace"`                                                 // 指标名称前缀，例如 "mygin" 得到 mygin_http_requests_total
+	// Buckets HTTP、数据库和 Redis 耗时直方图的桶边界 (秒)，为空时使用 prometheus.DefBuckets
+	Buckets []float64 `mapstructure:"buckets" validate:"dive,gt=0"`
+
+	RouteScope `mapstructure:",squash"` // 记录 HTTP 指标的路由范围
+}
+
+// SwaggerConfig OpenAPI 文档插件配置
+type SwaggerConfig struct {
+	Enable      bool   `mapstructure:"enable"`
+	Path        string `mapstructure:"path" default:"/swagger" validate:"omitempty,startswith=/"` // Swagger UI 路径，文档位于 <path>/openapi.json
+	Title       string `mapstructure:"title"`                                                     // 文档标题，为空时使用 app.name
+	Version     string `mapstructure:"version"`                                                   // 文档版本，为空时使用构建版本
+	Description string `mapstructure:"description"`
+	// Check 启动时检查已注册的 Gin 路由是否都有文档: off 不检查，warn 记录缺失的路由，fail 启动失败。
+	Check        string   `mapstructure:"check" default:"warn" validate:"omitempty,oneof=off warn fail"`
+	CheckExclude []string `mapstructure:"checkExclude" validate:"dive,path_pattern"` // 不需要文档的路由，例如 ["/metrics", "/debug/**"]
+}
+
+// TracingConfig OpenTelemetry 链路追踪插件配置
+type TracingConfig struct {
+	Enable      bool   `mapstructure:"enable"`
+	ServiceName string `mapstructure:"serviceName"` // 为空时使用 app.name
+	// Exporter otlp: 通过 OTLP/HTTP 发送到 endpoint; stdout: 输出到标准输出; file: 追加到 file (每行一个 JSON)，后两者用于本地调试
+	Exporter string            `mapstructure:"exporter" default:"otlp" validate:"omitempty,oneof=otlp stdout file"`
+	Endpoint string            `mapstructure:"endpoint" default:"localhost:4318"` // OTLP/HTTP 接收地址 (host:port)
+	URLPath  string            `mapstructure:"urlPath" default:"/v1/traces"`
+	Insecure bool              `mapstructure:"insecure"` // 使用 HTTP 而不是 HTTPS 连接 endpoint
+	Headers  map[string]string `mapstructure:"headers"`  // 发送到 endpoint 的附加请求头，例如认证信息
+	File     string            `mapstructure:"file" validate:"required_if=Exporter file"`
+	// SampleRatio 新链路的采样比例 (0~1)，请求携带 traceparent 时沿用上游的采样决定
+	SampleRatio float64 `mapstructure:"sampleRatio" default:"1" validate:"gte=0,lte=1"`
+
+	RouteScope `mapstructure:",squash"` // 创建服务端 span 的路由范围
 }
 
 // AuthConfig 认证插件配置
 type AuthConfig struct {
 	Enable bool   `mapstructure:"enable"`
-	Secret string `mapstructure:"secret"` // JWT 密钥
-	Expire int64  `mapstructure:"expire"` // 过期时间 (秒)
-	Issuer string `mapstructure:"issuer"` // 签发者 (可选)
+	Secret string `mapstructure:"secret" validate:"required_if=Enable true Algorithm HS256" secret:"true"` // HS256 密钥，使用 HS256 时不能为空
+	Expire int64  `mapstructure:"expire" default:"3600" validate:"required_if=Enable true,omitempty,gt=0"` // 过期时间 (秒)
+	Issuer string `mapstructure:"issuer"`                                                                  // 签发者 (可选)
+
+	RefreshExpire int64  `mapstructure:"refreshExpire" default:"604800" validate:"required_if=Enable true,omitempty,gt=0"`      // 刷新 Token 过期时间 (秒)
+	RoutePrefix   string `mapstructure:"routePrefix" default:"/auth" validate:"required_if=Enable true,omitempty,startswith=/"` // 登录/刷新/登出接口的路由前缀
+	// UserStore 校验登录凭据的用户存储: memory 使用下方 users 列表, gorm 使用数据库 users 表 (需要启用 database)
+	UserStore string           `mapstructure:"userStore" default:"memory" validate:"required_if=Enable true,omitempty,oneof=memory gorm"`
+	Users     []AuthUserConfig `mapstructure:"users" validate:"dive"` // memory 用户存储的用户列表
+
+	// Algorithm JWT 签名算法: HS256 使用 secret, RS256/ES256/EdDSA 使用 keys 中的 PEM 密钥对
+	Algorithm string `mapstructure:"algorithm" default:"HS256" validate:"required_if=Enable true,omitempty,oneof=HS256 RS256 ES256 EdDSA"`
+	// Mode issuer: 签发并验证 Token (注册登录接口和 JWKS); verify: 仅使用 jwksUrl 中的公钥验证其他服务签发的 Token
+	Mode         string          `mapstructure:"mode" default:"issuer" validate:"required_if=Enable true,omitempty,oneof=issuer verify"`
+	Keys         []AuthKeyConfig `mapstructure:"keys" validate:"dive"` // 非对称密钥，可同时配置多个用于轮换
+	SigningKeyID string          `mapstructure:"signingKeyId"`         // 签名使用的 kid，为空时使用第一个带私钥的密钥
+	JWKSPath     string          `mapstructure:"jwksPath" default:"/.well-known/jwks.json" validate:"required_if=Enable true,omitempty,startswith=/"`
+	JWKSURL      string          `mapstructure:"jwksUrl" validate:"required_if=Enable true Mode verify"` // verify 模式下获取公钥的 JWKS 地址
+	JWKSRefresh  string          `mapstructure:"jwksRefresh" default:"5m" validate:"omitempty,duration"` // verify 模式下 JWKS 缓存时长
+
+	// PolicyFile 授权策略文件 (YAML)，声明角色到权限的映射以及路由和 HTTP 方法需要的权限，为空表示只做认证
+	PolicyFile string `mapstructure:"policyFile"`
+
+	APIKeys APIKeyConfig `mapstructure:"apiKeys"` // 机器客户端使用的 API Key 认证
+
+	RouteScope `mapstructure:",squash"` // 需要认证的路由范围，例如 include: ["/api/**"], exclude: ["/api/v1/ping"]
+}
+
+// AuthKeyConfig JWT 非对称密钥
+// 用于签名的密钥需要私钥 (公钥由私钥导出)；轮换后仅用于验证旧 Token 的密钥可以只配置公钥。
+type AuthKeyConfig struct {
+	ID             string `mapstructure:"id" validate:"required"`                                   // kid，写入 JWT 头并发布在 JWKS 中
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`                                           // PEM 私钥 (PKCS#8, PKCS#1 或 SEC 1)
+	PublicKeyFile  string `mapstructure:"publicKeyFile" validate:"required_without=PrivateKeyFile"` // PEM 公钥 (PKIX 或 PKCS#1)
+}
+
+// APIKeyConfig API Key 认证配置
+// API Key 可以通过 Header 指定的请求头传递，或作为 "Authorization: Bearer <key>" 传递 (以 Prefix 开头的 Bearer Token 视为 API Key)。
+type APIKeyConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Header string `mapstructure:"header" default:"X-API-Key" validate:"required_if=Enable true"`
+	Prefix string `mapstructure:"prefix" default:"mgk" validate:"required_if=Enable true,omitempty,alphanum"` // 生成的 Key 形如 mgk_<id>_<secret>
+	// Store API Key 存储: memory 使用下方 keys 列表 (运行时签发和吊销的 Key 在重启后丢失)，gorm 使用数据库 api_keys 表
+	Store string `mapstructure:"store" default:"memory" validate:"required_if=Enable true,omitempty,oneof=memory gorm"`
+	// AdminPermission 调用签发/吊销/列出 API Key 管理接口需要的权限
+	AdminPermission string              `mapstructure:"adminPermission" default:"apikeys:manage"`
+	Keys            []APIKeyEntryConfig `mapstructure:"keys" validate:"dive"` // memory 存储的初始 Key
+}
+
+// APIKeyEntryConfig 配置文件中的单个 API Key，只保存哈希
+type APIKeyEntryConfig struct {
+	ID           string   `mapstructure:"id" validate:"required"` // Key 中 <id> 部分
+	Name         string   `mapstructure:"name"`
+	Hash         string   `mapstructure:"hash" validate:"required,len=64,hexadecimal" secret:"true"` // 完整 Key 的 SHA-256 (hex)
+	Scopes       []string `mapstructure:"scopes"`
+	ExpiresAt    string   `mapstructure:"expiresAt" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // RFC 3339，为空表示不过期
+	AllowedCIDRs []string `mapstructure:"allowedCidrs" validate:"dive,cidr"`                                 // 允许使用该 Key 的来源地址，为空表示不限制
+}
+
+// AuthUserConfig memory 用户存储中的单个用户
+type AuthUserConfig struct {
+	ID           int64    `mapstructure:"id" validate:"gt=0"`
+	Username     string   `mapstructure:"username" validate:"required"`
+	PasswordHash string   `mapstructure:"passwordHash" validate:"required" secret:"true"` // bcrypt 哈希, 例如 htpasswd -bnBC 10 "" password | tr -d ':\n'
+	Roles        []string `mapstructure:"roles"`                                          // 角色，通过策略文件映射为权限
+	Scopes       []string `mapstructure:"scopes"`                                         // 直接授予的权限, 例如 ["flights:search"]
 }
 
 // LoggerConfig 日志配置
 type LoggerConfig struct {
-	Level      string `yaml:"level"`
-	File       string `yaml:"file"`
-	MaxSize    int    `yaml:"maxSize"`    // 兆字节
-	MaxBackups int    `yaml:"maxBackups"` // 备份文件数
-	MaxAge     int    `yaml:"maxAge"`     // 天数
-	Compress   bool   `yaml:"compress"`
+	Level      string `mapstructure:"level" default:"info" validate:"oneof=debug info warn error dpanic panic fatal"`
+	File       string `mapstructure:"file" default:"logs/app.log" validate:"required"`
+	MaxSize    int    `mapstructure:"maxSize" default:"100" validate:"gte=0"` // 兆字节
+	MaxBackups int    `mapstructure:"maxBackups" validate:"gte=0"`            // 备份文件数
+	MaxAge     int    `mapstructure:"maxAge" validate:"gte=0"`                // 天数
+	Compress   bool   `mapstructure:"compress"`
+
+	Access AccessLogConfig `mapstructure:"access"` // HTTP 访问日志配置
+}
+
+// AccessLogConfig HTTP 访问日志中间件配置
+type AccessLogConfig struct {
+	// SkipPaths 不记录访问日志的路径 (例如健康检查)。
+	// 支持精确匹配 URL 路径或路由模板 (例如 "/api/v1/users/:id")，以 "/*" 结尾表示前缀匹配。
+	SkipPaths []string       `mapstructure:"skipPaths"`
+	Sampling  []SamplingRule `mapstructure:"sampling" validate:"dive"` // 按路由采样，未配置的路由全部记录
+}
+
+// SamplingRule 单个路由的访问日志采样规则
+// 采样仅作用于成功请求 (状态码 < 400)，错误请求总是会被记录。
+type SamplingRule struct {
+	Route string  `mapstructure:"route" validate:"required"`   // 路由模板, 例如: "/api/v1/ping"
+	Rate  float64 `mapstructure:"rate" validate:"gte=0,lte=1"` // 采样率 0~1, 例如: 0.1 表示记录 10% 的请求
 }
 
 // DatabaseConfig 数据库配置
 // 注意：yaml 不直接支持 time.Duration, gorm 通常在初始化时处理 dsn 中的参数或单独设置
 type DatabaseConfig struct {
-	Enable          bool   `yaml:"enable"`
-	Driver          string `yaml:"driver"`
-	DSN             string `yaml:"dsn"`
-	MaxIdleConns    int    `yaml:"maxIdleConns"`
-	MaxOpenConns    int    `yaml:"maxOpenConns"`
-	ConnMaxLifetime string `yaml:"connMaxLifetime"` // 保持字符串形式，初始化时解析
+	Enable          bool   `mapstructure:"enable"`
+	Driver          string `mapstructure:"driver" default:"mysql" validate:"required_if=Enable true,omitempty,oneof=mysql"` // 当前仅支持 mysql
+	DSN             string `mapstructure:"dsn" validate:"required_if=Enable true" secret:"true"`                            // 包含数据库密码
+	MaxIdleConns    int    `mapstructure:"maxIdleConns" default:"10" validate:"gte=0"`
+	MaxOpenConns    int    `mapstructure:"maxOpenConns" default:"100" validate:"gte=0"`
+	ConnMaxLifetime string `mapstructure:"connMaxLifetime" validate:"omitempty,duration"` // 保持字符串形式，初始化时解析
 }
 
 // RedisConfig Redis 配置
 type RedisConfig struct {
-	Enable   bool   `yaml:"enable"`
-	Addr     string `yaml:"addr"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
-}
\ No newline at end of file
+	Enable   bool   `mapstructure:"enable"`
+	Addr     string `mapstructure:"addr" default:"127.0.0.1:6379" validate:"required_if=Enable true"`
+	Password string `mapstructure:"password" secret:"true"`
+	DB       int    `mapstructure:"db" validate:"gte=0"`
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	Timeout       string `mapstructure:"timeout" default:"2s" validate:"omitempty,duration"`       // 单个依赖的探测超时
+	CacheTTL      string `mapstructure:"cacheTTL" default:"5s" validate:"omitempty,duration"`      // 探测结果缓存时长
+	ShutdownDelay string `mapstructure:"shutdownDelay" default:"5s" validate:"omitempty,duration"` // 收到关闭信号后, 就绪检查置为失败到真正关闭服务器之间的等待时间
+}
+
+// AdminConfig 管理端口配置
+// 管理端口与业务端口分开监听，提供 pprof、expvar、路由表、插件状态、生效配置、构建信息和日志级别切换，
+// 所有端点都需要携带 Authorization: Bearer <token>。
+type AdminConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Addr   string `mapstructure:"addr" default:"127.0.0.1:6060" validate:"required_if=Enable true"` // 默认只监听本机
+	Token  string `mapstructure:"token" secret:"true" validate:"required_if=Enable true"`
+	Pprof  bool   `mapstructure:"pprof" default:"true"` // 是否提供 /debug/pprof/
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// LoginRequest 定义登录接口的请求参数
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest 定义刷新 Token 接口的请求参数
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutRequest 定义登出接口的请求参数，访问 Token 通过 Authorization 头传递
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken,omitempty"` // 可选，同时吊销该刷新 Token
+}
+
+// TokenResponse 是登录和刷新接口的响应
+type TokenResponse struct {
+	AccessToken      string `json:"accessToken"`
+	RefreshToken     string `json:"refreshToken"`
+	TokenType        string `json:"tokenType"`        // 固定为 "Bearer"
+	ExpiresIn        int64  `json:"expiresIn"`        // 访问 Token 有效期 (秒)
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"` // 刷新 Token 有效期 (秒)
+}
+
+// CreateAPIKeyRequest 定义签发 API Key 接口的请求参数
+type CreateAPIKeyRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Scopes       []string `json:"scopes"`
+	ExpiresIn    int64    `json:"expiresIn" binding:"gte=0"`                  // 有效期 (秒)，0 表示不过期
+	AllowedCIDRs []string `json:"allowedCidrs" binding:"omitempty,dive,cidr"` // 允许的来源地址, 例如 ["10.0.0.0/8"]
+}
+
+// APIKeyResponse 描述一个 API Key，Key 明文只在签发时返回一次
+type APIKeyResponse struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Key          string     `json:"key,omitempty"`
+	Scopes       []string   `json:"scopes"`
+	AllowedCIDRs []string   `json:"allowedCidrs"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+}
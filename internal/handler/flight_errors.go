@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"net/http"
+
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/i18n"
+)
+
+// 机票模块的业务错误码。
+var (
+	// ErrOrderFailed 表示上游未能为全部乘客创建订单，detail 为服务层返回的失败原因。
+	ErrOrderFailed = errs.Define(errs.Definition{Code: 40001, Name: "order-failed", HTTPStatus: http.StatusBadRequest,
+		Messages: errs.Messages{i18n.Chinese: "订单创建失败", i18n.English: "Order creation failed"}})
+)
@@ -7,6 +7,7 @@ import (
 	"myGin/internal/dto"
 	// "myGin/internal/service"
 	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap" // 引入 zap 包
@@ -14,21 +15,11 @@ import (
 
 
 
-// SearchTickets 处理搜索机票的 POST 请求
-// @Summary 搜索机票
-// @Description 根据条件搜索机票信息
-// @Tags Flights
-// @Accept json
-// @Produce json
-// @Param searchOption body dto.SearchOption true "搜索条件"
-// @Success 200 {object} dto.SearchResult "搜索结果"
-// @Failure 400 {object} gin.H "请求参数错误"
-// @Failure 500 {object} gin.H "服务器内部错误"
-// @Router /v1/flights/tickets/search [post]
+// SearchTickets 处理搜索机票的 POST 请求，接口文档见 flight_openapi.go
 func (h *FlightHandler) SearchTickets(c *gin.Context) {
 	var opt dto.SearchOption
 	if err := c.ShouldBindJSON(&opt); err != nil {
-		errs.BadRequest.Wrap(err).JSON(c)
+		validation.Error(c, err).JSON(c)
 		return
 	}
 
@@ -47,17 +38,7 @@ func (h *FlightHandler) SearchTickets(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
-// CreateOrder 处理创建订单的 POST 请求
-// @Summary 创建机票订单
-// @Description 根据请求信息创建机票订单
-// @Tags Flights
-// @Accept json
-// @Produce json
-// @Param orderRequest body dto.OrderRequest true "订单请求信息"
-// @Success 200 {object} dto.OrderResponse "订单创建结果"
-// @Failure 400 {object} errs.APIError "请求参数错误或业务逻辑失败"
-// @Failure 500 {object} errs.APIError "服务器内部错误"
-// @Router /v1/flights/tickets/order [post]
+// CreateOrder 处理创建订单的 POST 请求，接口文档见 flight_openapi.go
 func (h *FlightHandler) CreateOrder(c *gin.Context) {
 	var req dto.OrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -68,7 +49,7 @@ func (h *FlightHandler) CreateOrder(c *gin.Context) {
 			zap.String("error", err.Error()),
 			zap.String("ip", c.ClientIP()),
 		)
-		errs.BadRequest.Wrap(err).JSON(c)
+		validation.Error(c, err).JSON(c)
 		return
 	}
 
@@ -94,9 +75,8 @@ func (h *FlightHandler) CreateOrder(c *gin.Context) {
 
 	// 检查业务逻辑是否成功
 	if !resp.Success {
-		// 将业务失败转换为 APIError 返回，保持错误格式统一
-		// 使用 40000 作为通用的业务逻辑失败代码
-		errs.NewAPIError(http.StatusBadRequest, 40000, resp.Message).JSON(c)
+		// 将业务失败转换为 APIError 返回，保持错误格式统一，失败原因作为消息
+		ErrOrderFailed.WrapWithMessage(nil, "%s", resp.Message).JSON(c)
 		return
 	}
 
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/openapi"
+)
+
+// flightRoutesSpec 返回 SearchTickets 和 CreateOrder 的 OpenAPI 声明，接口文档在这里与路由注册一起维护，basePath 为 /flights 分组的完整路径。
+func flightRoutesSpec(basePath string) []openapi.Route {
+	return []openapi.Route{
+		{
+			Method:      http.MethodPost,
+			Path:        basePath + "/tickets/search",
+			Summary:     "搜索机票",
+			Description: "根据条件搜索机票信息",
+			Tags:        []string{"Flights"},
+			Body:        dto.SearchOption{},
+			Responses: map[int]openapi.Reply{
+				http.StatusOK:                  {Description: "搜索结果", Body: dto.SearchResult{}},
+				http.StatusBadRequest:          {Description: "请求参数错误", Body: errs.ErrorResponse{}},
+				http.StatusInternalServerError: {Description: "服务器内部错误", Body: errs.ErrorResponse{}},
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        basePath + "/tickets/order",
+			Summary:     "创建机票订单",
+			Description: "根据请求信息创建机票订单",
+			Tags:        []string{"Flights"},
+			Body:        dto.OrderRequest{},
+			Secured:     true,
+			Responses: map[int]openapi.Reply{
+				http.StatusOK:                  {Description: "订单创建结果", Body: dto.OrderResponse{}},
+				http.StatusBadRequest:          {Description: "请求参数错误或业务逻辑失败", Body: errs.ErrorResponse{}},
+				http.StatusUnauthorized:        {Description: "未认证", Body: errs.ErrorResponse{}},
+				http.StatusInternalServerError: {Description: "服务器内部错误", Body: errs.ErrorResponse{}},
+			},
+		},
+	}
+}
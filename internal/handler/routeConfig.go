@@ -3,6 +3,7 @@ package handler
 import (
 
 	// "myGin/internal/bootstrap" // 移除 bootstrap 导入，解决循环依赖
+	"myGin/internal/pkg/openapi"
 	"myGin/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -32,4 +33,6 @@ func (h *FlightHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		// 注册创建订单路由
 		flightGroup.POST("/tickets/order", h.CreateOrder)
 	}
+	// 声明 OpenAPI 文档 (swagger 插件)
+	openapi.Register(flightRoutesSpec(flightGroup.BasePath())...)
 }
\ No newline at end of file
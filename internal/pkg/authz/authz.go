@@ -0,0 +1,159 @@
+// Package authz 实现基于角色和权限的授权: 角色映射到权限，策略文件声明路由和 HTTP 方法需要的权限。
+//
+// 权限是形如 "flights:order" 的字符串。授予的权限支持通配符: "*" 表示全部权限，"flights:*" 表示以 "flights:" 开头的全部权限。
+package authz
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"myGin/internal/pkg/pathmatch"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule 声明匹配的路由需要的权限，请求必须拥有全部 Permissions。
+type Rule struct {
+	Path        string   `yaml:"path"`        // 路径模式，语法见 internal/pkg/pathmatch
+	Methods     []string `yaml:"methods"`     // HTTP 方法，为空表示全部方法
+	Permissions []string `yaml:"permissions"` // 需要的权限
+}
+
+// Policy 是策略文件的内容:
+//
+//	roles:
+//	  admin: ["*"]
+//	  agent: ["flights:search", "flights:order"]
+//	rules:
+//	  - path: /api/v1/flights/tickets/order
+//	    methods: [POST]
+//	    permissions: ["flights:order"]
+type Policy struct {
+	Roles map[string][]string `yaml:"roles"` // 角色 -> 权限
+	Rules []Rule              `yaml:"rules"`
+}
+
+// LoadPolicy 读取并解析 YAML 策略文件，未知字段视为错误，避免拼写错误导致规则静默失效。
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", file, err)
+	}
+	return &p, nil
+}
+
+// compiledRule 是编译后的 Rule。
+type compiledRule struct {
+	pattern     pathmatch.Pattern
+	methods     map[string]struct{}
+	permissions []string
+}
+
+// Authorizer 根据编译后的策略计算请求需要的权限和用户拥有的权限。nil Authorizer 不要求任何权限。
+type Authorizer struct {
+	roles map[string][]string
+	rules []compiledRule
+}
+
+// Compile 校验并编译策略。
+func (p *Policy) Compile() (*Authorizer, error) {
+	a := &Authorizer{roles: p.Roles}
+	for i, r := range p.Rules {
+		pattern, err := pathmatch.Compile(r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if len(r.Permissions) == 0 {
+			return nil, fmt.Errorf("rules[%d]: permissions must not be empty", i)
+		}
+		cr := compiledRule{pattern: pattern, permissions: r.Permissions}
+		if len(r.Methods) > 0 {
+			cr.methods = make(map[string]struct{}, len(r.Methods))
+			for _, m := range r.Methods {
+				cr.methods[strings.ToUpper(m)] = struct{}{}
+			}
+		}
+		a.rules = append(a.rules, cr)
+	}
+	return a, nil
+}
+
+// Required 返回请求需要的权限: 所有匹配 method 和 path 的规则的权限并集。没有匹配的规则时返回 nil (只需认证)。
+func (a *Authorizer) Required(method, path string) []string {
+	if a == nil {
+		return nil
+	}
+	var required []string
+	for _, r := range a.rules {
+		if r.methods != nil {
+			if _, ok := r.methods[method]; !ok {
+				continue
+			}
+		}
+		if r.pattern.Match(path) {
+			required = append(required, r.permissions...)
+		}
+	}
+	return required
+}
+
+// Grant 返回角色和 scopes 授予的权限。scopes 本身即为权限，角色通过策略中的 roles 映射为权限。
+func (a *Authorizer) Grant(roles, scopes []string) Permissions {
+	granted := make(Permissions, len(scopes))
+	for _, s := range scopes {
+		granted[s] = struct{}{}
+	}
+	if a != nil {
+		for _, role := range roles {
+			for _, perm := range a.roles[role] {
+				granted[perm] = struct{}{}
+			}
+		}
+	}
+	return granted
+}
+
+// Permissions 是授予的权限集合。
+type Permissions map[string]struct{}
+
+// Has 判断是否拥有权限，支持 "*" 和 "prefix:*" 通配符。
+func (ps Permissions) Has(perm string) bool {
+	if _, ok := ps[perm]; ok {
+		return true
+	}
+	for granted := range ps {
+		if granted == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(perm, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing 返回 required 中未被授予的权限 (去重并排序)，全部拥有时返回 nil。
+func (ps Permissions) Missing(required []string) []string {
+	var missing []string
+	seen := make(map[string]struct{}, len(required))
+	for _, perm := range required {
+		if _, dup := seen[perm]; dup {
+			continue
+		}
+		seen[perm] = struct{}{}
+		if !ps.Has(perm) {
+			missing = append(missing, perm)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,53 @@
+// Package buildinfo 提供构建时通过 ldflags 注入的版本信息，例如:
+//
+//	go build -ldflags "-X myGin/internal/pkg/buildinfo.Version=v1.2.0 \
+//	  -X myGin/internal/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X myGin/internal/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 由 -ldflags "-X ..." 注入，未注入时 Commit 和 BuildTime 从 Go 工具链记录的 VCS 信息中读取。
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info 是构建信息。
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // 构建时工作区有未提交的修改 (仅从 VCS 信息读取)
+	GoVersion string `json:"goVersion"`
+	Module    string `json:"module,omitempty"`
+}
+
+// Get 返回当前二进制的构建信息。
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
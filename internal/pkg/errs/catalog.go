@@ -0,0 +1,90 @@
+package errs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"myGin/internal/pkg/i18n"
+)
+
+// TypeBase 是问题类型 URI 的前缀，Definition.Type 为 TypeBase + Name，例如 "urn:mygin:problem:bad-request"。
+// 使用 URN 而不是 URL，保证类型标识稳定且不依赖文档站点。
+const TypeBase = "urn:mygin:problem:"
+
+// Messages 是按语言 (i18n.Chinese、i18n.English) 索引的消息。
+type Messages map[string]string
+
+// Definition 声明一个错误码，每个错误码只在 Define 中声明一次。
+type Definition struct {
+	Code       int      `json:"code"`      // 业务错误码
+	Name       string   `json:"name"`      // 稳定的短名称 (kebab-case)，用于生成类型 URI 和客户端 SDK 的枚举名
+	Type       string   `json:"type"`      // 问题类型 URI (RFC 7807 的 type)，由 Define 根据 Name 生成
+	HTTPStatus int      `json:"status"`    // HTTP 状态码
+	Messages   Messages `json:"messages"`  // 默认消息，必须包含 i18n.Default
+	Retryable  bool     `json:"retryable"` // 客户端稍后重试同一请求是否可能成功
+}
+
+// Message 返回指定语言的默认消息，缺少该语言时使用 i18n.Default。
+func (d *Definition) Message(lang string) string {
+	if msg, ok := d.Messages[lang]; ok {
+		return msg
+	}
+	return d.Messages[i18n.Default]
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = make(map[int]*Definition)
+)
+
+// Define 将错误码加入目录并返回对应的预定义 APIError。
+// 与 plugin.Register 一样，错误码或名称重复、缺少名称或默认语言的消息时 panic。
+func Define(def Definition) *APIError {
+	if def.Name == "" || def.Messages[i18n.Default] == "" {
+		panic(fmt.Sprintf("errs: Define requires a name and a %q message for code %d", i18n.Default, def.Code))
+	}
+	def.Type = TypeBase + def.Name
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	if _, dup := catalog[def.Code]; dup {
+		panic(fmt.Sprintf("errs: Define called twice for code %d", def.Code))
+	}
+	for _, existing := range catalog {
+		if existing.Name == def.Name {
+			panic("errs: Define called twice for name " + def.Name)
+		}
+	}
+	catalog[def.Code] = &def
+	return &APIError{HTTPStatus: def.HTTPStatus, Code: def.Code, Message: def.Messages[i18n.Default], def: &def}
+}
+
+// Lookup 返回错误码的声明。
+func Lookup(code int) (Definition, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	def, ok := catalog[code]
+	if !ok {
+		return Definition{}, false
+	}
+	return *def, true
+}
+
+// Catalog 返回全部已声明的错误码，按错误码排序。
+func Catalog() []Definition {
+	catalogMu.RLock()
+	list := make([]Definition, 0, len(catalog))
+	for _, def := range catalog {
+		list = append(list, *def)
+	}
+	catalogMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// CatalogJSON 以 JSON 数组导出错误码目录，供客户端 SDK 生成错误枚举。
+func CatalogJSON() ([]byte, error) {
+	return json.MarshalIndent(Catalog(), "", "  ")
+}
@@ -3,6 +3,8 @@ package errs
 import (
 	"fmt"
 	"net/http"
+
+	"myGin/internal/pkg/i18n"
 )
 
 // APIError 定义了 API 响应的标准错误结构。
@@ -11,8 +13,9 @@ type APIError struct {
 	Code          int    // 自定义业务错误码
 	Message       string // 用户友好的错误信息
 	originalError error  // 原始底层错误，可选
-	// RequestID   string // 请求 ID，可选，用于跟踪请求
-	// Details  interface{} // 可选的详细信息字段
+	Details       interface{} // 可选的详细信息字段，会原样输出到响应的 details 中
+	def           *Definition // 错误码目录中的声明，由 Define 创建的错误及其派生错误才有
+	// 请求 ID 不保存在 APIError 上 (预定义错误是共享实例)，而是在 JSON 中从上下文读取
 }
 
 // Error 实现了标准的 error 接口。
@@ -35,6 +38,8 @@ func (e *APIError) Wrap(err error) *APIError {
 		Code:          e.Code,
 		Message:       e.Message, // 保留预定义的消息
 		originalError: err,
+		Details:       e.Details,
+		def:           e.def,
 	}
 }
 
@@ -46,6 +51,8 @@ func (e *APIError) WrapWithMessage(err error, message string, args ...interface{
 		Code:          e.Code,
 		Message:       fmt.Sprintf(message, args...), // 使用自定义消息
 		originalError: err,                           // 保留原始错误（如果提供）
+		Details:       e.Details,
+		def:           e.def,
 	}
 	// 如果原始错误也是 APIError，则链接原始错误
 	if apiErr, ok := err.(*APIError); ok {
@@ -54,23 +61,65 @@ func (e *APIError) WrapWithMessage(err error, message string, args ...interface{
 	return newErr
 }
 
+// WithDetails 返回附带详细信息的新 APIError 实例，不修改原始预设错误。
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	newErr := *e
+	newErr.Details = details
+	return &newErr
+}
+
+// Definition 返回错误码在目录中的声明，不是由 Define 创建的错误返回 false。
+func (e *APIError) Definition() (Definition, bool) {
+	if e.def == nil {
+		return Definition{}, false
+	}
+	return *e.def, true
+}
+
+// Retryable 报告客户端稍后重试是否可能成功，取自错误码的声明。
+func (e *APIError) Retryable() bool {
+	return e.def != nil && e.def.Retryable
+}
+
+// localizedMessage 返回指定语言的消息: 消息仍是声明中的默认消息时使用该语言的翻译，自定义消息原样返回。
+func (e *APIError) localizedMessage(lang string) string {
+	if e.def != nil && e.Message == e.def.Messages[i18n.Default] {
+		return e.def.Message(lang)
+	}
+	return e.Message
+}
+
+// Unwrap 返回被包装的原始错误，支持 errors.Is / errors.As。
+func (e *APIError) Unwrap() error {
+	return e.originalError
+}
+
 // === 预定义错误 ===
 
-// 通用错误 (根据需要自定义 Code)
+// 通用错误，每个错误码通过 Define 声明一次，业务模块的错误码在各自的包中声明 (例如 handler.ErrOrderFailed)。
+// 错误码规则: HTTP 状态码 × 100 + 序号。
 var (
-	BadRequest = &APIError{HTTPStatus: http.StatusBadRequest, Code: 40000, Message: "错误的请求"}         // 400 错误请求
-	Unauthorized = &APIError{HTTPStatus: http.StatusUnauthorized, Code: 40100, Message: "未授权"}       // 401 未授权
-	Forbidden    = &APIError{HTTPStatus: http.StatusForbidden, Code: 40300, Message: "禁止访问"}          // 403 禁止访问
-	NotFound     = &APIError{HTTPStatus: http.StatusNotFound, Code: 40400, Message: "资源未找到"}        // 404 未找到
-	Conflict     = &APIError{HTTPStatus: http.StatusConflict, Code: 40900, Message: "资源冲突"}          // 409 冲突
-	TooManyRequests = &APIError{HTTPStatus: http.StatusTooManyRequests, Code: 42900, Message: "请求过于频繁"} // 429 请求过多
+	BadRequest = Define(Definition{Code: 40000, Name: "bad-request", HTTPStatus: http.StatusBadRequest,
+		Messages: Messages{i18n.Chinese: "错误的请求", i18n.English: "Bad request"}}) // 400 错误请求
+	Unauthorized = Define(Definition{Code: 40100, Name: "unauthorized", HTTPStatus: http.StatusUnauthorized,
+		Messages: Messages{i18n.Chinese: "未授权", i18n.English: "Unauthorized"}}) // 401 未授权
+	Forbidden = Define(Definition{Code: 40300, Name: "forbidden", HTTPStatus: http.StatusForbidden,
+		Messages: Messages{i18n.Chinese: "禁止访问", i18n.English: "Forbidden"}}) // 403 禁止访问
+	NotFound = Define(Definition{Code: 40400, Name: "not-found", HTTPStatus: http.StatusNotFound,
+		Messages: Messages{i18n.Chinese: "资源未找到", i18n.English: "Resource not found"}}) // 404 未找到
+	Conflict = Define(Definition{Code: 40900, Name: "conflict", HTTPStatus: http.StatusConflict,
+		Messages: Messages{i18n.Chinese: "资源冲突", i18n.English: "Resource conflict"}}) // 409 冲突
+	TooManyRequests = Define(Definition{Code: 42900, Name: "too-many-requests", HTTPStatus: http.StatusTooManyRequests,
+		Messages: Messages{i18n.Chinese: "请求过于频繁", i18n.English: "Too many requests"}, Retryable: true}) // 429 请求过多
 
-	InternalServerError = &APIError{HTTPStatus: http.StatusInternalServerError, Code: 50000, Message: "服务器内部错误"} // 500 服务器内部错误
-	ServiceUnavailable  = &APIError{HTTPStatus: http.StatusServiceUnavailable, Code: 50300, Message: "服务不可用"}    // 503 服务不可用
+	InternalServerError = Define(Definition{Code: 50000, Name: "internal-error", HTTPStatus: http.StatusInternalServerError,
+		Messages: Messages{i18n.Chinese: "服务器内部错误", i18n.English: "Internal server error"}}) // 500 服务器内部错误
+	ServiceUnavailable = Define(Definition{Code: 50300, Name: "service-unavailable", HTTPStatus: http.StatusServiceUnavailable,
+		Messages: Messages{i18n.Chinese: "服务不可用", i18n.English: "Service unavailable"}, Retryable: true}) // 503 服务不可用
 )
 
-// NewAPIError 创建一个新的 APIError。
-// 通常建议对预定义错误使用 Wrap 或 WrapWithMessage。
+// NewAPIError 创建一个不在错误码目录中的 APIError，problem+json 响应的 type 为 "about:blank"。
+// 应优先使用 Define 声明错误码，或对预定义错误使用 Wrap 或 WrapWithMessage。
 func NewAPIError(httpStatus, code int, message string) *APIError {
 	return &APIError{
 		HTTPStatus: httpStatus,
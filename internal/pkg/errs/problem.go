@@ -0,0 +1,62 @@
+package errs
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType 是 RFC 7807 问题详情的媒体类型。
+const ProblemContentType = "application/problem+json"
+
+// Problem 是 RFC 7807 问题详情 (application/problem+json)。
+// type / title / status / detail / instance 为标准成员，其余为扩展成员。
+type Problem struct {
+	Type      string      `json:"type"`               // 问题类型 URI，未在目录中声明的错误为 "about:blank"
+	Title     string      `json:"title"`              // 问题类型的简短描述 (声明中的默认消息)
+	Status    int         `json:"status"`             // HTTP 状态码
+	Detail    string      `json:"detail,omitempty"`   // 本次错误的说明
+	Instance  string      `json:"instance,omitempty"` // 出错的请求路径
+	Code      int         `json:"code"`               // 业务错误码，与默认格式中的 code 相同
+	RequestID string      `json:"requestId,omitempty"`
+	Retryable bool        `json:"retryable,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Problem 返回 e 在指定语言下的问题详情，Instance 和 RequestID 由调用方填写。
+func (e *APIError) Problem(lang string) Problem {
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.HTTPStatus),
+		Status:    e.HTTPStatus,
+		Detail:    e.localizedMessage(lang),
+		Code:      e.Code,
+		Retryable: e.Retryable(),
+		Details:   e.Details,
+	}
+	if e.def != nil {
+		p.Type = e.def.Type
+		p.Title = e.def.Message(lang)
+	}
+	return p
+}
+
+// AcceptsProblem 报告客户端是否通过 Accept 请求头要求 application/problem+json (q 不为 0)。
+func AcceptsProblem(c *gin.Context) bool {
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != ProblemContentType {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -1,6 +1,9 @@
 package errs
 
 import (
+	"myGin/internal/pkg/i18n"
+	"myGin/internal/pkg/requestid"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap" // 引入 zap 日志库
 )
@@ -9,11 +12,12 @@ import (
 type ErrorResponse struct {
 	Code    int    `json:"code"`    // 自定义业务错误码
 	Message string `json:"message"` // 用户友好的错误信息
-	// 可选地添加 RequestID、Details 等。
-	// RequestID string `json:"requestId,omitempty"`
+	// RequestID 与响应头 X-Request-ID 相同，便于根据错误响应定位日志
+	RequestID string      `json:"requestId,omitempty"`
+	Details   interface{} `json:"details,omitempty"` // 可选的详细信息
 }
 
-// JSON 使用提供的 Gin 上下文将 APIError 作为 JSON 响应发送。
+// JSON 使用提供的 Gin 上下文将 APIError 作为 JSON 响应发送，客户端要求时使用 application/problem+json 格式。
 // 它设置适当的 HTTP 状态码并记录错误（如果 originalError 存在）。
 func (e *APIError) JSON(c *gin.Context) {
 	// 如果原始错误存在且不为 nil，则记录该错误
@@ -44,9 +48,21 @@ func (e *APIError) JSON(c *gin.Context) {
 		// }
 	}
 
+	// 消息按 Accept-Language 翻译；Accept 包含 application/problem+json 时以 RFC 7807 格式响应
+	lang := i18n.FromGin(c)
+	if AcceptsProblem(c) {
+		problem := e.Problem(lang)
+		problem.Instance = c.Request.URL.Path
+		problem.RequestID = requestid.FromGin(c)
+		c.Header("Content-Type", ProblemContentType)
+		c.AbortWithStatusJSON(e.HTTPStatus, problem)
+		return
+	}
+
 	c.AbortWithStatusJSON(e.HTTPStatus, ErrorResponse{
-		Code:    e.Code,
-		Message: e.Message,
-		// RequestID: c.GetString("request_id"), // Example: include request ID if available
+		Code:      e.Code,
+		Message:   e.localizedMessage(lang),
+		RequestID: requestid.FromGin(c), // 由 requestid.Middleware 设置，未设置时省略
+		Details:   e.Details,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,241 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status 表示单个依赖或整体的健康状态。
+type Status string
+
+const (
+	StatusUp   Status = "up"   // 正常
+	StatusDown Status = "down" // 异常
+)
+
+// Checker 是所有健康检查器必须实现的接口。
+type Checker interface {
+	// Name 返回依赖名称 (例如 "database", "redis")，用于 JSON 输出。
+	Name() string
+	// Check 执行一次探测，返回 nil 表示依赖可用。
+	Check(ctx context.Context) error
+}
+
+// CheckFunc 是探测函数的签名。
+type CheckFunc func(ctx context.Context) error
+
+// funcChecker 将普通函数适配为 Checker。
+type funcChecker struct {
+	name string
+	fn   CheckFunc
+}
+
+func (f *funcChecker) Name() string                    { return f.name }
+func (f *funcChecker) Check(ctx context.Context) error { return f.fn(ctx) }
+
+// NewChecker 使用名称和探测函数创建一个 Checker。
+func NewChecker(name string, fn CheckFunc) Checker {
+	return &funcChecker{name: name, fn: fn}
+}
+
+// CheckResult 是单个依赖的检查结果。
+type CheckResult struct {
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Latency   string    `json:"latency"`   // 探测耗时，例如 "1.2ms"
+	CheckedAt time.Time `json:"checkedAt"` // 探测完成时间
+	Cached    bool      `json:"cached"`    // 是否为缓存结果
+}
+
+// Report 是一次整体检查的汇总结果。
+type Report struct {
+	Status       Status                 `json:"status"`
+	ShuttingDown bool                   `json:"shuttingDown,omitempty"`
+	Checks       map[string]CheckResult `json:"checks"` // key: 依赖名称
+}
+
+// Option 用于在注册时调整单个检查器的参数。
+type Option func(*entry)
+
+// WithTimeout 设置该检查器的探测超时时间。
+func WithTimeout(d time.Duration) Option {
+	return func(e *entry) { e.timeout = d }
+}
+
+// WithCacheTTL 设置该检查器结果的缓存时长，0 表示不缓存。
+func WithCacheTTL(d time.Duration) Option {
+	return func(e *entry) { e.cacheTTL = d }
+}
+
+// entry 保存一个已注册的检查器及其缓存的结果。
+type entry struct {
+	checker  Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	last   CheckResult
+	hasRun bool
+}
+
+// Registry 是可插拔的健康检查器注册表。
+// 它并发执行所有检查器，并按照各自的 TTL 缓存结果，避免探针请求压垮下游依赖。
+type Registry struct {
+	mu              sync.RWMutex
+	entries         []*entry
+	defaultTimeout  time.Duration
+	defaultCacheTTL time.Duration
+	shuttingDown    atomic.Bool
+}
+
+// NewRegistry 创建一个新的 Registry。
+// timeout 和 cacheTTL 是注册检查器时未单独指定参数的默认值。
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second // 默认探测超时
+	}
+	if cacheTTL < 0 {
+		cacheTTL = 0
+	}
+	return &Registry{
+		defaultTimeout:  timeout,
+		defaultCacheTTL: cacheTTL,
+	}
+}
+
+// Register 注册一个检查器。同名检查器会被替换。
+func (r *Registry) Register(c Checker, opts ...Option) {
+	e := &entry{
+		checker:  c,
+		timeout:  r.defaultTimeout,
+		cacheTTL: r.defaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.entries {
+		if existing.checker.Name() == c.Name() {
+			r.entries[i] = e
+			return
+		}
+	}
+	r.entries = append(r.entries, e)
+}
+
+// Names 返回所有已注册检查器的名称 (按注册顺序)。
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		names = append(names, e.checker.Name())
+	}
+	return names
+}
+
+// SetShuttingDown 将注册表标记为正在关闭。
+// 此后就绪检查始终返回失败，让编排系统在 srv.Shutdown 之前停止转发流量。
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// IsShuttingDown 返回服务是否正在关闭。
+func (r *Registry) IsShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Check 并发执行所有检查器并返回汇总报告。
+// 任一依赖异常时整体状态为 down。
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	entries := make([]*entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = e.run(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:       StatusUp,
+		ShuttingDown: r.IsShuttingDown(),
+		Checks:       make(map[string]CheckResult, len(entries)),
+	}
+	for i, e := range entries {
+		report.Checks[e.checker.Name()] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// Ready 返回就绪报告以及服务是否可以接收流量。
+// 正在关闭时直接返回 false，不再探测依赖。
+func (r *Registry) Ready(ctx context.Context) (Report, bool) {
+	if r.IsShuttingDown() {
+		return Report{Status: StatusDown, ShuttingDown: true, Checks: map[string]CheckResult{}}, false
+	}
+	report := r.Check(ctx)
+	return report, report.Status == StatusUp
+}
+
+// run 执行单个检查器，命中缓存时直接返回上次结果。
+// 同一检查器的并发调用会被串行化，保证缓存过期时只有一次真实探测。
+func (e *entry) run(ctx context.Context) CheckResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.hasRun && e.cacheTTL > 0 && time.Since(e.last.CheckedAt) < e.cacheTTL {
+		cached := e.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.safeCheck(checkCtx)
+	if err == nil && checkCtx.Err() != nil {
+		// 检查器忽略了 ctx，但已经超时，仍视为失败
+		err = checkCtx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Latency:   time.Since(start).String(),
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	e.last = result
+	e.hasRun = true
+	return result
+}
+
+// safeCheck 调用检查器并将 panic 转换为错误，避免探针拖垮进程。
+func (e *entry) safeCheck(ctx context.Context) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic during health check: %v", rec)
+		}
+	}()
+	return e.checker.Check(ctx)
+}
@@ -0,0 +1,44 @@
+// Package i18n 根据 Accept-Language 请求头选择响应消息的语言。
+package i18n
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的语言。
+const (
+	Chinese = "zh"
+	English = "en"
+
+	Default = Chinese // 未指定或不支持的语言使用中文
+)
+
+// Negotiate 解析 Accept-Language (例如 "en-US,en;q=0.9,zh;q=0.8")，返回权重最高的受支持语言，
+// 只比较主语言标签，权重相同时取靠前的一个。
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if (primary == Chinese || primary == English) && q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+	return best
+}
+
+// FromGin 返回请求的 Accept-Language 协商得到的语言。
+func FromGin(c *gin.Context) string {
+	return Negotiate(c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,150 @@
+// Package jwks 提供 JWT 非对称密钥的加载 (PEM) 以及 JWK / JWKS (RFC 7517) 的编码、解码和远程获取。
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// 支持的签名算法 (JWS "alg")。
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Key 是单个 JSON Web Key，只包含公钥参数。
+type Key struct {
+	Kty string `json:"kty"`           // RSA, EC, OKP
+	Kid string `json:"kid,omitempty"` // 密钥 ID，与 JWT 头中的 kid 对应
+	Use string `json:"use,omitempty"` // 固定为 "sig"
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC (P-256) 与 OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Set 是 JWKS 文档，即 /.well-known/jwks.json 的响应。
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// Lookup 按 kid 查找密钥。
+func (s *Set) Lookup(kid string) (Key, bool) {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+var b64 = base64.RawURLEncoding
+
+// NewKey 将公钥编码为 JWK，公钥类型必须与 alg 对应。
+func NewKey(kid, alg string, pub crypto.PublicKey) (Key, error) {
+	if err := CheckKeyType(alg, pub); err != nil {
+		return Key{}, err
+	}
+	k := Key{Kid: kid, Use: "sig", Alg: alg}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		k.Kty = "RSA"
+		k.N = b64.EncodeToString(pub.N.Bytes())
+		k.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		k.Kty, k.Crv = "EC", "P-256"
+		k.X = b64.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+		k.Y = b64.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		k.Kty, k.Crv = "OKP", "Ed25519"
+		k.X = b64.EncodeToString(pub)
+	}
+	return k, nil
+}
+
+// PublicKey 解码 JWK 得到公钥: *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey。
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: invalid n: %w", k.Kid, err)
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: invalid e: %w", k.Kid, err)
+		}
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %q: invalid RSA key", k.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, errX := b64.DecodeString(k.X)
+		y, errY := b64.DecodeString(k.Y)
+		if err := errors.Join(errX, errY); err != nil {
+			return nil, fmt.Errorf("jwk %q: invalid coordinates: %w", k.Kid, err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("jwk %q: point is not on curve P-256", k.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %q: invalid Ed25519 key", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwk %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+}
+
+// CheckKeyType 检查公钥或私钥的类型是否与签名算法匹配 (ES256 还要求 P-256 曲线)。
+func CheckKeyType(alg string, key interface{}) error {
+	ok := false
+	switch alg {
+	case RS256:
+		switch key.(type) {
+		case *rsa.PublicKey, *rsa.PrivateKey:
+			ok = true
+		}
+	case ES256:
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			ok = k.Curve == elliptic.P256()
+		case *ecdsa.PrivateKey:
+			ok = k.Curve == elliptic.P256()
+		}
+	case EdDSA:
+		switch key.(type) {
+		case ed25519.PublicKey, ed25519.PrivateKey:
+			ok = true
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if !ok {
+		return fmt.Errorf("key type %T does not match algorithm %s", key, alg)
+	}
+	return nil
+}
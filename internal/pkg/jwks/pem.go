@@ -0,0 +1,66 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadPrivateKey 从 PEM 文件加载私钥，支持 PKCS#8 ("PRIVATE KEY")、PKCS#1 ("RSA PRIVATE KEY") 和 SEC 1 ("EC PRIVATE KEY")。
+func LoadPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key %s: %w", file, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("parse private key %s: unsupported key type %T", file, key)
+	}
+	return signer, nil
+}
+
+// LoadPublicKey 从 PEM 文件加载公钥，支持 PKIX ("PUBLIC KEY") 和 PKCS#1 ("RSA PUBLIC KEY")。
+func LoadPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	var key crypto.PublicKey
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %w", file, err)
+	}
+	return key, nil
+}
+
+// readPEM 读取文件中的第一个 PEM 块。
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in " + file)
+	}
+	return block, nil
+}
@@ -0,0 +1,163 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound 表示 JWKS 中没有请求的 kid。
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+// minRefetchInterval 限制因未知 kid 触发的重新获取频率，避免伪造 kid 的请求打爆 JWKS 服务。
+const minRefetchInterval = 10 * time.Second
+
+// maxBodySize 限制 JWKS 响应体大小。
+const maxBodySize = 1 << 20
+
+// remoteKey 是从 JWKS 解码出的公钥及其算法。
+type remoteKey struct {
+	alg string
+	pub crypto.PublicKey
+}
+
+// Remote 从远程 JWKS 地址获取验证公钥并缓存。
+// 缓存超过 refresh 后在下一次查询时重新获取；遇到未知 kid (签发方轮换了密钥) 时也会重新获取，但频率受 minRefetchInterval 限制。
+// 获取在锁外进行，同一时刻最多一个请求: 刷新期间已缓存的 kid 直接返回，未知 kid 等待进行中的获取。获取失败时继续使用已缓存的密钥。
+type Remote struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]remoteKey
+	fetchedAt   time.Time  // 上次成功获取的时间
+	attemptedAt time.Time  // 上次尝试获取的时间
+	inflight    *fetchCall // 进行中的获取，没有时为 nil
+}
+
+// fetchCall 是一次进行中的获取，done 关闭后 err 为获取结果。
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemote 创建远程 JWKS 缓存，client 为 nil 时使用 10 秒超时的默认客户端。
+func NewRemote(url string, refresh time.Duration, client *http.Client) *Remote {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Remote{url: url, refresh: refresh, client: client, keys: map[string]remoteKey{}}
+}
+
+// Refresh 立即重新获取 JWKS，已有获取在进行时等待它的结果。
+func (r *Remote) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	call := r.startFetch(ctx)
+	r.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Key 返回 kid 对应的公钥及其算法 (JWK 未声明 alg 时为空)。
+// 缓存过期但 kid 已缓存时在后台刷新并直接返回缓存的密钥；kid 未缓存时等待获取完成。
+func (r *Remote) Key(ctx context.Context, kid string) (crypto.PublicKey, string, error) {
+	r.mu.Lock()
+	k, ok := r.keys[kid]
+	stale := r.refresh > 0 && time.Since(r.fetchedAt) > r.refresh
+	call := r.inflight
+	if (stale || !ok) && call == nil && time.Since(r.attemptedAt) >= minRefetchInterval {
+		call = r.startFetch(ctx)
+	}
+	r.mu.Unlock()
+	if ok {
+		return k.pub, k.alg, nil
+	}
+	if call == nil {
+		return nil, "", fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+	r.mu.Lock()
+	k, ok = r.keys[kid]
+	r.mu.Unlock()
+	if !ok {
+		if call.err != nil {
+			return nil, "", call.err
+		}
+		return nil, "", fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return k.pub, k.alg, nil
+}
+
+// startFetch 在后台开始一次获取并返回它，已有获取在进行时返回进行中的获取。调用方需持有锁。
+// 获取不随发起请求的 context 取消 (由 client 的超时限制)，其他等待者不会因为发起者断开而失败。
+func (r *Remote) startFetch(ctx context.Context) *fetchCall {
+	if r.inflight != nil {
+		return r.inflight
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	r.inflight = call
+	r.attemptedAt = time.Now()
+	go func() {
+		keys, err := r.fetch(context.WithoutCancel(ctx))
+		r.mu.Lock()
+		if err == nil {
+			r.keys = keys
+			r.fetchedAt = time.Now()
+		}
+		call.err = err
+		r.inflight = nil
+		r.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// fetch 获取并解析 JWKS，不访问缓存，因此不需要持有锁。
+func (r *Remote) fetch(ctx context.Context) (map[string]remoteKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: unexpected status %s", r.url, resp.Status)
+	}
+
+	var set Set
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: decode %s: %w", r.url, err)
+	}
+	keys := make(map[string]remoteKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue // 忽略无法识别的密钥 (例如不支持的曲线)，不影响其他密钥
+		}
+		keys[k.Kid] = remoteKey{alg: k.Alg, pub: pub}
+	}
+	return keys, nil
+}
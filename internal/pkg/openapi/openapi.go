@@ -0,0 +1,280 @@
+// Package openapi 根据声明的路由和 dto 结构体生成 OpenAPI 3 文档。
+// 请求和响应的 schema 通过反射 json / form 标签和 binding 校验标签得到，
+// 路由在注册 Gin 处理函数的地方通过 Register 声明，与实际路由保持一致。
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version 是生成文档的 OpenAPI 版本。
+const Version = "3.0.3"
+
+// SecurityBearer 是 Bearer Token (JWT 或 API Key) 认证方案的名称。
+const SecurityBearer = "bearerAuth"
+
+// Document 是 OpenAPI 文档。
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info 是文档的基本信息。
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem 是同一路径下按小写 HTTP 方法索引的操作。
+type PathItem map[string]*Operation
+
+// Operation 是单个接口。
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 是路径或查询参数。
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 是请求体。
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response 是一种响应。
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType 是请求体或响应体的内容。
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components 是可复用的 schema 和认证方案。
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 是认证方案。
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Route 声明一个接口，Path 使用 Gin 的路由语法 (例如 "/auth/apikeys/:id")。
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Query       interface{}   // 查询参数结构体 (form 标签)，可选
+	Body        interface{}   // 请求体结构体 (json 标签)，可选
+	Responses   map[int]Reply // 按 HTTP 状态码的响应
+	Secured     bool          // 需要 Authorization: Bearer 认证
+}
+
+// Reply 描述一种响应，Body 为 nil 时没有响应体。
+type Reply struct {
+	Description string
+	Body        interface{}
+}
+
+// Registry 保存声明的路由，同一方法和路径重复声明时后者覆盖前者。
+type Registry struct {
+	mu     sync.RWMutex
+	routes map[string]Route
+}
+
+// NewRegistry 创建一个空的路由注册表。
+func NewRegistry() *Registry {
+	return &Registry{routes: make(map[string]Route)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局路由注册表，业务路由和插件路由都声明在这里。
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register 在全局注册表中声明路由。
+func Register(routes ...Route) {
+	defaultRegistry.Add(routes...)
+}
+
+// Reset 清空全局注册表。路由在注册 Gin 路由时声明，全局注册表会保留之前构建的引擎声明的路由，
+// 测试在构建新引擎之前调用 Reset，文档和路由检查才只反映当前引擎的路由。
+func Reset() {
+	defaultRegistry.Reset()
+}
+
+// Reset 删除全部已声明的路由。
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = make(map[string]Route)
+}
+
+// Add 声明路由。
+func (r *Registry) Add(routes ...Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, route := range routes {
+		route.Method = strings.ToUpper(route.Method)
+		r.routes[routeKey(route.Method, route.Path)] = route
+	}
+}
+
+// Has 报告是否声明了指定方法和 Gin 路径的路由。
+func (r *Registry) Has(method, path string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.routes[routeKey(strings.ToUpper(method), path)]
+	return ok
+}
+
+// Routes 返回声明的路由，按路径和方法排序。
+func (r *Registry) Routes() []Route {
+	r.mu.RLock()
+	list := make([]Route, 0, len(r.routes))
+	for _, route := range r.routes {
+		list = append(list, route)
+	}
+	r.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Method < list[j].Method
+	})
+	return list
+}
+
+// Build 生成包含所有声明路由的文档。
+func (r *Registry) Build(info Info) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				SecurityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "JWT 访问 Token 或 API Key"},
+			},
+		},
+	}
+	gen := newSchemaGenerator(doc.Components.Schemas)
+	for _, route := range r.Routes() {
+		path, params := convertPath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = buildOperation(gen, route, params)
+	}
+	return doc
+}
+
+// buildOperation 根据路由声明生成接口描述。
+func buildOperation(gen *schemaGenerator, route Route, pathParams []string) *Operation {
+	op := &Operation{
+		Tags:        route.Tags,
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(route.Method, route.Path),
+		Responses:   make(map[string]Response, len(route.Responses)),
+	}
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if route.Query != nil {
+		op.Parameters = append(op.Parameters, gen.queryParameters(reflect.TypeOf(route.Query))...)
+	}
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(gen.schema(reflect.TypeOf(route.Body)))}
+	}
+	for status, reply := range route.Responses {
+		resp := Response{Description: reply.Description}
+		if resp.Description == "" {
+			resp.Description = http.StatusText(status)
+		}
+		if reply.Body != nil {
+			resp.Content = jsonContent(gen.schema(reflect.TypeOf(reply.Body)))
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = Response{Description: "响应"}
+	}
+	if route.Secured {
+		op.Security = []map[string][]string{{SecurityBearer: {}}}
+	}
+	return op
+}
+
+// jsonContent 返回 application/json 内容。
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// convertPath 将 Gin 路径 (:id、*path) 转换为 OpenAPI 路径 ({id})，并返回路径参数名。
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 由方法和路径生成唯一的 operationId，例如 "post_auth_login"。
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.TrimLeft(seg, ":*")
+		if seg == "" {
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteString(strings.NewReplacer("-", "_", ".", "_").Replace(seg))
+	}
+	return b.String()
+}
+
+// routeKey 返回注册表中路由的 key。
+func routeKey(method, path string) string {
+	return method + " " + path
+}
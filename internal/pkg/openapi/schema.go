@@ -0,0 +1,320 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema 是 JSON Schema (OpenAPI 3.0 子集)。
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // *Schema 或 true
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator 通过反射生成 schema，具名结构体放入 components.schemas 并以 $ref 引用。
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// newSchemaGenerator 创建把具名结构体写入 schemas 的生成器。
+func newSchemaGenerator(schemas map[string]*Schema) *schemaGenerator {
+	return &schemaGenerator{schemas: schemas, names: make(map[reflect.Type]string)}
+}
+
+// schema 返回类型 t 的 schema。
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	s := g.schemaOf(t)
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+// schemaOf 返回非指针类型 t 的 schema。
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return &Schema{Type: "object", AdditionalProperties: true}
+		}
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.define(t)}
+	default:
+		// interface{} 等任意值
+		return &Schema{}
+	}
+}
+
+// define 将具名结构体写入 components.schemas 并返回名称，不同包中的同名类型以包名区分。
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // 占位，支持递归引用
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+// structSchema 生成结构体的 object schema，匿名嵌入的结构体字段会被展开。
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields 将结构体 t 的字段加入 s。
+func (g *schemaGenerator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f, "json")
+		if f.Anonymous && !hasTagName(f, "json") {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !ok {
+			continue
+		}
+		prop := g.schema(f.Type)
+		if applyBinding(prop, f.Tag.Get("binding"), f.Type) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// queryParameters 根据结构体的 form 标签和 binding 标签生成查询参数。
+func (g *schemaGenerator) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := fieldName(f, "form")
+		if !ok {
+			continue
+		}
+		s := g.schema(f.Type)
+		required := applyBinding(s, f.Tag.Get("binding"), f.Type)
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: s})
+	}
+	return params
+}
+
+// fieldName 返回字段在指定标签 (json 或 form) 下的名称，未导出或标签为 "-" 时返回 false。
+func fieldName(f reflect.StructField, tag string) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// hasTagName 报告字段的标签是否显式指定了名称。
+func hasTagName(f reflect.StructField, tag string) bool {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	return name != ""
+}
+
+// applyBinding 将 validator 的 binding 规则转换为 schema 约束，返回字段是否必填。
+// dive 之后的规则作用于数组元素。无法用 schema 表达的规则 (例如 cidr) 会被忽略。
+func applyBinding(s *Schema, binding string, t reflect.Type) (required bool) {
+	if binding == "" {
+		return false
+	}
+	if s.Ref != "" {
+		s = &Schema{} // OpenAPI 3.0 中 $ref 的同级关键字会被忽略，只读取是否必填
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	rules := strings.Split(binding, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if s.Items != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				applyBinding(s.Items, strings.Join(rules[i+1:], ","), t.Elem())
+			}
+			return required
+		case "required":
+			required = true
+		case "len":
+			n := atoi(param)
+			setLength(s, t, &n, &n)
+		case "min":
+			n := atoi(param)
+			if isNumber(t) {
+				s.Minimum = floatPtr(param)
+			} else {
+				setLength(s, t, &n, nil)
+			}
+		case "max":
+			n := atoi(param)
+			if isNumber(t) {
+				s.Maximum = floatPtr(param)
+			} else {
+				setLength(s, t, nil, &n)
+			}
+		case "gte", "gt":
+			if isNumber(t) {
+				s.Minimum, s.ExclusiveMinimum = floatPtr(param), name == "gt"
+			} else if name == "gte" {
+				n := atoi(param)
+				setLength(s, t, &n, nil)
+			}
+		case "lte", "lt":
+			if isNumber(t) {
+				s.Maximum, s.ExclusiveMaximum = floatPtr(param), name == "lt"
+			} else if name == "lte" {
+				n := atoi(param)
+				setLength(s, t, nil, &n)
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(v, t))
+			}
+		case "datetime":
+			if param == "2006-01-02" {
+				s.Format = "date"
+			} else {
+				s.Format = "date-time"
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri", "http_url":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		}
+	}
+	return required
+}
+
+// setLength 按类型设置字符串长度或数组元素个数的限制。
+func setLength(s *Schema, t reflect.Type, min, max *int) {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if min != nil {
+			s.MinItems = min
+		}
+		if max != nil {
+			s.MaxItems = max
+		}
+	case reflect.String:
+		if min != nil {
+			s.MinLength = min
+		}
+		if max != nil {
+			s.MaxLength = max
+		}
+	}
+}
+
+// isNumber 报告类型是否为数值。
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// enumValue 按字段类型转换 oneof 中的枚举值。
+func enumValue(v string, t reflect.Type) interface{} {
+	if isNumber(t) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// atoi 解析规则参数中的整数，无效时返回 0。
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// floatPtr 解析规则参数中的数值。
+func floatPtr(s string) *float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
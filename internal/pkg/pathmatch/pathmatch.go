@@ -0,0 +1,156 @@
+// Package pathmatch 实现基于路径段的 URL 路径模式匹配，用于按路由范围挂载插件中间件。
+//
+// 模式语法 (以 "/" 分隔的路径段):
+//   - "**" 匹配零个或多个路径段，例如 "/api/v1/**" 匹配 "/api/v1" 及其下所有路径；
+//   - "*" 或 ":name" 匹配恰好一个路径段，例如 "/api/v1/users/:id"；
+//   - 其他路径段按 path.Match 语法匹配，例如 "*.json"。
+//
+// 末尾的 "/" 会被忽略。
+package pathmatch
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Pattern 是编译后的路径模式。
+type Pattern struct {
+	raw  string
+	segs []string
+}
+
+// Compile 编译单个路径模式，模式必须以 "/" 开头。
+func Compile(pattern string) (Pattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return Pattern{}, fmt.Errorf("path pattern %q must start with \"/\"", pattern)
+	}
+	segs := splitPath(pattern)
+	for _, seg := range segs {
+		if seg == "**" || seg == "*" || strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if strings.Contains(seg, "**") {
+			return Pattern{}, fmt.Errorf("path pattern %q: \"**\" must be a whole path segment", pattern)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return Pattern{}, fmt.Errorf("path pattern %q: invalid segment %q: %w", pattern, seg, err)
+		}
+	}
+	return Pattern{raw: pattern, segs: segs}, nil
+}
+
+// String 返回原始模式。
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// Specificity 返回模式的具体程度，用于在多个匹配的模式中选择最具体的一个:
+// 每个字面量路径段计 3 分，"*"、":name" 或通配段计 2 分，"**" 不计分，不含 "**" 的模式额外加 1 分。
+// 例如 "/api/v1/users/:id" (12) > "/api/v1/users/**" (9) > "/api/**" (3)。
+func (p Pattern) Specificity() int {
+	score, exact := 0, 1
+	for _, seg := range p.segs {
+		switch {
+		case seg == "**":
+			exact = 0
+		case seg == "*" || strings.HasPrefix(seg, ":") || strings.ContainsAny(seg, "*?["):
+			score += 2
+		default:
+			score += 3
+		}
+	}
+	return score + exact
+}
+
+// Match 判断 URL 路径是否匹配模式。
+func (p Pattern) Match(urlPath string) bool {
+	return matchSegments(p.segs, splitPath(urlPath))
+}
+
+// matchSegments 递归匹配路径段，"**" 可以吞掉任意数量的路径段。
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		seg := pattern[0]
+		if seg == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if seg != "*" && !strings.HasPrefix(seg, ":") {
+			if ok, _ := path.Match(seg, parts[0]); !ok {
+				return false
+			}
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// splitPath 将路径拆分为路径段，忽略首尾的 "/"。
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// Matcher 组合 include/exclude 模式: 路径匹配任一 include (include 为空时视为全部匹配)
+// 且不匹配任何 exclude 时才算匹配，exclude 优先。
+type Matcher struct {
+	include []Pattern
+	exclude []Pattern
+}
+
+// NewMatcher 编译 include/exclude 模式，返回所有无效模式的错误。
+func NewMatcher(include, exclude []string) (*Matcher, error) {
+	m := &Matcher{}
+	var errList []error
+	for _, raw := range include {
+		p, err := Compile(raw)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		m.include = append(m.include, p)
+	}
+	for _, raw := range exclude {
+		p, err := Compile(raw)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		m.exclude = append(m.exclude, p)
+	}
+	if len(errList) > 0 {
+		return nil, errors.Join(errList...)
+	}
+	return m, nil
+}
+
+// Match 判断 URL 路径是否在作用范围内。
+func (m *Matcher) Match(urlPath string) bool {
+	for _, p := range m.exclude {
+		if p.Match(urlPath) {
+			return false
+		}
+	}
+	if len(m.include) == 0 {
+		return true
+	}
+	for _, p := range m.include {
+		if p.Match(urlPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+// Package ratelimit 提供限流算法和配额计数: 进程内令牌桶 (MemoryLimiter) 以及基于 Redis Lua 脚本的
+// 分布式令牌桶 (RedisTokenBucket) 和滑动窗口 (RedisSlidingWindow)；每日配额使用 Counter (MemoryCounter / RedisCounter)。
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// 限流算法名称，对应配置 modules.ratelimit.algorithm。
+const (
+	TokenBucket   = "token_bucket"
+	SlidingWindow = "sliding_window"
+)
+
+// Limit 描述一个限流规则。令牌桶使用 Rate 和 Burst；滑动窗口在任意长度为 Window 的时间段内
+// 最多允许 Rate × Window 个请求 (至少 1 个)。
+type Limit struct {
+	Rate   float64 // 每秒请求数
+	Burst  int     // 令牌桶容量
+	Window time.Duration
+}
+
+// WindowMax 返回滑动窗口内允许的请求数。
+func (l Limit) WindowMax() int {
+	return max(1, int(math.Ceil(l.Rate*l.Window.Seconds())))
+}
+
+// Result 是一次限流判断的结果。
+type Result struct {
+	Allowed    bool
+	Limit      int           // 配额上限 (令牌桶为 Burst，滑动窗口为 WindowMax)
+	Remaining  int           // 本次请求后剩余的配额
+	RetryAfter time.Duration // 被拒绝时距离下次可能成功的时间，允许时为 0
+	ResetAfter time.Duration // 距离配额完全恢复的时间
+}
+
+// Limiter 判断 key 对应的请求是否允许通过。limit 随每次调用传入，配置热重载后立即生效。
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryLimiter 是进程内的令牌桶限流器，每个 key 一个 rate.Limiter，保存在分片的有界 LRU 存储中。
+// 限流状态不在实例间共享，适用于单实例部署，或作为 Redis 不可用时的降级方案。
+// key 空闲超过 IdleTTL 后被清除 (IdleTTL 不小于令牌桶回满的时间时不影响限流结果)；
+// key 数超过 MaxKeys 时淘汰最久未使用的 key，被淘汰的调用方下次请求时获得一个满的令牌桶。
+type MemoryLimiter struct {
+	limiters *store[*rate.Limiter]
+}
+
+// NewMemoryLimiter 创建进程内令牌桶限流器。
+func NewMemoryLimiter(opts StoreOptions) *MemoryLimiter {
+	return &MemoryLimiter{limiters: newStore[*rate.Limiter](opts)}
+}
+
+// Allow 实现 Limiter。limit 与已有限流器的参数不同时 (配置热重载) 会就地调整。
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	res := Result{Limit: limit.Burst}
+	create := func() *rate.Limiter { return rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst) }
+	m.limiters.get(key, now, create, func(limiter *rate.Limiter) {
+		if limiter.Limit() != rate.Limit(limit.Rate) {
+			limiter.SetLimitAt(now, rate.Limit(limit.Rate))
+		}
+		if limiter.Burst() != limit.Burst {
+			limiter.SetBurstAt(now, limit.Burst)
+		}
+
+		reservation := limiter.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay == 0 {
+			res.Allowed = true
+		} else {
+			// 不等待令牌，归还预约并告知调用方需要等待多久
+			reservation.CancelAt(now)
+			res.RetryAfter = delay
+		}
+		tokens := limiter.TokensAt(now)
+		res.Remaining = max(0, int(tokens))
+		if limit.Rate > 0 {
+			res.ResetAfter = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+		}
+	})
+	return res, nil
+}
+
+// Stats 返回当前 key 数和累计淘汰数，调用时会先清除空闲的 key。
+func (m *MemoryLimiter) Stats() StoreStats {
+	return m.limiters.stats(time.Now())
+}
+
+// Counter 是按 key 计数、到期清零的计数器，用于每日配额等固定周期的限制。
+type Counter interface {
+	// Incr 将 key 的计数加 1 并返回新值，计数在 expireAt 时清零。
+	Incr(ctx context.Context, key string, expireAt time.Time) (int64, error)
+}
+
+// counterSweepInterval 是 MemoryCounter 清理过期计数的最小间隔。
+const counterSweepInterval = time.Minute
+
+// MemoryCounter 是进程内的 Counter，计数保存在与 MemoryLimiter 相同的有界 LRU 存储中。
+// key 数超过 MaxKeys 时淘汰最久未使用的计数，被淘汰的调用方重新从 0 开始计数。
+type MemoryCounter struct {
+	counts    *store[*memoryCount]
+	lastSweep atomic.Int64 // 上次清理过期计数的时间 (UnixNano)
+}
+
+type memoryCount struct {
+	n        int64
+	expireAt time.Time
+}
+
+// NewMemoryCounter 创建进程内计数器。opts.IdleTTL 不生效: 计数必须保留到 expireAt，不能因调用方空闲而清零。
+func NewMemoryCounter(opts StoreOptions) *MemoryCounter {
+	opts.IdleTTL = 0
+	return &MemoryCounter{counts: newStore[*memoryCount](opts)}
+}
+
+// Incr 实现 Counter。
+func (m *MemoryCounter) Incr(_ context.Context, key string, expireAt time.Time) (int64, error) {
+	now := time.Now()
+	// 每日配额的 key 带有日期，过期后不会再被访问，需要定期清理
+	if last := m.lastSweep.Load(); now.UnixNano()-last >= int64(counterSweepInterval) && m.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		m.counts.removeIf(func(c *memoryCount) bool { return !now.Before(c.expireAt) })
+	}
+	var n int64
+	create := func() *memoryCount { return &memoryCount{expireAt: expireAt} }
+	m.counts.get(key, now, create, func(c *memoryCount) {
+		if !now.Before(c.expireAt) {
+			c.n, c.expireAt = 0, expireAt
+		}
+		c.n++
+		n = c.n
+	})
+	return n, nil
+}
+
+// Stats 返回当前计数的 key 数、累计淘汰数和已清理的过期计数数。
+func (m *MemoryCounter) Stats() StoreStats {
+	return m.counts.stats(time.Now())
+}
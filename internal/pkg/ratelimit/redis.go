@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript 原子地补充并消耗令牌。状态保存在 hash {tokens, ts} 中，时间取自 Redis TIME，
+// 避免各实例时钟不一致。返回 {allowed, remaining, retry_after_ms, reset_after_ms}。
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = (1 - tokens) / rate
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+local reset = (burst - tokens) / rate
+redis.call('PEXPIRE', KEYS[1], math.ceil(reset * 1000) + 1000)
+return {allowed, math.floor(tokens), math.ceil(retry * 1000), math.ceil(reset * 1000)}
+`)
+
+// slidingWindowScript 在 sorted set 中记录窗口内每个请求的时间戳 (毫秒)，
+// 先删除窗口外的记录，再判断数量是否超过上限。返回值同 tokenBucketScript。
+var slidingWindowScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tostring(now - window))
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', KEYS[1], tostring(now), ARGV[3])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = math.max(0, tonumber(oldest[2]) + window - now)
+end
+local retry = 0
+if allowed == 0 then
+  retry = reset
+end
+return {allowed, limit - count, retry, reset}
+`)
+
+// counterScript 计数加 1，首次创建时设置过期时间 (毫秒时间戳)。
+var counterScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+  redis.call('PEXPIREAT', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// RedisTokenBucket 是基于 Redis 的分布式令牌桶限流器，多个实例共享同一个桶。
+type RedisTokenBucket struct {
+	rdb    redis.Scripter
+	prefix string
+}
+
+// NewRedisTokenBucket 创建 Redis 令牌桶限流器，key 在 Redis 中保存为 prefix+key。
+func NewRedisTokenBucket(rdb redis.Scripter, prefix string) *RedisTokenBucket {
+	return &RedisTokenBucket{rdb: rdb, prefix: prefix}
+}
+
+// Allow 实现 Limiter。
+func (r *RedisTokenBucket) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, r.rdb, []string{r.prefix + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("redis token bucket: %w", err)
+	}
+	return scriptResult(values, limit.Burst)
+}
+
+// RedisSlidingWindow 是基于 Redis 的分布式滑动窗口限流器，精确统计最近 Window 内的请求数，
+// 没有令牌桶在窗口边界处的突发，但每个 key 需要保存窗口内全部请求的时间戳。
+type RedisSlidingWindow struct {
+	rdb    redis.Scripter
+	prefix string
+	seq    atomic.Uint64
+	nonce  string // 实例随机标识，与 seq 组成 sorted set 成员
+}
+
+// NewRedisSlidingWindow 创建 Redis 滑动窗口限流器，key 在 Redis 中保存为 prefix+key。
+func NewRedisSlidingWindow(rdb redis.Scripter, prefix string) *RedisSlidingWindow {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return &RedisSlidingWindow{rdb: rdb, prefix: prefix, nonce: hex.EncodeToString(b)}
+}
+
+// Allow 实现 Limiter。
+func (r *RedisSlidingWindow) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	windowMax := limit.WindowMax()
+	// sorted set 成员必须唯一，同一毫秒内的多个请求 (可能来自不同实例) 才会分别计数
+	member := r.nonce + "-" + strconv.FormatUint(r.seq.Add(1), 36)
+	values, err := slidingWindowScript.Run(ctx, r.rdb, []string{r.prefix + key},
+		limit.Window.Milliseconds(), windowMax, member).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("redis sliding window: %w", err)
+	}
+	return scriptResult(values, windowMax)
+}
+
+// RedisCounter 是基于 Redis 的 Counter，多个实例共享计数。
+type RedisCounter struct {
+	rdb    redis.Scripter
+	prefix string
+}
+
+// NewRedisCounter 创建 Redis 计数器，key 在 Redis 中保存为 prefix+key。
+func NewRedisCounter(rdb redis.Scripter, prefix string) *RedisCounter {
+	return &RedisCounter{rdb: rdb, prefix: prefix}
+}
+
+// Incr 实现 Counter。
+func (r *RedisCounter) Incr(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	n, err := counterScript.Run(ctx, r.rdb, []string{r.prefix + key}, expireAt.UnixMilli()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis counter: %w", err)
+	}
+	return n, nil
+}
+
+// scriptResult 将 Lua 脚本返回的 {allowed, remaining, retry_after_ms, reset_after_ms} 转换为 Result。
+func scriptResult(values []int64, limit int) (Result, error) {
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected ratelimit script result %v", values)
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(max(0, values[1])),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
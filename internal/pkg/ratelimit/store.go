@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 进程内存储的默认参数。
+const (
+	DefaultShards  = 64
+	DefaultMaxKeys = 100000
+	DefaultIdleTTL = 10 * time.Minute
+)
+
+// StoreOptions 配置进程内限流器的 key 存储。
+type StoreOptions struct {
+	Shards  int           // 分片数，默认 DefaultShards；各分片独立加锁
+	MaxKeys int           // 所有分片合计的最大 key 数，超出时淘汰最久未使用的 key；0 表示不限
+	IdleTTL time.Duration // key 空闲超过该时长后被清除；0 表示不按空闲时间清除
+}
+
+// StoreStats 是进程内存储的统计信息。
+type StoreStats struct {
+	ActiveKeys  int    // 当前保存的 key 数
+	Evictions   uint64 // 因超过 MaxKeys 被淘汰的 key 累计数
+	Expirations uint64 // 因空闲超过 IdleTTL 被清除的 key 累计数
+}
+
+// store 是按 key 分片、容量有界的 LRU 存储。每个分片内按最近访问时间排序，
+// 访问时顺带清除分片尾部的空闲 key，因此不需要后台清理协程。
+type store[V any] struct {
+	seed        maphash.Seed
+	shards      []*shard[V]
+	idleTTL     time.Duration
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type shard[V any] struct {
+	mu       sync.Mutex
+	capacity int // 0 表示不限
+	items    map[string]*list.Element
+	lru      list.List // 元素为 *storeEntry[V]，表头为最近访问
+}
+
+type storeEntry[V any] struct {
+	key      string
+	value    V
+	lastSeen time.Time
+}
+
+// newStore 根据 opts 创建存储，MaxKeys 平均分配到各分片。
+func newStore[V any](opts StoreOptions) *store[V] {
+	n := opts.Shards
+	if n <= 0 {
+		n = DefaultShards
+	}
+	if opts.MaxKeys > 0 && opts.MaxKeys < n {
+		n = opts.MaxKeys
+	}
+	s := &store[V]{seed: maphash.MakeSeed(), shards: make([]*shard[V], n), idleTTL: opts.IdleTTL}
+	for i := range s.shards {
+		sh := &shard[V]{items: make(map[string]*list.Element)}
+		if opts.MaxKeys > 0 {
+			sh.capacity = opts.MaxKeys / n
+			if i < opts.MaxKeys%n {
+				sh.capacity++
+			}
+		}
+		s.shards[i] = sh
+	}
+	return s
+}
+
+// get 返回 key 对应的值，不存在时使用 create 创建。fn 在分片锁内对值执行，用于读取或更新状态。
+func (s *store[V]) get(key string, now time.Time, create func() V, fn func(V)) {
+	sh := s.shards[maphash.String(s.seed, key)%uint64(len(s.shards))]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	s.expire(sh, now)
+	if el, ok := sh.items[key]; ok {
+		e := el.Value.(*storeEntry[V])
+		e.lastSeen = now
+		sh.lru.MoveToFront(el)
+		fn(e.value)
+		return
+	}
+	if sh.capacity > 0 && len(sh.items) >= sh.capacity {
+		s.remove(sh, sh.lru.Back())
+		s.evictions.Add(1)
+	}
+	e := &storeEntry[V]{key: key, value: create(), lastSeen: now}
+	sh.items[key] = sh.lru.PushFront(e)
+	fn(e.value)
+}
+
+// expire 清除分片尾部空闲超过 idleTTL 的 key，需持有分片锁。
+func (s *store[V]) expire(sh *shard[V], now time.Time) {
+	if s.idleTTL <= 0 {
+		return
+	}
+	for el := sh.lru.Back(); el != nil; el = sh.lru.Back() {
+		if now.Sub(el.Value.(*storeEntry[V]).lastSeen) < s.idleTTL {
+			return
+		}
+		s.remove(sh, el)
+		s.expirations.Add(1)
+	}
+}
+
+func (s *store[V]) remove(sh *shard[V], el *list.Element) {
+	delete(sh.items, el.Value.(*storeEntry[V]).key)
+	sh.lru.Remove(el)
+}
+
+// removeIf 删除所有分片中满足 fn 的 key，计入 Expirations。
+func (s *store[V]) removeIf(fn func(V) bool) {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for el := sh.lru.Back(); el != nil; {
+			prev := el.Prev()
+			if fn(el.Value.(*storeEntry[V]).value) {
+				s.remove(sh, el)
+				s.expirations.Add(1)
+			}
+			el = prev
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// stats 清除所有分片中的空闲 key 后返回统计信息。
+func (s *store[V]) stats(now time.Time) StoreStats {
+	var keys int
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		s.expire(sh, now)
+		keys += len(sh.items)
+		sh.mu.Unlock()
+	}
+	return StoreStats{ActiveKeys: keys, Evictions: s.evictions.Load(), Expirations: s.expirations.Load()}
+}
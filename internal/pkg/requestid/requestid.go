@@ -0,0 +1,125 @@
+package requestid
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName 是用于传递和回写请求 ID 的 HTTP 头。
+const HeaderName = "X-Request-ID"
+
+// TraceparentHeader 是 W3C Trace Context 定义的链路头。
+// 当请求未携带 X-Request-ID 时，使用其中的 trace-id 作为请求 ID，便于与链路追踪系统关联。
+const TraceparentHeader = "traceparent"
+
+// GinKey 是请求 ID 在 gin.Context 中的键。
+const GinKey = "request_id"
+
+// maxLength 限制入站请求 ID 的长度，防止日志被超长头部污染。
+const maxLength = 128
+
+// ctxKey 是请求 ID 在 context.Context 中的键类型，避免与其他包冲突。
+type ctxKey struct{}
+
+// Middleware 返回请求 ID 中间件。
+// 应作为第一个中间件注册，以便后续中间件、处理函数和 service 层都能取到请求 ID。
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Ensure(c)
+		c.Next()
+	}
+}
+
+// Ensure 确保当前请求拥有请求 ID 并返回它。
+// 按以下顺序确定请求 ID：已存入上下文的值、X-Request-ID 头、traceparent 头中的 trace-id、新生成的 ID。
+// 结果会同时写入 gin.Context、c.Request 的 context.Context 以及响应头。该函数是幂等的。
+func Ensure(c *gin.Context) string {
+	if id := c.GetString(GinKey); id != "" {
+		return id
+	}
+
+	id := c.GetHeader(HeaderName)
+	if !isValid(id) {
+		id = traceIDFromTraceparent(c.GetHeader(TraceparentHeader))
+	}
+	if id == "" {
+		id = New()
+	}
+
+	c.Set(GinKey, id)
+	c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+	c.Header(HeaderName, id)
+	return id
+}
+
+// FromGin 返回 gin.Context 中的请求 ID，不存在时返回空字符串。
+func FromGin(c *gin.Context) string {
+	return c.GetString(GinKey)
+}
+
+// NewContext 返回携带请求 ID 的新 context.Context。
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 返回 context.Context 中的请求 ID，不存在时返回空字符串。
+// service 层可通过它将请求 ID 写入日志或透传给下游调用。
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New 生成一个 32 位十六进制的随机请求 ID。
+func New() string {
+	var b [16]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// crypto/rand 失败极其罕见，退化为基于时间的 ID
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// isValid 检查入站请求 ID 是否可以直接沿用。
+// 只接受长度受限的可打印 ASCII 字符，防止日志注入。
+func isValid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// traceIDFromTraceparent 从 traceparent 头中解析 trace-id。
+// 格式: {version}-{trace-id}-{parent-id}-{trace-flags}，例如
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01。
+// 格式无效或 trace-id 全为 0 时返回空字符串。
+func traceIDFromTraceparent(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if len(traceID) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ""
+	}
+	if _, err := hex.DecodeString(traceID); err != nil {
+		return ""
+	}
+	if strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
@@ -0,0 +1,184 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reloader 持有当前使用的服务端证书和客户端 CA 证书包，并在文件变更时重新加载。
+// 通过 tls.Config.GetCertificate / GetConfigForClient 接入，证书轮换无需重启进程。
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	logger   *zap.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time // 上次加载时各文件的修改时间
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewReloader 创建 Reloader 并立即加载一次证书。
+// caFile 为空表示不校验客户端证书。
+func NewReloader(certFile, keyFile, caFile string, logger *zap.Logger) (*Reloader, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		logger:   logger,
+		stop:     make(chan struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 返回当前证书，签名与 tls.Config.GetCertificate 一致。
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// ClientCAs 返回当前的客户端 CA 证书池，未配置 caFile 时返回 nil。
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.caPool
+}
+
+// Reload 在任一文件的修改时间发生变化时重新加载证书。
+// 加载失败时保留旧证书继续服务，并返回错误。
+func (r *Reloader) Reload() (bool, error) {
+	changed, err := r.filesChanged()
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := r.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Watch 启动后台 goroutine，按 interval 轮询证书文件。
+// 使用轮询而不是 inotify，是为了兼容 Kubernetes Secret 通过符号链接原子替换文件的方式。
+func (r *Reloader) Watch(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				reloaded, err := r.Reload()
+				if err != nil {
+					r.logger.Error("Failed to reload TLS certificates, keeping previous ones", zap.Error(err))
+				} else if reloaded {
+					r.logger.Info("TLS certificates reloaded", zap.String("certFile", r.certFile))
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台轮询。可重复调用。
+func (r *Reloader) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// load 读取所有文件并原子替换当前证书。
+func (r *Reloader) load() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS key pair (%s, %s): %w", r.certFile, r.keyFile, err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pool, err = LoadCertPool(r.caFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.caPool = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// files 返回需要监控的文件列表。
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+func (r *Reloader) statFiles() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, 3)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("stat TLS file: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *Reloader) filesChanged() (bool, error) {
+	current, err := r.statFiles()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for f, t := range current {
+		if !t.Equal(r.modTimes[f]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LoadCertPool 从 PEM 文件加载 CA 证书池。
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bytes.TrimSpace(data)) {
+		return nil, errors.New("CA bundle " + file + " contains no valid PEM certificates")
+	}
+	return pool, nil
+}
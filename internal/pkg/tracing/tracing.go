@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// InstrumentationName 是本服务创建 span 使用的 tracer 名称。
+const InstrumentationName = "myGin"
+
+// 日志中链路字段的名称。
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// LogFields 返回 ctx 中当前 span 的 trace_id 和 span_id 日志字段，没有有效的 span 时返回 nil。
+// service 层可以用它将日志与链路关联:
+//
+//	logger.With(tracing.LogFields(ctx)...)
+func LogFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String(TraceIDKey, sc.TraceID().String()),
+		zap.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
+
+// Start 使用全局 TracerProvider 创建子 span，用于在 service 层标记耗时步骤 (例如调用上游 API)。
+// tracing 插件未启用时全局 TracerProvider 为 no-op，开销可以忽略。
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, opts...)
+}
+
+// End 结束 span，err 不为 nil 时记录错误并将 span 标记为失败。
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
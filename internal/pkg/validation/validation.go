@@ -0,0 +1,210 @@
+// Package validation 将请求绑定失败的错误 (validator 校验错误、JSON 语法和类型错误) 转换为字段级的错误明细，
+// 错误消息按 Accept-Language 使用中文或英文。
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 非 validator 规则的错误类型，对应 FieldError.Rule。
+const (
+	RuleJSON  = "json"  // 请求体不是合法的 JSON
+	RuleType  = "type"  // JSON 值的类型与字段类型不匹配
+	RuleEmpty = "empty" // 请求体为空
+)
+
+// FieldError 是 400 响应 details 中的一条错误。
+type FieldError struct {
+	Field   string `json:"field,omitempty"`  // JSON 字段路径，例如 "passengers[0].name"；请求体整体的错误为空
+	Rule    string `json:"rule"`             // 失败的校验规则，例如 "required"、"len"，或 RuleJSON / RuleType / RuleEmpty
+	Param   string `json:"param,omitempty"`  // 规则参数，例如 len=3 中的 "3"；类型不匹配时为期望的 JSON 类型
+	Message string `json:"message"`          // 按 Accept-Language 翻译的消息
+	Offset  int64  `json:"offset,omitempty"` // JSON 语法或类型错误在请求体中的字节偏移
+}
+
+// messages 是本包自己的消息模板。
+var messages = map[string]map[string]string{
+	i18n.Chinese: {
+		"invalid":   "请求参数校验失败",
+		"malformed": "请求体格式错误",
+		RuleJSON:    "请求体不是合法的 JSON (第 %d 字节附近): %s",
+		RuleType:    "%s 应为 %s 类型，实际为 %s",
+		RuleEmpty:   "请求体不能为空",
+		"truncated": "请求体 JSON 不完整",
+		"rule":      "%s 不满足 %s 规则",
+	},
+	i18n.English: {
+		"invalid":   "request validation failed",
+		"malformed": "malformed request body",
+		RuleJSON:    "request body is not valid JSON (near byte %d): %s",
+		RuleType:    "%s must be of type %s, got %s",
+		RuleEmpty:   "request body must not be empty",
+		"truncated": "request body is truncated JSON",
+		"rule":      "%s failed on the '%s' rule",
+	},
+}
+
+// translators 是 validator 内置规则的翻译器，按语言索引。
+var translators = map[string]ut.Translator{}
+
+// init 让 Gin 默认校验器使用 json 标签作为字段名，并注册中英文翻译。
+// 校验器会缓存结构体的字段名，因此需要在任何请求绑定之前完成。
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(jsonFieldName)
+	uni := ut.New(zh.New(), zh.New(), en.New())
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	if zhtranslations.RegisterDefaultTranslations(v, zhTrans) == nil {
+		translators[i18n.Chinese] = zhTrans
+	}
+	if entranslations.RegisterDefaultTranslations(v, enTrans) == nil {
+		translators[i18n.English] = enTrans
+	}
+}
+
+// Error 将 c.ShouldBind* 返回的错误转换为 errs.BadRequest，details 为 []FieldError，语言取自请求的 Accept-Language。
+func Error(c *gin.Context, err error) *errs.APIError {
+	lang := i18n.FromGin(c)
+	details := Details(err, lang)
+	msg := messages[lang]["invalid"]
+	if len(details) > 0 && details[0].Field == "" {
+		msg = messages[lang]["malformed"]
+	}
+	apiErr := errs.BadRequest.WrapWithMessage(err, "%s", msg)
+	if len(details) > 0 {
+		apiErr = apiErr.WithDetails(details)
+	}
+	return apiErr
+}
+
+// Details 返回绑定错误的字段级明细，无法识别的错误返回 nil。
+func Details(err error, lang string) []FieldError {
+	if _, ok := messages[lang]; !ok {
+		lang = i18n.Default
+	}
+	msg := messages[lang]
+
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		details := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			details = append(details, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: translate(fe, lang),
+			})
+		}
+		return details
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Rule: RuleJSON, Offset: syntaxErr.Offset, Message: fmt.Sprintf(msg[RuleJSON], syntaxErr.Offset, syntaxErr.Error())}}
+	case errors.As(err, &typeErr):
+		field := jsonPath(typeErr.Field)
+		expected := jsonType(typeErr.Type)
+		return []FieldError{{
+			Field: field, Rule: RuleType, Param: expected, Offset: typeErr.Offset,
+			Message: fmt.Sprintf(msg[RuleType], field, expected, typeErr.Value),
+		}}
+	case errors.Is(err, io.EOF):
+		return []FieldError{{Rule: RuleEmpty, Message: msg[RuleEmpty]}}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Rule: RuleJSON, Message: msg["truncated"]}}
+	}
+	return nil
+}
+
+// translate 翻译 validator 错误，内置翻译未覆盖的规则使用通用消息。
+func translate(fe validator.FieldError, lang string) string {
+	if trans, ok := translators[lang]; ok {
+		if s := fe.Translate(trans); s != fe.Error() {
+			return s
+		}
+	}
+	return fmt.Sprintf(messages[lang]["rule"], fe.Field(), fe.Tag())
+}
+
+// fieldPath 去掉命名空间中的根结构体名，例如 "CreateAPIKeyRequest.allowedCidrs[0]" → "allowedCidrs[0]"。
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// jsonPath 将 encoding/json 的字段路径转换为与校验错误一致的形式，例如 "passengers.0.gender" → "passengers[0].gender"。
+func jsonPath(field string) string {
+	if field == "" {
+		return ""
+	}
+	var b strings.Builder
+	for i, seg := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(seg); err == nil {
+			b.WriteString("[" + seg + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// jsonFieldName 返回字段的 json 名称，作为校验错误中的字段名。
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// jsonType 返回 Go 类型对应的 JSON 类型名。
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return t.String()
+}
@@ -1,13 +1,23 @@
 package plugin
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time" // 需要 time 包来处理过期时间
 
 	"myGin/internal/conf"
+	"myGin/internal/dto"
+	"myGin/internal/pkg/authz"
 	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/jwks"
+	"myGin/internal/pkg/openapi"
+	"myGin/internal/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5" // 使用别名
@@ -18,15 +28,49 @@ import (
 type AuthPlugin struct {
 	authCfg *conf.AuthConfig // 存储加载的认证配置
 	logger  *zap.Logger
-	secret  []byte // 预编译的 secret，提高性能
+	secret  []byte // 预编译的 secret，提高性能 (仅 HS256)
+
+	algorithm  string                      // 签名算法 (HS256, RS256, ES256, EdDSA)
+	method     jwt.SigningMethod
+	signKey    interface{}                 // 签名密钥: HS256 为 secret，非对称算法为私钥；verify 模式为 nil
+	signKid    string                      // 签名密钥的 kid，写入 JWT 头
+	verifyKeys map[string]crypto.PublicKey // 本地验证公钥 (kid -> 公钥)
+	jwks       *jwks.Set                   // 发布在 jwksPath 的公钥集合
+	remote     *jwks.Remote                // verify 模式下的远程 JWKS
+
+	authorizer *authz.Authorizer // 授权策略，未配置 policyFile 时为 nil (只做认证)
+
+	users   UserStore       // 登录和刷新时校验用户
+	apiKeys APIKeyStore     // API Key 认证，未启用时为 nil
+	revoked RevocationStore // 已吊销的 jti (登出、刷新 Token 轮换)
+
+	metrics *MetricsPlugin // 记录认证失败次数，metrics 插件未启用时为 nil
 }
 
+// Token 类型，写入 claims 的 token_type 字段。访问 Token 和刷新 Token 不能互相替代。
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // MyCustomClaims 定义了 JWT 的自定义 Claims，嵌入了 RegisteredClaims 并添加了 UserID。
 // 你可以根据需要添加更多字段。
 type MyCustomClaims struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"` // 添加 Username 字段示例
-	jwt.RegisteredClaims
+	// TokenType 区分访问 Token 和刷新 Token
+	TokenType string   `json:"token_type"`
+	Roles     []string `json:"roles,omitempty"`  // 角色，通过授权策略映射为权限
+	Scopes    []string `json:"scopes,omitempty"` // 直接授予的权限，例如 "flights:order"
+	jwt.RegisteredClaims // ID (jti) 用于吊销
+}
+
+func init() {
+	Register("auth", NewAuthPlugin,
+		WithConfig(func(c *conf.Config) (interface{}, bool) {
+			return &c.Modules.Auth, c.Modules.Auth.Enable
+		}),
+	)
 }
 
 // NewAuthPlugin 创建一个新的 AuthPlugin 实例。
@@ -37,7 +81,7 @@ func NewAuthPlugin() Plugin {
 }
 
 // Init 初始化 AuthPlugin。符合 Plugin 接口，接收 interface{} 并断言为 *conf.AuthConfig。
-func (p *AuthPlugin) Init(cfg interface{}, deps map[string]interface{}) error {
+func (p *AuthPlugin) Init(cfg interface{}, deps Deps) error {
 	// 1. 类型断言获取 Auth 配置
 	authCfg, ok := cfg.(*conf.AuthConfig)
 	if !ok {
@@ -48,15 +92,12 @@ func (p *AuthPlugin) Init(cfg interface{}, deps map[string]interface{}) error {
 	p.authCfg = authCfg // 存储断言后的配置
 
 	// 2. 获取 logger 依赖
-	loggerDep, ok := deps["logger"]
-	if !ok {
-		// 如果没有 logger，可能需要返回错误或使用默认 logger
-		return fmt.Errorf("auth plugin init failed: logger dependency is missing")
-	}
-	p.logger, ok = loggerDep.(*zap.Logger)
-	if !ok {
-		return fmt.Errorf("auth plugin init failed: logger dependency is not of type *zap.Logger")
+	logger, err := Dep[*zap.Logger](deps, DepLogger)
+	if err != nil {
+		return fmt.Errorf("auth plugin init failed: %w", err)
 	}
+	p.logger = logger
+	p.metrics = metricsFrom(deps)
 
 	// 3. 检查是否启用 (现在 p.authCfg 肯定不是 nil)
 	if !p.authCfg.Enable {
@@ -64,158 +105,458 @@ func (p *AuthPlugin) Init(cfg interface{}, deps map[string]interface{}) error {
 		return nil // 如果未启用，则无需执行任何操作
 	}
 
-	// 4. 检查并存储密钥 (仅在启用时检查)
-	if p.authCfg.Secret == "" {
-		p.logger.Error("Auth Plugin init failed: JWT secret is empty in config")
-		return fmt.Errorf("auth plugin init failed: JWT secret cannot be empty when enabled")
+	// 4. 加载签名和验证密钥
+	if err := p.initKeys(); err != nil {
+		return fmt.Errorf("auth plugin init failed: %w", err)
 	}
-	p.secret = []byte(p.authCfg.Secret) // 存储字节切片
 
-	// 5. 检查过期时间 (秒) (仅在启用时检查)
-	if p.authCfg.Expire <= 0 {
-		p.logger.Warn("Auth Plugin: JWT expire time (seconds) is not set or invalid, using default 1 hour (3600s)", zap.Int64("expire_seconds", p.authCfg.Expire))
-		p.authCfg.Expire = 3600 // 默认1小时 (3600秒)
-	}
-
-	// 6. 检查签发者 (可选) (仅在启用时检查)
+	// 5. 检查签发者 (可选) (仅在启用时检查)
 	if p.authCfg.Issuer == "" {
 		p.logger.Warn("Auth Plugin: JWT issuer is not set in config.")
 		// 根据策略决定是否返回错误，这里仅警告
 	}
 
+	// 6. 用户存储: 优先使用注入的 UserStore，否则按配置创建 (verify 模式不签发 Token，无需用户存储)
+	if !p.verifyOnly() {
+		users, err := p.newUserStore(deps)
+		if err != nil {
+			return fmt.Errorf("auth plugin init failed: %w", err)
+		}
+		p.users = users
+	}
+
+	// 7. API Key 存储 (可选)
+	if p.authCfg.APIKeys.Enable {
+		apiKeys, err := p.newAPIKeyStore(deps)
+		if err != nil {
+			return fmt.Errorf("auth plugin init failed: %w", err)
+		}
+		p.apiKeys = apiKeys
+	}
+
+	// 8. 授权策略 (可选)
+	if p.authCfg.PolicyFile != "" {
+		policy, err := authz.LoadPolicy(p.authCfg.PolicyFile)
+		if err != nil {
+			return fmt.Errorf("auth plugin init failed: %w", err)
+		}
+		if p.authorizer, err = policy.Compile(); err != nil {
+			return fmt.Errorf("auth plugin init failed: policy %s: %w", p.authCfg.PolicyFile, err)
+		}
+		p.logger.Info("Auth Plugin policy loaded", zap.String("file", p.authCfg.PolicyFile),
+			zap.Int("roles", len(policy.Roles)), zap.Int("rules", len(policy.Rules)))
+	}
+
+	// 9. 吊销存储: Redis 可用时多个实例共享，否则退回到进程内存
+	if rdb, ok := deps.Redis(); ok {
+		p.revoked = NewRedisRevocationStore(rdb)
+	} else {
+		p.logger.Info("Auth Plugin: Redis is not available, revoked tokens are kept in memory.")
+		p.revoked = NewMemoryRevocationStore()
+	}
+
 	p.logger.Info("Auth Plugin initialized successfully.",
 		zap.Int64("expire_seconds", p.authCfg.Expire),
+		zap.Int64("refresh_expire_seconds", p.authCfg.RefreshExpire),
 		zap.String("issuer", p.authCfg.Issuer),
+		zap.String("algorithm", p.algorithm),
+		zap.String("mode", p.authCfg.Mode),
+		zap.String("user_store", fmt.Sprintf("%T", p.users)),
+		zap.Bool("api_keys", p.apiKeys != nil),
 	)
 	return nil
 }
 
-// Register 将 JWT 认证中间件注册到 Gin 引擎。
-func (p *AuthPlugin) Register(r *gin.Engine) error {
-	// 再次检查是否启用，因为 Init 可能因为配置缺失而将 Enable 设为 false
-	if p.authCfg == nil || !p.authCfg.Enable {
-		p.logger.Info("Auth Plugin is disabled, skipping middleware registration.")
-		return nil // 如果未启用，则不注册任何路由或中间件
+// newUserStore 返回 Deps 中注入的 UserStore，或根据 modules.auth.userStore 创建内置存储。
+func (p *AuthPlugin) newUserStore(deps Deps) (UserStore, error) {
+	if deps.Has(DepUserStore) {
+		return Dep[UserStore](deps, DepUserStore)
 	}
+	switch p.authCfg.UserStore {
+	case "gorm":
+		db, ok := deps.DB()
+		if !ok {
+			return nil, errors.New("userStore gorm requires the database to be enabled")
+		}
+		store := NewGormUserStore(db)
+		if err := store.Migrate(context.Background()); err != nil {
+			return nil, fmt.Errorf("migrate users table: %w", err)
+		}
+		return store, nil
+	default:
+		if len(p.authCfg.Users) == 0 {
+			p.logger.Warn("Auth Plugin: memory user store has no users configured (modules.auth.users), login will always fail.")
+		}
+		return NewMemoryUserStore(p.authCfg.Users)
+	}
+}
 
-	p.logger.Info("Registering Auth Plugin middleware globally...")
-	r.Use(p.jwtAuthMiddleware())
-
-	p.logger.Info("Auth Plugin middleware registered globally.")
+// Register 注册登录、刷新和登出接口 (路由前缀为 modules.auth.routePrefix，默认 /auth)，
+// 启用 API Key 时注册需要 apiKeys.adminPermission 权限的 Key 管理接口 ({routePrefix}/apikeys)，
+// 使用非对称算法时还会在 modules.auth.jwksPath 发布公钥；verify 模式下不注册任何路由。
+// JWT 认证中间件通过 Middleware 提供，由 bootstrap 按 modules.auth.include/exclude 挂载到匹配的路由上；
+// 这些接口自己校验 Token，不应位于 include 范围内。
+func (p *AuthPlugin) Register(r *gin.Engine) error {
+	if !p.authCfg.Enable || p.verifyOnly() {
+		return nil
+	}
+	if p.jwks != nil {
+		r.GET(p.authCfg.JWKSPath, p.serveJWKS)
+		p.logger.Info("Auth Plugin JWKS endpoint registered", zap.String("path", p.authCfg.JWKSPath))
+	}
+	group := r.Group(p.authCfg.RoutePrefix)
+	group.POST("/login", p.login)
+	group.POST("/refresh", p.refresh)
+	group.POST("/logout", p.logout)
+	if p.apiKeys != nil {
+		admin := group.Group("/apikeys", p.Middleware(), RequirePermission(p.authCfg.APIKeys.AdminPermission))
+		admin.POST("", p.issueAPIKey)
+		admin.GET("", p.listAPIKeys)
+		admin.DELETE("/:id", p.revokeAPIKey)
+	}
+	openapi.Register(p.routesSpec()...)
+	p.logger.Info("Auth Plugin routes registered", zap.String("prefix", p.authCfg.RoutePrefix))
 	return nil
 }
 
+// Middleware 返回认证中间件 (实现 MiddlewareProvider 接口)。
+func (p *AuthPlugin) Middleware() gin.HandlerFunc {
+	return p.authMiddleware()
+}
 
-// jwtAuthMiddleware 创建并返回 JWT 认证中间件。
-func (p *AuthPlugin) jwtAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			p.logger.Debug("Auth middleware: Authorization header is missing")
-			errs.Unauthorized.WrapWithMessage(nil, "请求未携带Token").JSON(c)
-			c.Abort()
-			return
+// authenticate 从请求中读取凭据并校验: 启用 API Key 时依次检查 apiKeys.header 请求头和以 Key 前缀开头的 Bearer Token，
+// 否则按 JWT 访问 Token 校验。两种方式返回相同结构的 claims。
+func (p *AuthPlugin) authenticate(c *gin.Context) (*MyCustomClaims, *errs.APIError) {
+	if p.apiKeys != nil {
+		if key := c.GetHeader(p.authCfg.APIKeys.Header); key != "" {
+			return p.verifyAPIKey(c, key)
 		}
+	}
 
-		// 检查是否是 Bearer Token
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			p.logger.Debug("Auth middleware: Authorization header format is invalid", zap.String("header", authHeader))
-			errs.Unauthorized.WrapWithMessage(nil, "Token格式错误").JSON(c)
-			c.Abort()
-			return
-		}
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		loggerFrom(c, p.logger).Debug("Auth middleware: Authorization header is missing")
+		return nil, ErrTokenMissing
+	}
 
-		tokenString := parts[1]
+	// 检查是否是 Bearer Token
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		loggerFrom(c, p.logger).Debug("Auth middleware: Authorization header format is invalid", zap.String("header", authHeader))
+		return nil, ErrTokenMalformed
+	}
+	if p.isAPIKey(parts[1]) {
+		return p.verifyAPIKey(c, parts[1])
+	}
 
-		// 解析和验证 Token
-		claims := &MyCustomClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// 确保签名方法是预期的 HMAC 方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			// 返回初始化时存储的密钥
-			return p.secret, nil
-		}, jwt.WithIssuer(p.authCfg.Issuer), // 添加 Issuer 验证
-			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name})) // 明确指定签名算法
+	// 解析和验证 Token，只接受未吊销的访问 Token
+	return p.verifyToken(c, parts[1], TokenTypeAccess)
+}
 
-		if err != nil {
-			p.logger.Warn("Auth middleware: Token parsing error", zap.Error(err))
-			// 根据具体错误类型返回不同消息
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				errs.Unauthorized.WrapWithMessage(err, "Token已过期").JSON(c)
-			} else if errors.Is(err, jwt.ErrSignatureInvalid) {
-				errs.Unauthorized.WrapWithMessage(err, "Token签名无效").JSON(c)
-			} else if errors.Is(err, jwt.ErrTokenNotValidYet) {
-				errs.Unauthorized.WrapWithMessage(err, "Token尚未生效").JSON(c)
-			} else if errors.Is(err, jwt.ErrTokenMalformed) {
-				errs.Unauthorized.WrapWithMessage(err, "Token格式错误").JSON(c)
-			} else if errors.Is(err, jwt.ErrTokenUsedBeforeIssued) {
-				errs.Unauthorized.WrapWithMessage(err, "Token在签发前被使用").JSON(c)
+// authMiddleware 创建并返回认证中间件 (JWT 或 API Key)。
+func (p *AuthPlugin) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 外层的认证中间件已经验证过 (例如全局作用范围同时覆盖了 /auth/apikeys 分组)，不再重复验证和执行回调
+		if _, ok := ClaimsFrom(c); ok {
+			c.Next()
+			return
+		}
+
+		claims, apiErr := p.authenticate(c)
+		if apiErr != nil {
+			if apiErr.HTTPStatus == http.StatusForbidden {
+				p.metrics.authFailed(AuthFailureForbidden)
 			} else {
-				// 其他解析错误，例如 Issuer 不匹配等
-				errs.Unauthorized.WrapWithMessage(err, "Token无效").JSON(c)
+				p.metrics.authFailed(AuthFailureUnauthenticated)
+			}
+			// 认证之前的中间件登记的回调 (例如限流按 IP 计数) 已响应时不再响应认证错误
+			if !runAuthFailed(c) {
+				return
 			}
+			apiErr.JSON(c)
 			c.Abort()
 			return
 		}
 
-		// 双重检查 token.Valid，虽然 ParseWithClaims 内部会检查
-		if !token.Valid {
-			p.logger.Warn("Auth middleware: Token is invalid (post-parsing check)")
-			errs.Unauthorized.WrapWithMessage(nil, "Token无效").JSON(c)
-			c.Abort()
+		// 验证通过，将 claims 和权限存储到 Gin 的上下文中，通过 ClaimsFrom / PermissionsFrom 读取
+		perms := p.authorizer.Grant(claims.Roles, claims.Scopes)
+		c.Set(ClaimsKey, claims)
+		c.Set(permissionsKey, perms)
+		loggerFrom(c, p.logger).Debug("Auth middleware: Token validated successfully", zap.Int64("userID", claims.UserID), zap.String("username", claims.Username))
+		// 执行认证之前的中间件登记的身份相关检查 (例如按用户限流)，被拒绝时已响应
+		if !runAuthenticated(c) {
 			return
 		}
 
-		// 验证通过，将解析出的 claims 存储到 Gin 的上下文中
-		c.Set("claims", claims) // 存储整个 claims 对象
-		p.logger.Debug("Auth middleware: Token validated successfully", zap.Int64("userID", claims.UserID), zap.String("username", claims.Username))
+		// 按授权策略检查当前路由和方法需要的权限
+		if missing := perms.Missing(p.authorizer.Required(c.Request.Method, c.Request.URL.Path)); len(missing) > 0 {
+			loggerFrom(c, p.logger).Info("Auth middleware: permission denied",
+				zap.Int64("userID", claims.UserID), zap.Strings("missing", missing), zap.String("path", c.Request.URL.Path))
+			p.metrics.authFailed(AuthFailureForbidden)
+			forbidden(missing).JSON(c)
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
-// GenerateToken 生成一个新的 JWT Token。
+// verifyToken 解析并验证 Token: 签名、签发者、有效期、Token 类型以及 jti 是否已被吊销。
+// 失败时返回可直接响应给客户端的 APIError。
+func (p *AuthPlugin) verifyToken(c *gin.Context, tokenString, tokenType string) (*MyCustomClaims, *errs.APIError) {
+	ctx := c.Request.Context()
+	claims := &MyCustomClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, p.keyFunc(ctx),
+		jwt.WithIssuer(p.authCfg.Issuer),            // 添加 Issuer 验证
+		jwt.WithValidMethods([]string{p.algorithm})) // 只接受配置的签名算法，防止算法混淆
+
+	if err != nil {
+		loggerFrom(c, p.logger).Warn("Auth middleware: Token parsing error", zap.Error(err))
+		// 根据具体错误类型返回不同消息
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrTokenExpired.Wrap(err)
+		case errors.Is(err, jwt.ErrSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+			return nil, ErrTokenSignatureInvalid.Wrap(err)
+		case errors.Is(err, jwt.ErrTokenNotValidYet):
+			return nil, ErrTokenNotYetValid.Wrap(err)
+		case errors.Is(err, jwt.ErrTokenMalformed):
+			return nil, ErrTokenMalformed.Wrap(err)
+		case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+			return nil, ErrTokenNotYetValid.Wrap(err)
+		default:
+			// 其他解析错误，例如 Issuer 不匹配等
+			return nil, ErrTokenInvalid.Wrap(err)
+		}
+	}
+
+	// 双重检查 token.Valid，虽然 ParseWithClaims 内部会检查
+	if !token.Valid {
+		loggerFrom(c, p.logger).Warn("Auth middleware: Token is invalid (post-parsing check)")
+		return nil, ErrTokenInvalid
+	}
+
+	// 刷新 Token 不能用于访问接口，反之亦然
+	if claims.TokenType != tokenType {
+		loggerFrom(c, p.logger).Debug("Auth: unexpected token type", zap.String("want", tokenType), zap.String("got", claims.TokenType))
+		return nil, ErrTokenTypeMismatch
+	}
+
+	revoked, err := p.revoked.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		loggerFrom(c, p.logger).Error("Auth: failed to check token revocation", zap.Error(err))
+		return nil, errs.ServiceUnavailable.Wrap(err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// GenerateToken 生成一个新的 JWT 访问 Token。
 // userID 和 username 是示例，你可以根据需要传递更多信息或一个包含所有信息的结构体。
 func (p *AuthPlugin) GenerateToken(userID int64, username string) (string, error) {
+	return p.signToken(&User{ID: userID, Username: username}, TokenTypeAccess, time.Duration(p.authCfg.Expire)*time.Second)
+}
+
+// GenerateTokenPair 为用户签发访问 Token 和刷新 Token。
+func (p *AuthPlugin) GenerateTokenPair(user *User) (*dto.TokenResponse, error) {
+	access, err := p.signToken(user, TokenTypeAccess, time.Duration(p.authCfg.Expire)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := p.signToken(user, TokenTypeRefresh, time.Duration(p.authCfg.RefreshExpire)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TokenResponse{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        p.authCfg.Expire,
+		RefreshExpiresIn: p.authCfg.RefreshExpire,
+	}, nil
+}
+
+// signToken 签发指定类型的 Token，每个 Token 带有随机的 jti 以便单独吊销。
+func (p *AuthPlugin) signToken(user *User, tokenType string, ttl time.Duration) (string, error) {
 	if p.authCfg == nil || !p.authCfg.Enable {
 		return "", errors.New("auth plugin is disabled or not configured")
 	}
-	if len(p.secret) == 0 {
-		// 理论上 Init 阶段已检查，这里是双重保险
-		return "", errors.New("jwt secret is not configured")
+	if p.signKey == nil {
+		// verify 模式只有公钥，不能签发 Token
+		return "", errors.New("auth plugin has no signing key (verify mode)")
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
 	}
 
-	// 计算过期时间 (使用秒)
-	expireDuration := time.Duration(p.authCfg.Expire) * time.Second
-	expireTime := time.Now().Add(expireDuration)
-
+	now := time.Now()
 	// 创建 Claims
 	claims := MyCustomClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:    user.ID,
+		Username:  user.Username,
+		TokenType: tokenType,
+		Roles:     user.Roles,
+		Scopes:    user.Scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expireTime),           // 过期时间
-			IssuedAt:  jwt.NewNumericDate(time.Now()),           // 签发时间
-			NotBefore: jwt.NewNumericDate(time.Now()),           // 生效时间
-			Issuer:    p.authCfg.Issuer,                         // 签发者
-			Subject:   fmt.Sprintf("%d", userID),                // 主题，通常是用户ID的字符串形式
-			// ID:        "unique_id", // JWT ID, 可选
-			// Audience:  []string{"some_audience"}, // 受众, 可选
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)), // 过期时间
+			IssuedAt:  jwt.NewNumericDate(now),          // 签发时间
+			NotBefore: jwt.NewNumericDate(now),          // 生效时间
+			Issuer:    p.authCfg.Issuer,                 // 签发者
+			Subject:   fmt.Sprintf("%d", user.ID),       // 主题，通常是用户ID的字符串形式
+			ID:        jti,                              // JWT ID, 用于吊销
 		},
 	}
 
-	// 创建 Token 对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// 签名 Token
-	signedToken, err := token.SignedString(p.secret)
+	// 创建并签名 Token，非对称算法在头部写入 kid 以便验证方选择公钥
+	token := jwt.NewWithClaims(p.method, claims)
+	if p.signKid != "" {
+		token.Header["kid"] = p.signKid
+	}
+	signedToken, err := token.SignedString(p.signKey)
 	if err != nil {
 		p.logger.Error("Failed to sign token", zap.Error(err))
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
-
 	return signedToken, nil
-}
\ No newline at end of file
+}
+
+// newTokenID 生成 128 位随机 jti。
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// revoke 吊销 Token 的 jti 直到其过期，返回 jti 此前是否未被吊销。
+func (p *AuthPlugin) revoke(ctx context.Context, claims *MyCustomClaims) (bool, error) {
+	var ttl time.Duration
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	return p.revoked.Revoke(ctx, claims.ID, ttl)
+}
+
+// login 处理 POST {routePrefix}/login: 校验用户名和密码，签发访问 Token 和刷新 Token。
+func (p *AuthPlugin) login(c *gin.Context) {
+	var req dto.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.Error(c, err).JSON(c)
+		return
+	}
+
+	user, err := p.users.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			loggerFrom(c, p.logger).Info("Auth: login failed", zap.String("username", req.Username), zap.String("ip", c.ClientIP()))
+			p.metrics.authFailed(AuthFailureLogin)
+			ErrLoginFailed.JSON(c)
+			return
+		}
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	p.respondTokens(c, user)
+}
+
+// refresh 处理 POST {routePrefix}/refresh: 使用刷新 Token 换取新的 Token 对。
+// 刷新 Token 会轮换: 旧的刷新 Token 在本次请求中被吊销，重复使用会被拒绝。
+func (p *AuthPlugin) refresh(c *gin.Context) {
+	var req dto.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.Error(c, err).JSON(c)
+		return
+	}
+
+	ctx := c.Request.Context()
+	claims, apiErr := p.verifyToken(c, req.RefreshToken, TokenTypeRefresh)
+	if apiErr != nil {
+		apiErr.JSON(c)
+		return
+	}
+	// 并发请求使用同一个刷新 Token 时只有一个能成功吊销
+	first, err := p.revoke(ctx, claims)
+	if err != nil {
+		loggerFrom(c, p.logger).Error("Auth: failed to revoke refresh token", zap.Error(err))
+		errs.ServiceUnavailable.Wrap(err).JSON(c)
+		return
+	}
+	if !first {
+		loggerFrom(c, p.logger).Warn("Auth: refresh token reused", zap.Int64("userID", claims.UserID), zap.String("ip", c.ClientIP()))
+		ErrTokenRevoked.JSON(c)
+		return
+	}
+
+	user, err := p.users.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			ErrTokenUserNotFound.Wrap(err).JSON(c)
+			return
+		}
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	p.respondTokens(c, user)
+}
+
+// logout 处理 POST {routePrefix}/logout: 吊销 Authorization 头中的访问 Token，
+// 以及请求体中可选的刷新 Token (必须属于同一用户)。
+func (p *AuthPlugin) logout(c *gin.Context) {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		ErrTokenMissing.JSON(c)
+		return
+	}
+	var req dto.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			validation.Error(c, err).JSON(c)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	access, apiErr := p.verifyToken(c, parts[1], TokenTypeAccess)
+	if apiErr != nil {
+		apiErr.JSON(c)
+		return
+	}
+	revokeList := []*MyCustomClaims{access}
+	if req.RefreshToken != "" {
+		refresh, apiErr := p.verifyToken(c, req.RefreshToken, TokenTypeRefresh)
+		if apiErr != nil {
+			apiErr.JSON(c)
+			return
+		}
+		if refresh.Subject != access.Subject {
+			ErrRefreshTokenMismatch.JSON(c)
+			return
+		}
+		revokeList = append(revokeList, refresh)
+	}
+
+	for _, claims := range revokeList {
+		if _, err := p.revoke(ctx, claims); err != nil {
+			loggerFrom(c, p.logger).Error("Auth: failed to revoke token", zap.Error(err))
+			errs.ServiceUnavailable.Wrap(err).JSON(c)
+			return
+		}
+	}
+	loggerFrom(c, p.logger).Debug("Auth: user logged out", zap.Int64("userID", access.UserID))
+	c.Status(http.StatusNoContent)
+}
+
+// respondTokens 签发 Token 对并写入响应。
+func (p *AuthPlugin) respondTokens(c *gin.Context, user *User) {
+	tokens, err := p.GenerateTokenPair(user)
+	if err != nil {
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
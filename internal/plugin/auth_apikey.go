@@ -0,0 +1,229 @@
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// TokenTypeAPIKey 是通过 API Key 认证时 claims.TokenType 的值。
+const TokenTypeAPIKey = "api_key"
+
+// apiKeyTouchInterval 是更新 API Key 最近使用时间的最小间隔，避免每个请求都写存储。
+const apiKeyTouchInterval = time.Minute
+
+// HashAPIKey 返回完整 API Key 的 SHA-256 (hex)。API Key 是高熵随机串，无需使用 bcrypt 等慢哈希。
+// 可用于生成配置文件中的 hash，等价于 `printf %s "$KEY" | sha256sum`。
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey 生成形如 <prefix>_<id>_<secret> 的 API Key，id 为 16 位 hex，secret 为 64 位 hex。
+func generateAPIKey(prefix string) (id, key string, err error) {
+	b := make([]byte, 8+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	id = hex.EncodeToString(b[:8])
+	return id, prefix + "_" + id + "_" + hex.EncodeToString(b[8:]), nil
+}
+
+// apiKeyID 从 API Key 中取出 id，格式不符时返回 false。
+func (p *AuthPlugin) apiKeyID(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, p.authCfg.APIKeys.Prefix+"_")
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	return id, ok && id != "" && secret != ""
+}
+
+// isAPIKey 判断 Bearer Token 是否是 API Key (以配置的前缀开头)。
+func (p *AuthPlugin) isAPIKey(token string) bool {
+	return p.apiKeys != nil && strings.HasPrefix(token, p.authCfg.APIKeys.Prefix+"_")
+}
+
+// newAPIKeyStore 返回 Deps 中注入的 APIKeyStore，或根据 modules.auth.apiKeys.store 创建内置存储。
+func (p *AuthPlugin) newAPIKeyStore(deps Deps) (APIKeyStore, error) {
+	if deps.Has(DepAPIKeyStore) {
+		return Dep[APIKeyStore](deps, DepAPIKeyStore)
+	}
+	if p.authCfg.APIKeys.Store == "gorm" {
+		db, ok := deps.DB()
+		if !ok {
+			return nil, errors.New("apiKeys store gorm requires the database to be enabled")
+		}
+		store := NewGormAPIKeyStore(db)
+		if err := store.Migrate(context.Background()); err != nil {
+			return nil, fmt.Errorf("migrate api_keys table: %w", err)
+		}
+		return store, nil
+	}
+	return NewMemoryAPIKeyStore(p.authCfg.APIKeys.Keys)
+}
+
+// verifyAPIKey 校验 API Key 的哈希、吊销状态、有效期和来源地址，并返回与 JWT 认证相同结构的 claims:
+// Username 为 "apikey:<name>"，Subject 为 "apikey:<id>"，Scopes 为 Key 的 scopes。
+func (p *AuthPlugin) verifyAPIKey(c *gin.Context, raw string) (*MyCustomClaims, *errs.APIError) {
+	ctx := c.Request.Context()
+	id, ok := p.apiKeyID(raw)
+	if !ok {
+		return nil, ErrAPIKeyMalformed
+	}
+	key, err := p.apiKeys.Get(ctx, id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		loggerFrom(c, p.logger).Error("Auth: failed to load api key", zap.String("id", id), zap.Error(err))
+		return nil, errs.ServiceUnavailable.Wrap(err)
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(raw)), []byte(key.Hash)) != 1 {
+		loggerFrom(c, p.logger).Warn("Auth: api key hash mismatch", zap.String("id", id), zap.String("ip", c.ClientIP()))
+		return nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+	if len(key.AllowedCIDRs) > 0 && !ipAllowed(c.ClientIP(), key.AllowedCIDRs) {
+		loggerFrom(c, p.logger).Warn("Auth: api key used from disallowed address", zap.String("id", id), zap.String("ip", c.ClientIP()))
+		return nil, ErrAPIKeyIPDenied
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := p.apiKeys.Touch(ctx, id, now); err != nil {
+			loggerFrom(c, p.logger).Warn("Auth: failed to update api key last used time", zap.String("id", id), zap.Error(err))
+		}
+	}
+
+	claims := &MyCustomClaims{
+		Username:  "apikey:" + key.Name,
+		TokenType: TokenTypeAPIKey,
+		Scopes:    key.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       key.ID,
+			Subject:  "apikey:" + key.ID,
+			IssuedAt: jwt.NewNumericDate(key.CreatedAt),
+		},
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.ExpiresAt)
+	}
+	return claims, nil
+}
+
+// ipAllowed 判断 IP 是否位于任一 CIDR 中。无法解析的 CIDR 被忽略 (配置和签发时已校验)。
+func ipAllowed(ip string, cidrs []string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// issueAPIKey 处理 POST {routePrefix}/apikeys: 签发新的 API Key，明文只在响应中返回一次。
+func (p *AuthPlugin) issueAPIKey(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validation.Error(c, err).JSON(c)
+		return
+	}
+	id, raw, err := generateAPIKey(p.authCfg.APIKeys.Prefix)
+	if err != nil {
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	key := &APIKey{
+		ID: id, Name: req.Name, Hash: HashAPIKey(raw),
+		Scopes: req.Scopes, AllowedCIDRs: req.AllowedCIDRs,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if req.ExpiresIn > 0 {
+		exp := key.CreatedAt.Add(time.Duration(req.ExpiresIn) * time.Second)
+		key.ExpiresAt = &exp
+	}
+	if err := p.apiKeys.Create(c.Request.Context(), key); err != nil {
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+
+	issuer, _ := ClaimsFrom(c)
+	loggerFrom(c, p.logger).Info("Auth: api key issued", zap.String("id", id), zap.String("name", req.Name),
+		zap.Strings("scopes", req.Scopes), zap.String("by", issuer.Subject))
+	resp := apiKeyResponse(key)
+	resp.Key = raw
+	c.JSON(http.StatusCreated, resp)
+}
+
+// listAPIKeys 处理 GET {routePrefix}/apikeys: 列出全部 API Key (不含明文和哈希)。
+func (p *AuthPlugin) listAPIKeys(c *gin.Context) {
+	keys, err := p.apiKeys.List(c.Request.Context())
+	if err != nil {
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	out := make([]dto.APIKeyResponse, len(keys))
+	for i, k := range keys {
+		out[i] = apiKeyResponse(k)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// revokeAPIKey 处理 DELETE {routePrefix}/apikeys/:id: 吊销 API Key，立即生效。
+func (p *AuthPlugin) revokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	if err := p.apiKeys.Revoke(c.Request.Context(), id, time.Now()); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			ErrUnknownAPIKey.Wrap(err).JSON(c)
+			return
+		}
+		errs.InternalServerError.Wrap(err).JSON(c)
+		return
+	}
+	revoker, _ := ClaimsFrom(c)
+	loggerFrom(c, p.logger).Info("Auth: api key revoked", zap.String("id", id), zap.String("by", revoker.Subject))
+	c.Status(http.StatusNoContent)
+}
+
+// apiKeyResponse 将 APIKey 转换为响应结构。
+func apiKeyResponse(k *APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID: k.ID, Name: k.Name,
+		Scopes: nonNil(k.Scopes), AllowedCIDRs: nonNil(k.AllowedCIDRs),
+		ExpiresAt: k.ExpiresAt, CreatedAt: k.CreatedAt, LastUsedAt: k.LastUsedAt, RevokedAt: k.RevokedAt,
+	}
+}
+
+// nonNil 使空列表序列化为 [] 而不是 null。
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
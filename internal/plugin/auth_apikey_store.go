@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"myGin/internal/conf"
+
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound 表示 API Key 不存在。
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey 是一个已签发的 API Key。只保存完整 Key 的 SHA-256 哈希，明文只在签发时返回一次。
+type APIKey struct {
+	ID           string // Key 中 <id> 部分，用于查找
+	Name         string // 调用方名称，写入 claims.Username ("apikey:<name>")
+	Hash         string // 完整 Key 的 SHA-256 (hex)
+	Scopes       []string
+	AllowedCIDRs []string
+	ExpiresAt    *time.Time // nil 表示不过期
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+	RevokedAt    *time.Time
+}
+
+// APIKeyStore 保存 API Key，auth 插件通过它校验、签发和吊销 Key。
+// 内置 MemoryAPIKeyStore 和 GormAPIKeyStore，也可以通过 Deps 的 DepAPIKeyStore 注入自定义实现。
+type APIKeyStore interface {
+	// Get 按 ID 查询 Key (包括已吊销的)，不存在时返回 ErrAPIKeyNotFound。
+	Get(ctx context.Context, id string) (*APIKey, error)
+	// List 返回全部 Key，按创建时间排序。
+	List(ctx context.Context) ([]*APIKey, error)
+	Create(ctx context.Context, key *APIKey) error
+	// Revoke 将 Key 标记为已吊销，不存在时返回 ErrAPIKeyNotFound。
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// Touch 更新最近使用时间。
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// MemoryAPIKeyStore 是基于内存的 API Key 存储，初始 Key 来自配置 modules.auth.apiKeys.keys。
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// NewMemoryAPIKeyStore 根据配置创建内存 API Key 存储。
+func NewMemoryAPIKeyStore(entries []conf.APIKeyEntryConfig) (*MemoryAPIKeyStore, error) {
+	s := &MemoryAPIKeyStore{keys: make(map[string]*APIKey, len(entries))}
+	for _, e := range entries {
+		key := &APIKey{
+			ID: e.ID, Name: e.Name, Hash: strings.ToLower(e.Hash),
+			Scopes: e.Scopes, AllowedCIDRs: e.AllowedCIDRs,
+		}
+		if e.ExpiresAt != "" {
+			t, err := time.Parse(time.RFC3339, e.ExpiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("api key %q: invalid expiresAt: %w", e.ID, err)
+			}
+			key.ExpiresAt = &t
+		}
+		if _, dup := s.keys[e.ID]; dup {
+			return nil, fmt.Errorf("duplicate api key id %q", e.ID)
+		}
+		s.keys[e.ID] = key
+	}
+	return s, nil
+}
+
+// Get 实现 APIKeyStore。
+func (s *MemoryAPIKeyStore) Get(_ context.Context, id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+// List 实现 APIKeyStore。
+func (s *MemoryAPIKeyStore) List(_ context.Context) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		k := *key
+		out = append(out, &k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Create 实现 APIKeyStore。
+func (s *MemoryAPIKeyStore) Create(_ context.Context, key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.keys[key.ID]; dup {
+		return fmt.Errorf("duplicate api key id %q", key.ID)
+	}
+	k := *key
+	s.keys[key.ID] = &k
+	return nil
+}
+
+// Revoke 实现 APIKeyStore。
+func (s *MemoryAPIKeyStore) Revoke(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+	return nil
+}
+
+// Touch 实现 APIKeyStore。
+func (s *MemoryAPIKeyStore) Touch(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+// APIKeyRecord 是 GormAPIKeyStore 使用的 api_keys 表结构。
+type APIKeyRecord struct {
+	ID           string `gorm:"primaryKey;size:32"`
+	Name         string `gorm:"size:128"`
+	Hash         string `gorm:"size:64;not null"`
+	Scopes       string `gorm:"size:1024"` // 逗号分隔
+	AllowedCIDRs string `gorm:"size:1024"` // 逗号分隔
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+	RevokedAt    *time.Time
+}
+
+// TableName 指定表名为 api_keys。
+func (APIKeyRecord) TableName() string {
+	return "api_keys"
+}
+
+// apiKey 将数据库记录转换为 APIKey。
+func (r *APIKeyRecord) apiKey() *APIKey {
+	return &APIKey{
+		ID: r.ID, Name: r.Name, Hash: r.Hash,
+		Scopes: splitList(r.Scopes), AllowedCIDRs: splitList(r.AllowedCIDRs),
+		ExpiresAt: r.ExpiresAt, CreatedAt: r.CreatedAt, LastUsedAt: r.LastUsedAt, RevokedAt: r.RevokedAt,
+	}
+}
+
+// GormAPIKeyStore 是基于数据库 api_keys 表的 API Key 存储。
+type GormAPIKeyStore struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyStore 创建数据库 API Key 存储。
+func NewGormAPIKeyStore(db *gorm.DB) *GormAPIKeyStore {
+	return &GormAPIKeyStore{db: db}
+}
+
+// Migrate 创建或更新 api_keys 表。
+func (s *GormAPIKeyStore) Migrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).AutoMigrate(&APIKeyRecord{})
+}
+
+// Get 实现 APIKeyStore。
+func (s *GormAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	var record APIKeyRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record.apiKey(), nil
+}
+
+// List 实现 APIKeyStore。
+func (s *GormAPIKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	var records []APIKeyRecord
+	if err := s.db.WithContext(ctx).Order("created_at, id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*APIKey, len(records))
+	for i := range records {
+		out[i] = records[i].apiKey()
+	}
+	return out, nil
+}
+
+// Create 实现 APIKeyStore。
+func (s *GormAPIKeyStore) Create(ctx context.Context, key *APIKey) error {
+	record := &APIKeyRecord{
+		ID: key.ID, Name: key.Name, Hash: key.Hash,
+		Scopes: strings.Join(key.Scopes, ","), AllowedCIDRs: strings.Join(key.AllowedCIDRs, ","),
+		ExpiresAt: key.ExpiresAt, CreatedAt: key.CreatedAt,
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("create api key %q: %w", key.ID, err)
+	}
+	return nil
+}
+
+// Revoke 实现 APIKeyStore。
+func (s *GormAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	res := s.db.WithContext(ctx).Model(&APIKeyRecord{}).Where("id = ?", id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", at))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Touch 实现 APIKeyStore。
+func (s *GormAPIKeyStore) Touch(ctx context.Context, id string, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&APIKeyRecord{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"myGin/internal/pkg/authz"
+	"myGin/internal/pkg/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context 中保存认证结果的键。请使用 ClaimsFrom / PermissionsFrom 读取。
+const (
+	ClaimsKey      = "claims"
+	permissionsKey = "auth.permissions"
+)
+
+// ClaimsFrom 返回认证中间件写入上下文的 claims，请求未经过认证中间件时返回 false。
+func ClaimsFrom(c *gin.Context) (*MyCustomClaims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*MyCustomClaims)
+	return claims, ok && claims != nil
+}
+
+// PermissionsFrom 返回当前用户的权限 (scopes 以及策略文件中角色映射的权限)。
+func PermissionsFrom(c *gin.Context) authz.Permissions {
+	if v, ok := c.Get(permissionsKey); ok {
+		if perms, ok := v.(authz.Permissions); ok {
+			return perms
+		}
+	}
+	return authz.Permissions{}
+}
+
+// authenticatedHooksKey 和 authFailedHooksKey 是 gin.Context 中保存认证回调的键，见 onAuthenticated 和 onAuthFailed。
+const (
+	authenticatedHooksKey = "auth.hooks"
+	authFailedHooksKey    = "auth.failedHooks"
+)
+
+// onAuthenticated 登记在认证中间件写入 claims 和权限之后执行的回调，回调已响应并中断请求时返回 false。
+// 供在认证之前执行的中间件补充依赖调用方身份的检查，例如限流插件按用户、API Key 或权限的规则。
+func onAuthenticated(c *gin.Context, hook func(*gin.Context) bool) {
+	addAuthHook(c, authenticatedHooksKey, hook)
+}
+
+// onAuthFailed 登记在认证失败、响应 401/403 之前执行的回调，回调已响应并中断请求时返回 false (认证中间件不再响应)。
+func onAuthFailed(c *gin.Context, hook func(*gin.Context) bool) {
+	addAuthHook(c, authFailedHooksKey, hook)
+}
+
+// runAuthenticated 依次执行 onAuthenticated 登记的回调，某个回调中断请求时返回 false。
+func runAuthenticated(c *gin.Context) bool {
+	return runAuthHooks(c, authenticatedHooksKey)
+}
+
+// runAuthFailed 依次执行 onAuthFailed 登记的回调，某个回调中断请求时返回 false。
+func runAuthFailed(c *gin.Context) bool {
+	return runAuthHooks(c, authFailedHooksKey)
+}
+
+func addAuthHook(c *gin.Context, key string, hook func(*gin.Context) bool) {
+	hooks, _ := c.Get(key)
+	list, _ := hooks.([]func(*gin.Context) bool)
+	c.Set(key, append(list, hook))
+}
+
+// runAuthHooks 执行并清空 key 下登记的回调，每个回调最多执行一次。
+func runAuthHooks(c *gin.Context, key string) bool {
+	hooks, _ := c.Get(key)
+	list, _ := hooks.([]func(*gin.Context) bool)
+	c.Set(key, nil)
+	for _, hook := range list {
+		if !hook(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// RequirePermission 返回要求当前用户拥有全部指定权限的中间件，需挂载在认证中间件之后:
+//
+//	v1.POST("/flights/tickets/order", plugin.RequirePermission("flights:order"), h.CreateOrder)
+//
+// 未认证时返回 ErrTokenMissing (401)，缺少权限时返回 ErrPermissionDenied (403) 并在 details.missing 中列出缺少的权限。
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := ClaimsFrom(c); !ok {
+			ErrTokenMissing.JSON(c)
+			c.Abort()
+			return
+		}
+		if missing := PermissionsFrom(c).Missing(perms); len(missing) > 0 {
+			forbidden(missing).JSON(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// forbidden 返回列出缺少权限的 403 错误。
+func forbidden(missing []string) *errs.APIError {
+	return ErrPermissionDenied.WithDetails(gin.H{"missing": missing})
+}
@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"net/http"
+
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/i18n"
+)
+
+// 认证、授权和限流插件的业务错误码。
+// 错误码的前三位与 HTTP 状态码一致 (401xx 为认证失败，403xx 为授权失败，429xx 为配额限制)，客户端可以按 code 区分 (例如 Token 过期时刷新，吊销时重新登录)。
+var (
+	// ErrTokenMissing 表示请求未携带 Token 或 API Key。
+	ErrTokenMissing = errs.Define(errs.Definition{Code: 40101, Name: "token-missing", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "请求未携带Token", i18n.English: "Missing token"}})
+	// ErrTokenMalformed 表示 Authorization 头或 Token 本身的格式错误。
+	ErrTokenMalformed = errs.Define(errs.Definition{Code: 40102, Name: "token-malformed", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token格式错误", i18n.English: "Malformed token"}})
+	// ErrTokenExpired 表示 Token 已过期，客户端应使用刷新 Token 换取新的 Token。
+	ErrTokenExpired = errs.Define(errs.Definition{Code: 40103, Name: "token-expired", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token已过期", i18n.English: "Token expired"}})
+	// ErrTokenSignatureInvalid 表示 Token 签名无法验证。
+	ErrTokenSignatureInvalid = errs.Define(errs.Definition{Code: 40104, Name: "token-signature-invalid", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token签名无效", i18n.English: "Invalid token signature"}})
+	// ErrTokenNotYetValid 表示 Token 尚未生效 (nbf / iat 晚于当前时间)。
+	ErrTokenNotYetValid = errs.Define(errs.Definition{Code: 40105, Name: "token-not-yet-valid", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token尚未生效", i18n.English: "Token not yet valid"}})
+	// ErrTokenTypeMismatch 表示用刷新 Token 访问接口或用访问 Token 刷新。
+	ErrTokenTypeMismatch = errs.Define(errs.Definition{Code: 40106, Name: "token-type-mismatch", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token类型错误", i18n.English: "Wrong token type"}})
+	// ErrTokenRevoked 表示 Token 已被吊销 (登出或刷新 Token 被重复使用)，客户端应重新登录。
+	ErrTokenRevoked = errs.Define(errs.Definition{Code: 40107, Name: "token-revoked", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token已失效", i18n.English: "Token revoked"}})
+	// ErrTokenInvalid 表示其他 Token 验证失败，例如签发者不匹配。
+	ErrTokenInvalid = errs.Define(errs.Definition{Code: 40108, Name: "token-invalid", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "Token无效", i18n.English: "Invalid token"}})
+	// ErrLoginFailed 表示登录时用户名或密码错误。
+	ErrLoginFailed = errs.Define(errs.Definition{Code: 40109, Name: "invalid-credentials", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "用户名或密码错误", i18n.English: "Invalid username or password"}})
+	// ErrTokenUserNotFound 表示刷新 Token 对应的用户已不存在。
+	ErrTokenUserNotFound = errs.Define(errs.Definition{Code: 40110, Name: "user-not-found", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "用户不存在", i18n.English: "User no longer exists"}})
+	// ErrAPIKeyMalformed 表示 API Key 的格式错误。
+	ErrAPIKeyMalformed = errs.Define(errs.Definition{Code: 40111, Name: "apikey-malformed", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "API Key格式错误", i18n.English: "Malformed API key"}})
+	// ErrAPIKeyInvalid 表示 API Key 不存在或与存储的哈希不匹配。
+	ErrAPIKeyInvalid = errs.Define(errs.Definition{Code: 40112, Name: "apikey-invalid", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "API Key无效", i18n.English: "Invalid API key"}})
+	// ErrAPIKeyRevoked 表示 API Key 已被吊销。
+	ErrAPIKeyRevoked = errs.Define(errs.Definition{Code: 40113, Name: "apikey-revoked", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "API Key已吊销", i18n.English: "API key revoked"}})
+	// ErrAPIKeyExpired 表示 API Key 已过期。
+	ErrAPIKeyExpired = errs.Define(errs.Definition{Code: 40114, Name: "apikey-expired", HTTPStatus: http.StatusUnauthorized,
+		Messages: errs.Messages{i18n.Chinese: "API Key已过期", i18n.English: "API key expired"}})
+
+	// ErrPermissionDenied 表示缺少路由要求的权限，details.missing 列出缺少的权限。
+	ErrPermissionDenied = errs.Define(errs.Definition{Code: 40301, Name: "permission-denied", HTTPStatus: http.StatusForbidden,
+		Messages: errs.Messages{i18n.Chinese: "缺少权限", i18n.English: "Missing permissions"}})
+	// ErrAPIKeyIPDenied 表示请求来源地址不在 API Key 的 allowedCidrs 中。
+	ErrAPIKeyIPDenied = errs.Define(errs.Definition{Code: 40302, Name: "apikey-ip-denied", HTTPStatus: http.StatusForbidden,
+		Messages: errs.Messages{i18n.Chinese: "来源地址不允许使用该API Key", i18n.English: "API key not allowed from this address"}})
+
+	// ErrUnknownAPIKey 表示要吊销的 API Key 不存在。
+	ErrUnknownAPIKey = errs.Define(errs.Definition{Code: 40401, Name: "apikey-not-found", HTTPStatus: http.StatusNotFound,
+		Messages: errs.Messages{i18n.Chinese: "API Key不存在", i18n.English: "API key not found"}})
+
+	// ErrRefreshTokenMismatch 表示登出时提交的刷新 Token 不属于当前用户。
+	ErrRefreshTokenMismatch = errs.Define(errs.Definition{Code: 40002, Name: "refresh-token-mismatch", HTTPStatus: http.StatusBadRequest,
+		Messages: errs.Messages{i18n.Chinese: "刷新Token不属于当前用户", i18n.English: "Refresh token belongs to another user"}})
+
+	// ErrDailyQuotaExceeded 表示调用方已用完限流规则的每日请求配额，details 中的 reset 为配额重置时间。
+	ErrDailyQuotaExceeded = errs.Define(errs.Definition{Code: 42901, Name: "daily-quota-exceeded", HTTPStatus: http.StatusTooManyRequests,
+		Messages: errs.Messages{i18n.Chinese: "已超过每日请求配额", i18n.English: "Daily request quota exceeded"}, Retryable: true})
+)
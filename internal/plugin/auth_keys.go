@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"myGin/internal/pkg/jwks"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// 认证模式，见 conf.AuthConfig.Mode。
+const (
+	AuthModeIssuer = "issuer"
+	AuthModeVerify = "verify"
+)
+
+// initKeys 根据 algorithm 和 mode 加载签名与验证密钥:
+//   - HS256: 使用 secret 签名和验证；
+//   - RS256/ES256/EdDSA: 从 keys 加载 PEM 密钥对，按 signingKeyId 选择签名密钥，所有密钥都可用于验证 (密钥轮换)；
+//   - verify 模式: 不签发 Token，从 jwksUrl 获取公钥验证。
+func (p *AuthPlugin) initKeys() error {
+	p.algorithm = p.authCfg.Algorithm
+	if p.algorithm == "" {
+		p.algorithm = jwt.SigningMethodHS256.Name
+	}
+	p.method = jwt.GetSigningMethod(p.algorithm)
+	if p.method == nil {
+		return fmt.Errorf("unsupported algorithm %q", p.algorithm)
+	}
+
+	if p.verifyOnly() {
+		if p.algorithm == jwt.SigningMethodHS256.Name {
+			return errors.New("verify mode requires an asymmetric algorithm (RS256, ES256 or EdDSA)")
+		}
+		refresh := 5 * time.Minute
+		if p.authCfg.JWKSRefresh != "" {
+			d, err := time.ParseDuration(p.authCfg.JWKSRefresh)
+			if err != nil {
+				return fmt.Errorf("invalid jwksRefresh: %w", err)
+			}
+			refresh = d
+		}
+		p.remote = jwks.NewRemote(p.authCfg.JWKSURL, refresh, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := p.remote.Refresh(ctx); err != nil {
+			// 远程 JWKS 暂时不可用时不阻止启动，首次验证 Token 时会重试
+			p.logger.Warn("Auth Plugin: initial JWKS fetch failed, will retry on demand", zap.String("url", p.authCfg.JWKSURL), zap.Error(err))
+		}
+		return nil
+	}
+
+	if p.algorithm == jwt.SigningMethodHS256.Name {
+		// Secret 非空已由 bootstrap.LoadConfig 根据 conf.AuthConfig 的 validate 标签校验
+		p.secret = []byte(p.authCfg.Secret)
+		p.signKey = p.secret
+		if len(p.authCfg.Keys) > 0 {
+			p.logger.Warn("Auth Plugin: keys are ignored when algorithm is HS256")
+		}
+		return nil
+	}
+
+	p.verifyKeys = make(map[string]crypto.PublicKey, len(p.authCfg.Keys))
+	p.jwks = &jwks.Set{Keys: []jwks.Key{}}
+	for _, k := range p.authCfg.Keys {
+		if _, dup := p.verifyKeys[k.ID]; dup {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		var pub crypto.PublicKey
+		if k.PrivateKeyFile != "" {
+			signer, err := jwks.LoadPrivateKey(k.PrivateKeyFile)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", k.ID, err)
+			}
+			if err := jwks.CheckKeyType(p.algorithm, signer); err != nil {
+				return fmt.Errorf("key %q: %w", k.ID, err)
+			}
+			pub = signer.Public()
+			if p.signKey == nil && (p.authCfg.SigningKeyID == "" || p.authCfg.SigningKeyID == k.ID) {
+				p.signKey, p.signKid = signer, k.ID
+			}
+		} else {
+			var err error
+			if pub, err = jwks.LoadPublicKey(k.PublicKeyFile); err != nil {
+				return fmt.Errorf("key %q: %w", k.ID, err)
+			}
+		}
+		jwk, err := jwks.NewKey(k.ID, p.algorithm, pub)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		p.verifyKeys[k.ID] = pub
+		p.jwks.Keys = append(p.jwks.Keys, jwk)
+	}
+	if p.signKey == nil {
+		if p.authCfg.SigningKeyID != "" {
+			return fmt.Errorf("signing key %q is not configured or has no privateKeyFile", p.authCfg.SigningKeyID)
+		}
+		return fmt.Errorf("algorithm %s requires at least one key with a privateKeyFile", p.algorithm)
+	}
+	p.logger.Info("Auth Plugin keys loaded",
+		zap.String("algorithm", p.algorithm),
+		zap.String("signing_kid", p.signKid),
+		zap.Int("verification_keys", len(p.verifyKeys)),
+	)
+	return nil
+}
+
+// verifyOnly 判断插件是否处于仅验证模式。
+func (p *AuthPlugin) verifyOnly() bool {
+	return p.authCfg.Mode == AuthModeVerify
+}
+
+// keyFunc 返回验证 Token 签名使用的密钥。非对称算法按 JWT 头中的 kid 选择公钥；
+// 只配置了一个密钥时，不带 kid 的 Token 也使用该密钥验证。
+func (p *AuthPlugin) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if p.secret != nil {
+			return p.secret, nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		if p.remote != nil {
+			pub, alg, err := p.remote.Key(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if alg != "" && alg != p.algorithm {
+				return nil, fmt.Errorf("key %q is for algorithm %s, not %s", kid, alg, p.algorithm)
+			}
+			return pub, nil
+		}
+		if pub, ok := p.verifyKeys[kid]; ok {
+			return pub, nil
+		}
+		if kid == "" && len(p.verifyKeys) == 1 {
+			for _, pub := range p.verifyKeys {
+				return pub, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+}
+
+// serveJWKS 处理 GET {jwksPath}，发布所有验证公钥，其他服务据此验证本服务签发的 Token。
+func (p *AuthPlugin) serveJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, p.jwks)
+}
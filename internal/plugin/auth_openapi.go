@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"net/http"
+
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/jwks"
+	"myGin/internal/pkg/openapi"
+)
+
+// routesSpec 返回认证插件在 Register 中注册的路由的 OpenAPI 声明，路径取决于 routePrefix 和 jwksPath。
+func (p *AuthPlugin) routesSpec() []openapi.Route {
+	prefix := p.authCfg.RoutePrefix
+	errorReply := func(description string) openapi.Reply {
+		return openapi.Reply{Description: description, Body: errs.ErrorResponse{}}
+	}
+	routes := []openapi.Route{
+		{
+			Method: http.MethodPost, Path: prefix + "/login", Tags: []string{"Auth"},
+			Summary: "登录", Description: "校验用户名和密码，签发访问 Token 和刷新 Token",
+			Body: dto.LoginRequest{},
+			Responses: map[int]openapi.Reply{
+				http.StatusOK:           {Description: "Token 对", Body: dto.TokenResponse{}},
+				http.StatusBadRequest:   errorReply("请求参数错误"),
+				http.StatusUnauthorized: errorReply("用户名或密码错误"),
+			},
+		},
+		{
+			Method: http.MethodPost, Path: prefix + "/refresh", Tags: []string{"Auth"},
+			Summary: "刷新 Token", Description: "使用刷新 Token 换取新的 Token 对，旧的刷新 Token 随即失效",
+			Body: dto.RefreshRequest{},
+			Responses: map[int]openapi.Reply{
+				http.StatusOK:           {Description: "新的 Token 对", Body: dto.TokenResponse{}},
+				http.StatusBadRequest:   errorReply("请求参数错误"),
+				http.StatusUnauthorized: errorReply("刷新 Token 无效或已失效"),
+			},
+		},
+		{
+			Method: http.MethodPost, Path: prefix + "/logout", Tags: []string{"Auth"},
+			Summary: "登出", Description: "吊销当前访问 Token 和请求体中的刷新 Token",
+			Body: dto.LogoutRequest{}, Secured: true,
+			Responses: map[int]openapi.Reply{
+				http.StatusNoContent:    {Description: "已登出"},
+				http.StatusUnauthorized: errorReply("未认证"),
+			},
+		},
+	}
+	if p.jwks != nil {
+		routes = append(routes, openapi.Route{
+			Method: http.MethodGet, Path: p.authCfg.JWKSPath, Tags: []string{"Auth"},
+			Summary: "JWKS 公钥集合", Description: "验证本服务签发的 Token 所需的公钥",
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "JWK Set", Body: jwks.Set{}}},
+		})
+	}
+	if p.apiKeys != nil {
+		routes = append(routes,
+			openapi.Route{
+				Method: http.MethodPost, Path: prefix + "/apikeys", Tags: []string{"API Keys"},
+				Summary: "签发 API Key", Description: "Key 明文只在响应中返回一次", Secured: true,
+				Body: dto.CreateAPIKeyRequest{},
+				Responses: map[int]openapi.Reply{
+					http.StatusCreated:    {Description: "新签发的 API Key", Body: dto.APIKeyResponse{}},
+					http.StatusBadRequest: errorReply("请求参数错误"),
+					http.StatusForbidden:  errorReply("缺少管理权限"),
+				},
+			},
+			openapi.Route{
+				Method: http.MethodGet, Path: prefix + "/apikeys", Tags: []string{"API Keys"},
+				Summary: "列出 API Key", Secured: true,
+				Responses: map[int]openapi.Reply{
+					http.StatusOK:        {Description: "API Key 列表", Body: []dto.APIKeyResponse{}},
+					http.StatusForbidden: errorReply("缺少管理权限"),
+				},
+			},
+			openapi.Route{
+				Method: http.MethodDelete, Path: prefix + "/apikeys/:id", Tags: []string{"API Keys"},
+				Summary: "吊销 API Key", Secured: true,
+				Responses: map[int]openapi.Reply{
+					http.StatusNoContent: {Description: "已吊销"},
+					http.StatusNotFound:  errorReply("API Key 不存在"),
+					http.StatusForbidden: errorReply("缺少管理权限"),
+				},
+			},
+		)
+	}
+	return routes
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationStore 记录已吊销的 Token ID (jti)。记录只需保留到 Token 本身过期为止。
+type RevocationStore interface {
+	// Revoke 吊销 jti，ttl 为 Token 的剩余有效期。jti 此前未被吊销时返回 true，
+	// 刷新 Token 轮换时借此保证同一个刷新 Token 只能使用一次。
+	Revoke(ctx context.Context, jti string, ttl time.Duration) (bool, error)
+	// IsRevoked 判断 jti 是否已被吊销。
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// revokedKeyPrefix 是 Redis 中吊销记录的 key 前缀。
+const revokedKeyPrefix = "auth:revoked:"
+
+// RedisRevocationStore 将吊销记录保存在 Redis 中，多个实例之间共享，记录随 Token 过期自动删除。
+type RedisRevocationStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisRevocationStore 创建基于 Redis 的吊销存储。
+func NewRedisRevocationStore(rdb *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{rdb: rdb}
+}
+
+// Revoke 实现 RevocationStore。
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, revokedKeyPrefix+jti, 1, minRevocationTTL(ttl)).Result()
+}
+
+// IsRevoked 实现 RevocationStore。
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, revokedKeyPrefix+jti).Result()
+	return n > 0, err
+}
+
+// memorySweepInterval 是内存吊销存储清理过期记录的最小间隔。
+const memorySweepInterval = time.Minute
+
+// MemoryRevocationStore 将吊销记录保存在进程内存中，未配置 Redis 时使用。
+// 记录不会在多个实例之间共享，重启后丢失。
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time // jti -> 记录过期时间
+	lastSweep time.Time
+}
+
+// NewMemoryRevocationStore 创建基于内存的吊销存储。
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Revoke 实现 RevocationStore。
+func (s *MemoryRevocationStore) Revoke(_ context.Context, jti string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	if exp, ok := s.revoked[jti]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.revoked[jti] = now.Add(minRevocationTTL(ttl))
+	return true, nil
+}
+
+// IsRevoked 实现 RevocationStore。
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.revoked[jti]
+	return ok && time.Now().Before(exp), nil
+}
+
+// sweep 删除已过期的记录，调用方需持有锁。
+func (s *MemoryRevocationStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	for jti, exp := range s.revoked {
+		if !now.Before(exp) {
+			delete(s.revoked, jti)
+		}
+	}
+	s.lastSweep = now
+}
+
+// minRevocationTTL 保证吊销记录至少保留一秒 (Token 即将过期时剩余有效期可能接近 0)。
+func minRevocationTTL(ttl time.Duration) time.Duration {
+	if ttl < time.Second {
+		return time.Second
+	}
+	return ttl
+}
@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"myGin/internal/conf"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 用户存储返回的错误。
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password") // 用户不存在或密码错误，两种情况不加区分以免泄露用户是否存在
+	ErrUserNotFound       = errors.New("user not found")
+)
+
+// User 是通过认证的用户，其字段会写入 Token 的 claims。
+type User struct {
+	ID       int64
+	Username string
+	Roles    []string
+	Scopes   []string
+}
+
+// UserStore 校验登录凭据并按 ID 查询用户，auth 插件通过它实现登录和刷新。
+// 内置 MemoryUserStore 和 GormUserStore，也可以通过 Deps 的 DepUserStore 注入自定义实现。
+type UserStore interface {
+	// Authenticate 校验用户名和密码，失败时返回 ErrInvalidCredentials。
+	Authenticate(ctx context.Context, username, password string) (*User, error)
+	// FindByID 按 ID 查询用户，刷新 Token 时用于确认用户仍然存在，不存在时返回 ErrUserNotFound。
+	FindByID(ctx context.Context, id int64) (*User, error)
+}
+
+// HashPassword 使用 bcrypt 生成密码哈希，用于写入配置中的 passwordHash 或数据库。
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword 在用户不存在时执行一次 bcrypt 比较，使响应时间与密码错误时一致。
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// memoryUser 是 MemoryUserStore 中保存的用户及其密码哈希。
+type memoryUser struct {
+	User
+	passwordHash []byte
+}
+
+// MemoryUserStore 是基于内存的用户存储，用户来自配置 modules.auth.users，密码以 bcrypt 哈希保存。
+type MemoryUserStore struct {
+	mu         sync.RWMutex
+	byID       map[int64]*memoryUser
+	byUsername map[string]*memoryUser
+}
+
+// NewMemoryUserStore 根据配置创建内存用户存储，哈希无效或 ID/用户名重复时返回错误。
+func NewMemoryUserStore(users []conf.AuthUserConfig) (*MemoryUserStore, error) {
+	s := &MemoryUserStore{
+		byID:       make(map[int64]*memoryUser, len(users)),
+		byUsername: make(map[string]*memoryUser, len(users)),
+	}
+	for _, u := range users {
+		if err := s.add(User{ID: u.ID, Username: u.Username, Roles: u.Roles, Scopes: u.Scopes}, []byte(u.PasswordHash)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add 添加一个用户，password 为明文密码，保存前使用 bcrypt 哈希。
+func (s *MemoryUserStore) Add(user User, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.add(user, []byte(hash))
+}
+
+func (s *MemoryUserStore) add(user User, hash []byte) error {
+	if _, err := bcrypt.Cost(hash); err != nil {
+		return fmt.Errorf("user %q: invalid bcrypt password hash: %w", user.Username, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.byID[user.ID]; dup {
+		return fmt.Errorf("user %q: duplicate user id %d", user.Username, user.ID)
+	}
+	if _, dup := s.byUsername[user.Username]; dup {
+		return fmt.Errorf("duplicate username %q", user.Username)
+	}
+	u := &memoryUser{User: user, passwordHash: hash}
+	s.byID[user.ID] = u
+	s.byUsername[user.Username] = u
+	return nil
+}
+
+// Authenticate 实现 UserStore。
+func (s *MemoryUserStore) Authenticate(_ context.Context, username, password string) (*User, error) {
+	s.mu.RLock()
+	u, ok := s.byUsername[username]
+	s.mu.RUnlock()
+	if !ok {
+		compareDummyPassword(password)
+		return nil, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword(u.passwordHash, []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	user := u.User
+	return &user, nil
+}
+
+// FindByID 实现 UserStore。
+func (s *MemoryUserStore) FindByID(_ context.Context, id int64) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.byID[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	user := u.User
+	return &user, nil
+}
+
+// UserRecord 是 GormUserStore 使用的 users 表结构。
+type UserRecord struct {
+	ID           int64  `gorm:"primaryKey;autoIncrement"`
+	Username     string `gorm:"size:64;not null;uniqueIndex"`
+	PasswordHash string `gorm:"size:100;not null"` // bcrypt 哈希
+	Roles        string `gorm:"size:255"`          // 逗号分隔的角色
+	Scopes       string `gorm:"size:1024"`         // 逗号分隔的权限
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// TableName 指定表名为 users。
+func (UserRecord) TableName() string {
+	return "users"
+}
+
+// user 将数据库记录转换为 User。
+func (r *UserRecord) user() *User {
+	return &User{ID: r.ID, Username: r.Username, Roles: splitList(r.Roles), Scopes: splitList(r.Scopes)}
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项。
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// GormUserStore 是基于数据库 users 表的用户存储，密码以 bcrypt 哈希保存。
+type GormUserStore struct {
+	db *gorm.DB
+}
+
+// NewGormUserStore 创建数据库用户存储。
+func NewGormUserStore(db *gorm.DB) *GormUserStore {
+	return &GormUserStore{db: db}
+}
+
+// Migrate 创建或更新 users 表 (GORM AutoMigrate 只会添加缺失的表、列和索引)。
+func (s *GormUserStore) Migrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).AutoMigrate(&UserRecord{})
+}
+
+// Create 创建用户，password 为明文密码，保存前使用 bcrypt 哈希。user.ID 被忽略，由数据库生成。
+func (s *GormUserStore) Create(ctx context.Context, user User, password string) (*User, error) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	record := &UserRecord{
+		Username:     user.Username,
+		PasswordHash: hash,
+		Roles:        strings.Join(user.Roles, ","),
+		Scopes:       strings.Join(user.Scopes, ","),
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return nil, fmt.Errorf("create user %q: %w", user.Username, err)
+	}
+	return record.user(), nil
+}
+
+// Authenticate 实现 UserStore。
+func (s *GormUserStore) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	var record UserRecord
+	err := s.db.WithContext(ctx).Where("username = ?", username).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		compareDummyPassword(password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(record.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return record.user(), nil
+}
+
+// FindByID 实现 UserStore。
+func (s *GormUserStore) FindByID(ctx context.Context, id int64) (*User, error) {
+	var record UserRecord
+	err := s.db.WithContext(ctx).First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record.user(), nil
+}
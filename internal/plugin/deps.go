@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"fmt"
+
+	"myGin/internal/conf"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 共享依赖项的标准名称。
+const (
+	DepLogger = "logger" // *zap.Logger
+	DepConfig = "config" // *conf.Config，应用总配置
+	DepDB     = "db"     // *gorm.DB，仅在数据库初始化成功时存在
+	DepRedis  = "redis"  // *redis.Client，仅在 Redis 初始化成功时存在
+
+	DepUserStore    = "userStore"    // plugin.UserStore，可选，存在时 auth 插件使用它代替 modules.auth.userStore 配置的存储
+	DepAPIKeyStore  = "apiKeyStore"  // plugin.APIKeyStore，可选，存在时 auth 插件使用它代替 modules.auth.apiKeys.store 配置的存储
+	DepSpanExporter = "spanExporter" // sdktrace.SpanExporter，可选，存在时 tracing 插件使用它代替 modules.tracing.exporter 配置的导出器
+)
+
+// Deps 是传递给插件 Init 的共享依赖项，key 为依赖名称。
+// 已初始化的插件实例也会以 PluginDepKey(name) 为 key 放入其中，供声明了 DependsOn 的插件使用。
+type Deps map[string]interface{}
+
+// PluginDepKey 返回插件实例在 Deps 中的 key。
+func PluginDepKey(name string) string {
+	return "plugin:" + name
+}
+
+// Dep 按名称获取依赖项并断言为类型 T，缺失或类型不匹配时返回错误。
+//
+//	logger, err := plugin.Dep[*zap.Logger](deps, plugin.DepLogger)
+func Dep[T any](deps Deps, name string) (T, error) {
+	var zero T
+	value, ok := deps[name]
+	if !ok || value == nil {
+		return zero, fmt.Errorf("%s dependency is missing", name)
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("%s dependency is not of type %T, got %T", name, zero, value)
+	}
+	return typed, nil
+}
+
+// Has 判断依赖项是否存在且不为 nil。
+func (d Deps) Has(name string) bool {
+	value, ok := d[name]
+	return ok && value != nil
+}
+
+// Logger 返回 logger 依赖，缺失时返回 Nop logger。
+func (d Deps) Logger() *zap.Logger {
+	if logger, err := Dep[*zap.Logger](d, DepLogger); err == nil {
+		return logger
+	}
+	return zap.NewNop()
+}
+
+// Config 返回应用总配置。
+func (d Deps) Config() (*conf.Config, bool) {
+	cfg, err := Dep[*conf.Config](d, DepConfig)
+	return cfg, err == nil
+}
+
+// DB 返回数据库连接。
+func (d Deps) DB() (*gorm.DB, bool) {
+	db, err := Dep[*gorm.DB](d, DepDB)
+	return db, err == nil
+}
+
+// Redis 返回 Redis 客户端。
+func (d Deps) Redis() (*redis.Client, bool) {
+	rdb, err := Dep[*redis.Client](d, DepRedis)
+	return rdb, err == nil
+}
+
+// Plugin 返回已初始化的插件实例。
+func (d Deps) Plugin(name string) (Plugin, bool) {
+	p, err := Dep[Plugin](d, PluginDepKey(name))
+	return p, err == nil
+}
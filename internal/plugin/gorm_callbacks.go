@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// instrumentDB 在 GORM 各类操作 (create、query、update、delete、row、raw) 的前后注册回调，
+// 回调名称为 "<name>:before_<operation>" 和 "<name>:after_<operation>"，供 metrics 和 tracing 插件使用。
+func instrumentDB(db *gorm.DB, name string, before, after func(tx *gorm.DB, operation string)) error {
+	cb := db.Callback()
+	return errors.Join(
+		registerDBCallbacks(name, "create", cb.Create().Get, cb.Create().Before("*"), cb.Create().After("*"), before, after),
+		registerDBCallbacks(name, "query", cb.Query().Get, cb.Query().Before("*"), cb.Query().After("*"), before, after),
+		registerDBCallbacks(name, "update", cb.Update().Get, cb.Update().Before("*"), cb.Update().After("*"), before, after),
+		registerDBCallbacks(name, "delete", cb.Delete().Get, cb.Delete().Before("*"), cb.Delete().After("*"), before, after),
+		registerDBCallbacks(name, "row", cb.Row().Get, cb.Row().Before("*"), cb.Row().After("*"), before, after),
+		registerDBCallbacks(name, "raw", cb.Raw().Get, cb.Raw().Before("*"), cb.Raw().After("*"), before, after),
+	)
+}
+
+// gormCallback 是 GORM 回调注册器 (db.Callback().Query().Before(...) 的返回值) 的方法集。
+type gormCallback interface {
+	Register(name string, fn func(*gorm.DB)) error
+	Replace(name string, fn func(*gorm.DB)) error
+}
+
+// registerDBCallbacks 注册一类操作的前后回调。同一个 *gorm.DB 上重复初始化时替换之前的回调，而不是重复注册。
+func registerDBCallbacks[C gormCallback](name, operation string, get func(string) func(*gorm.DB), before, after C, beforeFn, afterFn func(*gorm.DB, string)) error {
+	beforeName, afterName := name+":before_"+operation, name+":after_"+operation
+	registerBefore, registerAfter := before.Register, after.Register
+	if get(beforeName) != nil {
+		registerBefore, registerAfter = before.Replace, after.Replace
+	}
+	if err := registerBefore(beforeName, func(tx *gorm.DB) { beforeFn(tx, operation) }); err != nil {
+		return fmt.Errorf("register gorm callback %s: %w", beforeName, err)
+	}
+	if err := registerAfter(afterName, func(tx *gorm.DB) { afterFn(tx, operation) }); err != nil {
+		return fmt.Errorf("register gorm callback %s: %w", afterName, err)
+	}
+	return nil
+}
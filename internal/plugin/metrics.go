@@ -0,0 +1,339 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"myGin/internal/conf"
+	"myGin/internal/pkg/openapi"
+	"myGin/internal/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 认证失败的原因，对应 auth_failures_total 的 reason 标签。
+const (
+	AuthFailureUnauthenticated = "unauthenticated" // 缺少或无效的 Token / API Key
+	AuthFailureForbidden       = "forbidden"       // 缺少权限或来源地址不允许
+	AuthFailureLogin           = "login"           // 用户名或密码错误
+)
+
+// unmatchedRoute 是未匹配任何路由的请求的 route 标签，避免按原始路径产生大量时间序列。
+const unmatchedRoute = "unmatched"
+
+func init() {
+	// 指标中间件放在认证和限流之前，被它们拒绝的请求同样会被记录
+	Register("metrics", NewMetricsPlugin,
+		WithConfig(func(c *conf.Config) (interface{}, bool) {
+			return &c.Modules.Metrics, c.Modules.Metrics.Enable
+		}),
+		Before("auth", "ratelimit"),
+	)
+}
+
+// MetricsPlugin 以 Prometheus 格式暴露 HTTP、数据库、Redis、限流、认证以及 Go 运行时和进程指标。
+// 指标注册在插件自己的 Registry 中，业务代码可以通过 Registry() 注册自定义指标。
+// addr 为空时在主服务上注册指标接口，否则在 Start 时单独监听该地址。
+type MetricsPlugin struct {
+	metricsCfg *conf.MetricsConfig
+	logger     *zap.Logger
+	registry   *prometheus.Registry
+	server     *http.Server // addr 非空时的独立监听，未启动时为 nil
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	httpInFlight  *prometheus.GaugeVec
+	dbDuration    *prometheus.HistogramVec
+	dbErrors      *prometheus.CounterVec
+	redisDuration *prometheus.HistogramVec
+	rateLimited   *prometheus.CounterVec
+	authFailures  *prometheus.CounterVec
+}
+
+// NewMetricsPlugin 创建一个新的 MetricsPlugin 实例。
+func NewMetricsPlugin() Plugin {
+	return &MetricsPlugin{}
+}
+
+// Init 创建指标并挂载数据库回调和 Redis 钩子 (对应依赖存在时)。
+func (p *MetricsPlugin) Init(cfg interface{}, deps Deps) error {
+	metricsCfg, ok := cfg.(*conf.MetricsConfig)
+	if !ok {
+		return fmt.Errorf("metrics plugin init failed: expected config type *conf.MetricsConfig, but got %T", cfg)
+	}
+	p.metricsCfg = metricsCfg
+	p.logger = deps.Logger()
+	if p.metricsCfg.Path == "" {
+		p.metricsCfg.Path = "/metrics"
+	}
+
+	buckets := p.metricsCfg.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	ns := p.metricsCfg.Namespace
+	p.httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns, Name: "http_requests_total", Help: "HTTP 请求总数",
+	}, []string{"route", "method", "status"})
+	p.httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns, Name: "http_request_duration_seconds", Help: "HTTP 请求处理耗时", Buckets: buckets,
+	}, []string{"route", "method", "status"})
+	p.httpInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: ns, Name: "http_requests_in_flight", Help: "正在处理的 HTTP 请求数",
+	}, []string{"route", "method"})
+	p.dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns, Name: "db_query_duration_seconds", Help: "GORM 查询耗时", Buckets: buckets,
+	}, []string{"operation", "table"})
+	p.dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns, Name: "db_query_errors_total", Help: "GORM 查询错误数 (不包括 record not found)",
+	}, []string{"operation", "table"})
+	p.redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns, Name: "redis_command_duration_seconds", Help: "Redis 命令耗时，流水线记为 pipeline", Buckets: buckets,
+	}, []string{"command", "status"})
+	p.rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns, Name: "ratelimit_rejected_total", Help: "被限流拒绝的请求数，dry_run=\"true\" 表示只记录未拒绝",
+	}, []string{"rule", "dry_run"})
+	p.authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns, Name: "auth_failures_total", Help: "认证和授权失败次数",
+	}, []string{"reason"})
+
+	p.registry = prometheus.NewRegistry()
+	p.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		p.httpRequests, p.httpDuration, p.httpInFlight,
+		p.dbDuration, p.dbErrors, p.redisDuration,
+		p.rateLimited, p.authFailures,
+	)
+
+	if db, ok := deps.DB(); ok {
+		before := func(tx *gorm.DB, _ string) { tx.InstanceSet(dbStartKey, time.Now()) }
+		if err := instrumentDB(db, "metrics", before, p.observeDB); err != nil {
+			return fmt.Errorf("metrics plugin init failed: %w", err)
+		}
+	}
+	if rdb, ok := deps.Redis(); ok {
+		rdb.AddHook(redisMetricsHook{duration: p.redisDuration})
+	}
+
+	p.logger.Info("Metrics Plugin initialized", zap.String("path", p.metricsCfg.Path), zap.String("addr", p.metricsCfg.Addr))
+	return nil
+}
+
+// Registry 返回插件使用的 Prometheus Registry，可用于注册业务指标。
+func (p *MetricsPlugin) Registry() *prometheus.Registry {
+	return p.registry
+}
+
+// Handler 返回以 Prometheus 文本格式输出全部指标的 http.Handler。
+func (p *MetricsPlugin) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{Registry: p.registry})
+}
+
+// Register 在主服务上注册指标接口；配置了独立的 addr 时不注册，由 Start 单独监听。
+func (p *MetricsPlugin) Register(r *gin.Engine) error {
+	if p.metricsCfg.Addr != "" {
+		return nil
+	}
+	r.GET(p.metricsCfg.Path, gin.WrapH(p.Handler()))
+	openapi.Register(openapi.Route{
+		Method: http.MethodGet, Path: p.metricsCfg.Path, Tags: []string{"Observability"},
+		Summary: "Prometheus 指标", Description: "Prometheus 文本格式",
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "指标"}},
+	})
+	p.logger.Info("Metrics endpoint registered", zap.String("path", p.metricsCfg.Path))
+	return nil
+}
+
+// Start 在配置了 addr 时启动独立的指标服务器。先同步监听，端口被占用时返回错误。
+func (p *MetricsPlugin) Start(ctx context.Context) error {
+	if p.metricsCfg.Addr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", p.metricsCfg.Addr)
+	if err != nil {
+		return fmt.Errorf("metrics plugin start failed: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(p.metricsCfg.Path, p.Handler())
+	p.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := p.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Error("Metrics server stopped unexpectedly", zap.Error(err))
+		}
+	}()
+	p.logger.Info("Metrics server listening", zap.String("addr", ln.Addr().String()), zap.String("path", p.metricsCfg.Path))
+	return nil
+}
+
+// Stop 关闭独立的指标服务器。
+func (p *MetricsPlugin) Stop(ctx context.Context) error {
+	if p.server == nil {
+		return nil
+	}
+	return p.server.Shutdown(ctx)
+}
+
+// Middleware 返回记录 HTTP 请求数、耗时和并发数的中间件。
+// route 标签使用路由模板 (例如 "/api/v1/users/:id")，未匹配路由的请求记为 "unmatched"。
+func (p *MetricsPlugin) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := metricsMethod(c.Request.Method)
+		inFlight := p.httpInFlight.WithLabelValues(route, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		p.httpRequests.WithLabelValues(route, method, status).Inc()
+		p.httpDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// metricsMethod 返回 method 标签，非标准方法统一记为 "OTHER"。
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// metricsFrom 返回已加载的 metrics 插件，未启用时返回 nil。
+// 其他插件在 Init 中调用它，记录指标的方法对 nil 接收者是安全的。
+func metricsFrom(deps Deps) *MetricsPlugin {
+	p, ok := deps.Plugin("metrics")
+	if !ok {
+		return nil
+	}
+	m, _ := p.(*MetricsPlugin)
+	return m
+}
+
+// rateLimitRejected 记录一次限流拒绝，rule 为空表示顶层的默认规则。
+func (p *MetricsPlugin) rateLimitRejected(rule string, dryRun bool) {
+	if p == nil {
+		return
+	}
+	if rule == "" {
+		rule = "default"
+	}
+	p.rateLimited.WithLabelValues(rule, strconv.FormatBool(dryRun)).Inc()
+}
+
+// observeRateLimitStore 注册限流实例进程内存储的 key 数、LRU 淘汰数和空闲清除数，抓取时调用 stats 读取。
+// group 为路由组实例的组名，空字符串表示默认实例。
+func (p *MetricsPlugin) observeRateLimitStore(group string, stats func() ratelimit.StoreStats) error {
+	if p == nil {
+		return nil
+	}
+	if group == "" {
+		group = "default"
+	}
+	labels := prometheus.Labels{"group": group}
+	ns := p.metricsCfg.Namespace
+	for _, c := range []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: ns, Name: "ratelimit_store_keys", Help: "进程内限流器当前保存的 key 数", ConstLabels: labels,
+		}, func() float64 { return float64(stats().ActiveKeys) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: ns, Name: "ratelimit_store_evictions_total", Help: "进程内限流器因超过 maxKeys 淘汰的 key 数", ConstLabels: labels,
+		}, func() float64 { return float64(stats().Evictions) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: ns, Name: "ratelimit_store_expirations_total", Help: "进程内限流器因空闲超过 idleTTL 清除的 key 数", ConstLabels: labels,
+		}, func() float64 { return float64(stats().Expirations) }),
+	} {
+		if err := p.registry.Register(c); err != nil {
+			return fmt.Errorf("register ratelimit store metrics for group %s: %w", group, err)
+		}
+	}
+	return nil
+}
+
+// authFailed 记录一次认证或授权失败。
+func (p *MetricsPlugin) authFailed(reason string) {
+	if p == nil {
+		return
+	}
+	p.authFailures.WithLabelValues(reason).Inc()
+}
+
+// dbStartKey 是 GORM 语句中保存查询开始时间的 key。
+const dbStartKey = "metrics:start"
+
+// observeDB 记录一次 GORM 操作的耗时和错误。
+func (p *MetricsPlugin) observeDB(tx *gorm.DB, operation string) {
+	value, ok := tx.InstanceGet(dbStartKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+	table := tx.Statement.Table
+	if table == "" {
+		table = "unknown"
+	}
+	p.dbDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		p.dbErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// redisMetricsHook 是记录 Redis 命令耗时的 go-redis 钩子。
+type redisMetricsHook struct {
+	duration *prometheus.HistogramVec
+}
+
+// DialHook 不记录连接建立。
+func (h redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 记录单个命令的耗时。
+func (h redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(strings.ToLower(cmd.Name()), start, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 将整个流水线 (包括 MULTI/EXEC 事务) 记为一次 pipeline 命令。
+func (h redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h redisMetricsHook) observe(command string, start time.Time, err error) {
+	status := "ok"
+	if redisError(err) != nil {
+		status = "error"
+	}
+	h.duration.WithLabelValues(command, status).Observe(time.Since(start).Seconds())
+}
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
 	"myGin/internal/conf"
 
@@ -8,17 +9,50 @@ import (
 )
 
 // Plugin 是所有插件必须实现的接口。
-// 插件的生命周期：New -> Init -> Register
+// 插件的生命周期：New -> Init -> Register -> Start (可选) -> ... -> Stop (可选)
+// 插件通过 Register (见 registry.go) 在自己的 init 函数中注册，bootstrap 按依赖顺序加载。
 type Plugin interface {
 	// Init 用于初始化插件，通常在此处处理配置和依赖注入。
 	// cfg 参数是特定于该插件的配置结构 (例如 *conf.AuthConfig)。
-	// deps 包含共享的依赖项，如 logger, db 等。
-	Init(cfg interface{}, deps map[string]interface{}) error
+	// deps 包含共享的依赖项，如 logger, db 等，使用 Dep[T] 按类型获取。
+	Init(cfg interface{}, deps Deps) error
 
-	// Register 用于将插件的功能（例如中间件、路由）注册到 Gin 引擎。
+	// Register 用于将插件的路由注册到 Gin 引擎。
+	// 中间件应通过 MiddlewareProvider 提供，由 bootstrap 按配置的路由范围挂载，而不是在这里调用 r.Use。
 	Register(r *gin.Engine) error
 }
 
+// MiddlewareProvider 是插件可选实现的接口，返回需要挂载到请求链上的中间件。
+// bootstrap 在调用 Register 之前挂载该中间件，并根据插件配置的作用范围 (见 Scoped)
+// 只在匹配的路径上执行它，因此插件自己注册的路由同样受作用范围控制。
+type MiddlewareProvider interface {
+	Middleware() gin.HandlerFunc
+}
+
+// Scoped 由插件配置实现 (通常通过嵌入 conf.RouteScope)，声明中间件的作用范围。
+// 配置未实现该接口时，中间件作用于全部路由。
+type Scoped interface {
+	Scope() conf.RouteScope
+}
+
+// Starter 是插件可选实现的接口，在所有插件注册完成、HTTP 服务器开始监听前按依赖顺序调用。
+// 适合启动后台任务 (例如定时刷新、连接预热)。返回错误会导致启动失败。
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper 是插件可选实现的接口，在优雅关停时按依赖的逆序调用，ctx 带有关停超时。
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Reconfigurable 是插件可选实现的接口，用于在配置热重载时应用新配置而无需重启。
+// cfg 与 Init 接收的配置类型相同 (例如 *conf.RateLimitConfig)。
+// 返回错误时插件应继续使用旧配置。
+type Reconfigurable interface {
+	Reconfigure(cfg interface{}) error
+}
+
 // --- 类型断言辅助函数 (可选，但推荐) ---
 
 // GetAuthConfig 从 interface{} 安全地获取 AuthConfig。
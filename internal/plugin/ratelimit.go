@@ -3,22 +3,79 @@ package plugin
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 	"myGin/internal/conf"
 	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 )
 
-// RateLimitPlugin 实现了基于 IP 的请求速率限制插件。
-// 注意：当前实现使用内存存储 (sync.Map) 来跟踪每个 IP 的限流器。
-// 这适用于单实例部署或测试环境。在生产环境或分布式部署中，
-// 应考虑使用 Redis 或其他分布式存储来维护限流状态，以确保跨实例的一致性。
+// redisRetryInterval 是 Redis 限流失败后降级为进程内限流的时长，期间不再请求 Redis，避免每个请求都等待超时。
+const redisRetryInterval = 5 * time.Second
+
+// RateLimitPlugin 实现了请求速率限制插件。默认按 IP 限流，rules 可以按路由、方法以及用户、API Key 或请求头设置独立的配额。
+// backend: memory 时每个 key 使用一个进程内令牌桶，仅适用于单实例部署；
+// backend: redis 时限流状态通过 Lua 脚本原子地保存在 Redis 中 (令牌桶或滑动窗口)，多个实例共享同一配额，
+// Redis 不可用时自动降级为进程内令牌桶。
 type RateLimitPlugin struct {
+	mu           sync.RWMutex          // 保护 rateLimitCfg 和 policy
 	rateLimitCfg *conf.RateLimitConfig // 存储加载的限流配置
+	policy       *rateLimitPolicy      // 由 rateLimitCfg 编译得到的规则
 	logger       *zap.Logger
-	ipLimiters   sync.Map // key: IP 地址 (string), value: *rate.Limiter
+
+	limiter         ratelimit.Limiter        // 主限流器 (memory 或 redis)
+	counter         ratelimit.Counter        // 每日配额计数器 (memory 或 redis)
+	fallback        *ratelimit.MemoryLimiter // Redis 失败时使用的进程内限流器；backend 为 memory 时与 limiter 相同
+	fallbackCounter *ratelimit.MemoryCounter // Redis 失败时使用的进程内计数器
+	degradedUntil   atomic.Int64             // 降级截止时间 (UnixNano)，0 表示未降级
+
+	metrics *MetricsPlugin // 记录拒绝次数，metrics 插件未启用时为 nil
+
+	credentialHeaders []string // 携带认证凭证的请求头: Authorization 和 auth 插件配置的 API Key 请求头
+	authlessRoutes    sync.Map // 不在 auth 插件作用范围内的路由 (c.FullPath())，携带凭证的请求也在认证之前计数
+}
+
+func init() {
+	// 限流在认证之前执行，未携带凭证的请求按 IP 计数和拒绝；存在依赖用户、API Key 或权限的规则时，
+	// 携带凭证的请求推迟到认证之后 (onAuthenticated / onAuthFailed) 匹配和计数，每个请求只按一条规则计数。
+	Register("ratelimit", NewRateLimitPlugin,
+		WithConfig(func(c *conf.Config) (interface{}, bool) {
+			return &c.Modules.RateLimit, c.Modules.RateLimit.Enable
+		}),
+		WithInstances(func(c *conf.Config) []Instance {
+			// 每个路由组创建独立的限流实例
+			var instances []Instance
+			for _, g := range c.Modules.RateLimit.Groups {
+				rate, burst := g.Rate, g.Burst
+				if rate == 0 {
+					rate = c.Modules.RateLimit.Rate
+				}
+				if burst == 0 {
+					burst = c.Modules.RateLimit.Burst
+				}
+				instances = append(instances, Instance{Name: g.Name, Config: &conf.RateLimitConfig{
+					Enable:     true,
+					Rate:       rate,
+					Burst:      burst,
+					Backend:    c.Modules.RateLimit.Backend,
+					Algorithm:  c.Modules.RateLimit.Algorithm,
+					Window:     c.Modules.RateLimit.Window,
+					KeyPrefix:  c.Modules.RateLimit.KeyPrefix + g.Name + ":", // 各组使用独立的 Redis key
+					MaxKeys:    c.Modules.RateLimit.MaxKeys,
+					IdleTTL:    c.Modules.RateLimit.IdleTTL,
+					Allowlist:  c.Modules.RateLimit.Allowlist,
+					DryRun:     c.Modules.RateLimit.DryRun,
+					RouteScope: g.RouteScope,
+					Group:      g.Name,
+				}})
+			}
+			return instances
+		}),
+		Before("auth"),
+	)
 }
 
 // NewRateLimitPlugin 创建一个新的 RateLimitPlugin 实例。
@@ -27,7 +84,7 @@ func NewRateLimitPlugin() Plugin {
 }
 
 // Init 初始化 RateLimitPlugin。
-func (p *RateLimitPlugin) Init(cfg interface{}, deps map[string]interface{}) error {
+func (p *RateLimitPlugin) Init(cfg interface{}, deps Deps) error {
 	// 1. 类型断言获取具体配置
 	rateLimitCfg, err := GetRateLimitConfig(cfg)
 	if err != nil {
@@ -36,13 +93,16 @@ func (p *RateLimitPlugin) Init(cfg interface{}, deps map[string]interface{}) err
 	p.rateLimitCfg = rateLimitCfg
 
 	// 2. 获取 logger 依赖
-	loggerDep, ok := deps["logger"]
-	if !ok {
-		return fmt.Errorf("ratelimit plugin init failed: logger dependency is missing")
+	p.logger, err = Dep[*zap.Logger](deps, DepLogger)
+	if err != nil {
+		return fmt.Errorf("ratelimit plugin init failed: %w", err)
 	}
-	p.logger, ok = loggerDep.(*zap.Logger)
-	if !ok {
-		return fmt.Errorf("ratelimit plugin init failed: logger dependency is not of type *zap.Logger")
+
+	p.metrics = metricsFrom(deps)
+
+	p.credentialHeaders = []string{"Authorization"}
+	if appCfg, ok := deps.Config(); ok && appCfg.Modules.Auth.APIKeys.Enable {
+		p.credentialHeaders = append(p.credentialHeaders, appCfg.Modules.Auth.APIKeys.Header)
 	}
 
 	// 3. 检查是否启用
@@ -51,33 +111,117 @@ func (p *RateLimitPlugin) Init(cfg interface{}, deps map[string]interface{}) err
 		return nil // 如果未启用，则无需执行任何操作
 	}
 
-	// 4. 检查配置有效性
-	if p.rateLimitCfg.Rate <= 0 || p.rateLimitCfg.Burst <= 0 {
-		p.logger.Error("RateLimit Plugin init failed: invalid rate or burst value in config",
-			zap.Float64("rate", p.rateLimitCfg.Rate),
-			zap.Int("burst", p.rateLimitCfg.Burst))
-		return fmt.Errorf("ratelimit plugin init failed: rate and burst must be positive")
+	// Rate、Burst 启用时必须大于 0，已由 bootstrap.LoadConfig 根据 conf.RateLimitConfig 的 validate 标签校验
+	if p.policy, err = compileRateLimitPolicy(p.rateLimitCfg); err != nil {
+		return fmt.Errorf("ratelimit plugin init failed: %w", err)
 	}
 
-	// 5. 初始化 IP 限流器存储 (确保只在初始化时执行一次)
-	p.ipLimiters = sync.Map{}
+	// 4. 创建限流器
+	storeOpts := ratelimit.StoreOptions{MaxKeys: p.rateLimitCfg.MaxKeys}
+	if p.rateLimitCfg.IdleTTL != "" {
+		if storeOpts.IdleTTL, err = time.ParseDuration(p.rateLimitCfg.IdleTTL); err != nil {
+			return fmt.Errorf("ratelimit plugin init failed: invalid idleTTL: %w", err)
+		}
+	}
+	if refill := time.Duration(float64(p.rateLimitCfg.Burst) / p.rateLimitCfg.Rate * float64(time.Second)); storeOpts.IdleTTL > 0 && storeOpts.IdleTTL < refill {
+		p.logger.Warn("RateLimit: idleTTL 小于令牌桶回满时间, 空闲后被清除的调用方会提前获得完整配额",
+			zap.Duration("idleTTL", storeOpts.IdleTTL), zap.Duration("refill", refill))
+	}
+	p.fallback = ratelimit.NewMemoryLimiter(storeOpts)
+	p.fallbackCounter = ratelimit.NewMemoryCounter(storeOpts)
+	if err = p.newLimiter(deps); err != nil {
+		return fmt.Errorf("ratelimit plugin init failed: %w", err)
+	}
+	if err = p.metrics.observeRateLimitStore(p.rateLimitCfg.Group, p.Stats); err != nil {
+		return fmt.Errorf("ratelimit plugin init failed: %w", err)
+	}
 
-	p.logger.Info("RateLimit Plugin initialized successfully.")
+	p.logger.Info("RateLimit Plugin initialized successfully.",
+		zap.String("limiter", fmt.Sprintf("%T", p.limiter)),
+		zap.Float64("rate", p.rateLimitCfg.Rate),
+		zap.Int("burst", p.rateLimitCfg.Burst),
+		zap.Int("rules", len(p.policy.rules)),
+		zap.Bool("dryRun", p.rateLimitCfg.DryRun))
 	return nil
 }
 
-// Register 将限流中间件注册到 Gin 引擎。
+// newLimiter 根据 backend 和 algorithm 创建主限流器和计数器。backend 为 redis 但 Redis 未启用时降级为进程内令牌桶。
+func (p *RateLimitPlugin) newLimiter(deps Deps) error {
+	p.limiter, p.counter = p.fallback, p.fallbackCounter
+	algorithm := p.rateLimitCfg.Algorithm
+	if algorithm == "" {
+		algorithm = ratelimit.TokenBucket
+	}
+	if p.rateLimitCfg.Backend != "redis" {
+		if algorithm != ratelimit.TokenBucket {
+			return fmt.Errorf("algorithm %s requires backend redis", algorithm)
+		}
+		return nil
+	}
+
+	rdb, ok := deps.Redis()
+	if !ok {
+		p.logger.Warn("RateLimit: Redis 未启用, 降级为进程内令牌桶限流 (多实例间不共享配额)")
+		return nil
+	}
+	if algorithm == ratelimit.SlidingWindow {
+		p.limiter = ratelimit.NewRedisSlidingWindow(rdb, p.rateLimitCfg.KeyPrefix)
+	} else {
+		p.limiter = ratelimit.NewRedisTokenBucket(rdb, p.rateLimitCfg.KeyPrefix)
+	}
+	p.counter = ratelimit.NewRedisCounter(rdb, p.rateLimitCfg.KeyPrefix)
+	return nil
+}
+
+// Stats 返回进程内限流器和每日配额计数器合计的 key 数和淘汰统计 (backend 为 redis 时只包含降级期间使用的 key)。
+func (p *RateLimitPlugin) Stats() ratelimit.StoreStats {
+	if p.fallback == nil {
+		return ratelimit.StoreStats{}
+	}
+	limiter, counter := p.fallback.Stats(), p.fallbackCounter.Stats()
+	return ratelimit.StoreStats{
+		ActiveKeys:  limiter.ActiveKeys + counter.ActiveKeys,
+		Evictions:   limiter.Evictions + counter.Evictions,
+		Expirations: limiter.Expirations + counter.Expirations,
+	}
+}
+
+// Register 注册限流插件的路由。限流中间件通过 Middleware 提供，
+// 由 bootstrap 按 modules.ratelimit.include/exclude (或 groups 中各组的范围) 挂载。
 func (p *RateLimitPlugin) Register(r *gin.Engine) error {
-	if !p.rateLimitCfg.Enable {
-		return nil // 如果未启用，则不注册中间件
+	return nil // 当前没有需要注册的路由
+}
+
+// Middleware 返回限流中间件 (实现 MiddlewareProvider 接口)。
+func (p *RateLimitPlugin) Middleware() gin.HandlerFunc {
+	return p.rateLimitMiddleware()
+}
+
+// Reconfigure 应用热重载后的限流配置 (实现 Reconfigurable 接口)。
+// rate/burst/window、rules、allowlist 和 dryRun 的变化在下一个请求时生效 (进程内限流器按新参数就地调整)；
+// backend、algorithm 和 keyPrefix 的变化需要重启。
+// enable 从 true 变为 false 时中间件直接放行，而从 false 变为 true 需要重启 (中间件未注册)。
+func (p *RateLimitPlugin) Reconfigure(cfg interface{}) error {
+	rateLimitCfg, err := GetRateLimitConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("ratelimit plugin reconfigure failed: %w", err)
 	}
 
-	p.logger.Info("Registering RateLimit Plugin middleware...")
+	policy, err := compileRateLimitPolicy(rateLimitCfg)
+	if err != nil {
+		return fmt.Errorf("ratelimit plugin reconfigure failed: %w", err)
+	}
 
-	// 将中间件应用到全局
-	r.Use(p.rateLimitMiddleware())
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	p.logger.Info("RateLimit Plugin middleware registered globally.")
+	p.rateLimitCfg = rateLimitCfg
+	p.policy = policy
+	p.logger.Info("RateLimit Plugin reconfigured",
+		zap.Float64("rate", rateLimitCfg.Rate),
+		zap.Int("burst", rateLimitCfg.Burst),
+		zap.Int("rules", len(policy.rules)),
+		zap.Bool("dryRun", rateLimitCfg.DryRun))
 	return nil
 }
 
@@ -88,26 +232,185 @@ func (p *RateLimitPlugin) rateLimitMiddleware() gin.HandlerFunc {
 		ip := c.ClientIP()
 		if ip == "" {
 			// 如果无法获取 IP，可以选择放行或记录警告
-			p.logger.Warn("RateLimit middleware: Could not get client IP, allowing request")
+			loggerFrom(c, p.logger).Warn("RateLimit middleware: Could not get client IP, allowing request")
+			c.Next()
+			return
+		}
+
+		p.mu.RLock()
+		policy := p.policy
+		p.mu.RUnlock()
+		if !policy.enabled {
+			c.Next() // 已通过配置热重载禁用
+			return
+		}
+		if policy.allowlisted(c, ip) {
 			c.Next()
 			return
 		}
 
-		// 获取或创建该 IP 对应的限流器
-		// 使用 LoadOrStore 保证并发安全地获取或创建 Limiter
-		limiterUntyped, _ := p.ipLimiters.LoadOrStore(ip, rate.NewLimiter(rate.Limit(p.rateLimitCfg.Rate), p.rateLimitCfg.Burst))
-		limiter := limiterUntyped.(*rate.Limiter) // 类型断言
-
-		// 检查是否允许请求
-		if !limiter.Allow() {
-			// 如果不允许，则拒绝请求
-			p.logger.Warn("RateLimit middleware: Too many requests", zap.String("ip", ip))
-			errs.TooManyRequests.JSON(c) // 使用 errs 包返回标准错误
-			c.Abort()                    // 中断请求链
+		// 携带凭证的请求在认证之前身份未知，存在依赖身份的规则时推迟到认证之后匹配和计数
+		if p.deferrable(c, policy) {
+			p.deferLimit(c, policy, ip)
+			return
+		}
+
+		// 选择最具体的规则，检查速率和每日配额
+		rule, id := policy.match(c, ip)
+		if !p.limit(c, policy, rule, id) {
 			return
 		}
 
 		// 如果允许，则继续处理请求
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// deferrable 判断请求是否需要推迟到认证之后限流: 存在依赖身份的规则、请求携带凭证且尚未认证，
+// 并且路由没有被记录为不在 auth 插件的作用范围内。
+func (p *RateLimitPlugin) deferrable(c *gin.Context, policy *rateLimitPolicy) bool {
+	if !policy.identityRules {
+		return false
+	}
+	if _, ok := ClaimsFrom(c); ok {
+		return false
+	}
+	if _, ok := p.authlessRoutes.Load(c.FullPath()); ok {
+		return false
+	}
+	for _, header := range p.credentialHeaders {
+		if c.GetHeader(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// deferLimit 在认证之后只按一条规则计数: 认证通过时按调用方身份匹配规则并检查用户 / API Key 白名单，
+// 认证失败时按认证之前适用的规则 (IP、请求头或默认规则) 计数，超限时以 429 代替认证错误响应。
+// 两个回调都没有执行说明路由不在 auth 插件的作用范围内，记录该路由，之后的请求在认证之前计数。
+func (p *RateLimitPlugin) deferLimit(c *gin.Context, policy *rateLimitPolicy, ip string) {
+	checked := false
+	check := func(c *gin.Context) bool {
+		checked = true
+		if policy.allowlisted(c, ip) {
+			return true
+		}
+		rule, id := policy.match(c, ip)
+		return p.limit(c, policy, rule, id)
+	}
+	onAuthenticated(c, check)
+	onAuthFailed(c, check)
+
+	c.Next()
+
+	if !checked && !c.IsAborted() {
+		p.authlessRoutes.Store(c.FullPath(), struct{}{})
+		loggerFrom(c, p.logger).Debug("RateLimit middleware: route is outside the auth scope, limiting before auth", zap.String("route", c.FullPath()))
+	}
+}
+
+// limit 按规则检查调用方 id 的速率和每日配额并设置 RateLimit-* 响应头，请求被拒绝时返回 false。
+func (p *RateLimitPlugin) limit(c *gin.Context, policy *rateLimitPolicy, rule *rateLimitRule, id string) bool {
+	dryRun := policy.dryRun || rule.dryRun
+	var state ratelimit.Result // 写入响应头的限流状态: 同时有速率和每日配额时取剩余较少的一个
+	if rule.limit.Rate > 0 {
+		res := p.allow(c, rule.storageKey(id), rule.limit)
+		if !res.Allowed && p.reject(c, rule, id, dryRun, res, errs.TooManyRequests) {
+			return false
+		}
+		state = res
+	}
+	if rule.daily > 0 {
+		res := p.allowDaily(c, rule, id)
+		if !res.Allowed && p.reject(c, rule, id, dryRun, res, ErrDailyQuotaExceeded) {
+			return false
+		}
+		if rule.limit.Rate <= 0 || res.Remaining < state.Remaining {
+			state = res
+		}
+	}
+	setRateLimitHeaders(c, state)
+	return true
+}
+
+// reject 拒绝超出配额的请求并返回 true，响应带有 RateLimit-* 和 Retry-After 头，details 为 RateLimitDetails；
+// dry-run 时只记录日志并返回 false (请求继续处理)。
+func (p *RateLimitPlugin) reject(c *gin.Context, rule *rateLimitRule, id string, dryRun bool, res ratelimit.Result, apiErr *errs.APIError) bool {
+	fields := []zap.Field{zap.String("ip", c.ClientIP()), zap.String("rule", rule.name), zap.String("key", id), zap.String("path", c.Request.URL.Path)}
+	p.metrics.rateLimitRejected(rule.name, dryRun)
+	if dryRun {
+		loggerFrom(c, p.logger).Warn("RateLimit middleware: dry-run, request would be rejected", fields...)
+		return false
+	}
+	// 如果不允许，则拒绝请求
+	loggerFrom(c, p.logger).Warn("RateLimit middleware: Too many requests", fields...)
+	setRateLimitHeaders(c, res)
+	apiErr.WithDetails(rateLimitDetails(res, time.Now())).JSON(c) // 使用 errs 包返回标准错误
+	c.Abort()      // 中断请求链
+	return true
+}
+
+// allow 使用主限流器判断请求是否允许。主限流器 (Redis) 出错时在 redisRetryInterval 内降级为进程内限流器，
+// 之后再次尝试 Redis。
+func (p *RateLimitPlugin) allow(c *gin.Context, key string, limit ratelimit.Limit) ratelimit.Result {
+	if p.limiter != ratelimit.Limiter(p.fallback) && !p.degraded() {
+		res, err := p.limiter.Allow(c.Request.Context(), key, limit)
+		if err == nil {
+			p.redisRecovered()
+			return res
+		}
+		p.redisFailed(err)
+	}
+	res, _ := p.fallback.Allow(c.Request.Context(), key, limit)
+	return res
+}
+
+// allowDaily 增加调用方在规则下的当日请求数并判断是否超过 daily，Redis 出错时与 allow 一样降级。
+func (p *RateLimitPlugin) allowDaily(c *gin.Context, rule *rateLimitRule, id string) ratelimit.Result {
+	now := time.Now()
+	key, expireAt := dailyKey(rule, id, now)
+	var n int64
+	if p.counter != ratelimit.Counter(p.fallbackCounter) && !p.degraded() {
+		var err error
+		if n, err = p.counter.Incr(c.Request.Context(), key, expireAt); err == nil {
+			p.redisRecovered()
+		} else {
+			p.redisFailed(err)
+		}
+	}
+	if n == 0 {
+		n, _ = p.fallbackCounter.Incr(c.Request.Context(), key, expireAt)
+	}
+
+	res := ratelimit.Result{
+		Allowed:    n <= rule.daily,
+		Limit:      int(rule.daily),
+		Remaining:  int(max(0, rule.daily-n)),
+		ResetAfter: expireAt.Sub(now),
+	}
+	if !res.Allowed {
+		res.RetryAfter = res.ResetAfter
+	}
+	return res
+}
+
+// degraded 判断是否处于 Redis 降级期。
+func (p *RateLimitPlugin) degraded() bool {
+	return time.Now().UnixNano() < p.degradedUntil.Load()
+}
+
+// redisFailed 进入 Redis 降级期，只在从正常状态进入时记录日志。
+func (p *RateLimitPlugin) redisFailed(err error) {
+	if p.degradedUntil.Swap(time.Now().Add(redisRetryInterval).UnixNano()) == 0 {
+		p.logger.Warn("RateLimit: Redis 限流失败, 降级为进程内令牌桶限流",
+			zap.Duration("retry_after", redisRetryInterval), zap.Error(err))
+	}
+}
+
+// redisRecovered 在 Redis 请求成功后结束降级。
+func (p *RateLimitPlugin) redisRecovered() {
+	if p.degradedUntil.Load() != 0 && p.degradedUntil.Swap(0) != 0 {
+		p.logger.Info("RateLimit: Redis 已恢复, 停止降级")
+	}
+}
@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"myGin/internal/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限流响应头 (IETF draft-ietf-httpapi-ratelimit-headers)，时间均为秒数。
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"     // 配额上限 (令牌桶容量、窗口内请求数或每日请求数)
+	HeaderRateLimitRemaining = "RateLimit-Remaining" // 本次请求后剩余的配额
+	HeaderRateLimitReset     = "RateLimit-Reset"     // 距离配额完全恢复的秒数
+	HeaderRetryAfter         = "Retry-After"         // 仅 429 响应，距离下次可能成功的秒数
+)
+
+// RateLimitDetails 是 429 响应 details 字段的内容，客户端可据此退避。
+type RateLimitDetails struct {
+	Limit      int       `json:"limit"`
+	Remaining  int       `json:"remaining"`
+	RetryAfter int64     `json:"retryAfter"` // 秒，与 Retry-After 响应头相同
+	Reset      int64     `json:"reset"`      // 秒，与 RateLimit-Reset 响应头相同
+	ResetAt    time.Time `json:"resetAt"`    // 配额完全恢复的时间 (UTC)
+}
+
+// setRateLimitHeaders 根据限流结果写入 RateLimit-* 响应头，被拒绝时同时写入 Retry-After。
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	h := c.Writer.Header()
+	h.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+	h.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+	h.Set(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+	if !res.Allowed {
+		h.Set(HeaderRetryAfter, strconv.FormatInt(max(1, ceilSeconds(res.RetryAfter)), 10))
+	}
+}
+
+// rateLimitDetails 返回 429 响应的 details。
+func rateLimitDetails(res ratelimit.Result, now time.Time) RateLimitDetails {
+	return RateLimitDetails{
+		Limit:      res.Limit,
+		Remaining:  res.Remaining,
+		RetryAfter: max(1, ceilSeconds(res.RetryAfter)),
+		Reset:      ceilSeconds(res.ResetAfter),
+		ResetAt:    now.Add(res.ResetAfter).UTC().Truncate(time.Second),
+	}
+}
+
+// ceilSeconds 将时长向上取整为秒，避免客户端过早重试。
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	if d >= time.Duration(math.MaxInt64)-time.Second {
+		return math.MaxInt64 / int64(time.Second)
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
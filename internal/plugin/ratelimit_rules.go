@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myGin/internal/conf"
+	"myGin/internal/pkg/pathmatch"
+	"myGin/internal/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限流规则的 key 维度，对应配置 modules.ratelimit.rules[].key。
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyAPIKey = "apikey"
+	RateLimitKeyHeader = "header"
+)
+
+// rateLimitRule 是编译后的限流规则。
+type rateLimitRule struct {
+	name        string             // 为空表示顶层 rate/burst 对应的默认规则
+	pattern     *pathmatch.Pattern // nil 表示全部路径
+	methods     map[string]struct{}
+	permissions []string
+	key         string
+	header      string
+	limit       ratelimit.Limit // Rate 为 0 时只限制 daily
+	daily       int64
+	dryRun      bool
+	specificity [3]int // path 具体程度、是否声明 methods、permissions 数量
+}
+
+// storageKey 返回调用方 id 在限流器中的 key。默认规则直接使用 id (例如 "ip:1.2.3.4")。
+func (r *rateLimitRule) storageKey(id string) string {
+	if r.name == "" {
+		return id
+	}
+	return "rule:" + r.name + ":" + id
+}
+
+// rateLimitPolicy 是由 conf.RateLimitConfig 编译得到的限流策略，热重载时整体替换。
+type rateLimitPolicy struct {
+	enabled       bool
+	dryRun        bool
+	defaultRule   *rateLimitRule   // 没有规则匹配时按 IP 使用顶层 rate/burst
+	rules         []*rateLimitRule // 按具体程度从高到低排序
+	identityRules bool             // 存在按 user / apikey 维度或要求权限的规则，需要在认证之后再匹配
+	allowNets     []*net.IPNet
+	allowKeys     map[string]struct{} // "user:<id>" 或 "apikey:<id>"
+}
+
+// compileRateLimitPolicy 校验并编译限流配置。
+func compileRateLimitPolicy(cfg *conf.RateLimitConfig) (*rateLimitPolicy, error) {
+	window, err := parseWindow(cfg.Window, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	pol := &rateLimitPolicy{
+		enabled: cfg.Enable,
+		dryRun:  cfg.DryRun,
+		defaultRule: &rateLimitRule{
+			key:   RateLimitKeyIP,
+			limit: ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst, Window: window},
+		},
+		allowKeys: make(map[string]struct{}),
+	}
+
+	names := make(map[string]struct{}, len(cfg.Rules))
+	for i, rc := range cfg.Rules {
+		if _, dup := names[rc.Name]; dup {
+			return nil, fmt.Errorf("rules[%d]: duplicate rule name %q", i, rc.Name)
+		}
+		names[rc.Name] = struct{}{}
+		rule, err := compileRateLimitRule(rc, window)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d] (%s): %w", i, rc.Name, err)
+		}
+		pol.rules = append(pol.rules, rule)
+		if rule.key == RateLimitKeyUser || rule.key == RateLimitKeyAPIKey || len(rule.permissions) > 0 {
+			pol.identityRules = true
+		}
+	}
+	// 稳定排序: 同样具体的规则保持配置中的顺序
+	sort.SliceStable(pol.rules, func(i, j int) bool {
+		a, b := pol.rules[i].specificity, pol.rules[j].specificity
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] > b[k]
+			}
+		}
+		return false
+	})
+
+	for _, entry := range cfg.Allowlist {
+		switch {
+		case strings.HasPrefix(entry, RateLimitKeyUser+":"), strings.HasPrefix(entry, RateLimitKeyAPIKey+":"):
+			pol.allowKeys[entry] = struct{}{}
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("allowlist: invalid CIDR %q", entry)
+			}
+			pol.allowNets = append(pol.allowNets, network)
+		default:
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("allowlist: %q is not an IP, CIDR, user:<id> or apikey:<id>", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			pol.allowNets = append(pol.allowNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return pol, nil
+}
+
+// compileRateLimitRule 编译单条规则，window 为顶层配置的窗口长度。
+func compileRateLimitRule(rc conf.RateLimitRuleConfig, window time.Duration) (*rateLimitRule, error) {
+	ruleWindow, err := parseWindow(rc.Window, window)
+	if err != nil {
+		return nil, err
+	}
+	rule := &rateLimitRule{
+		name:        rc.Name,
+		permissions: rc.Permissions,
+		key:         rc.Key,
+		header:      rc.Header,
+		limit:       ratelimit.Limit{Rate: rc.Rate, Burst: rc.Burst, Window: ruleWindow},
+		daily:       rc.Daily,
+		dryRun:      rc.DryRun,
+	}
+	if rule.key == "" {
+		rule.key = RateLimitKeyIP
+	}
+	if rc.Path != "" {
+		pattern, err := pathmatch.Compile(rc.Path)
+		if err != nil {
+			return nil, err
+		}
+		rule.pattern = &pattern
+		rule.specificity[0] = pattern.Specificity()
+	}
+	if len(rc.Methods) > 0 {
+		rule.methods = make(map[string]struct{}, len(rc.Methods))
+		for _, m := range rc.Methods {
+			rule.methods[strings.ToUpper(m)] = struct{}{}
+		}
+		rule.specificity[1] = 1
+	}
+	rule.specificity[2] = len(rc.Permissions)
+	return rule, nil
+}
+
+// parseWindow 解析窗口长度，为空时返回 def。
+func parseWindow(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	window, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive, got %q", s)
+	}
+	return window, nil
+}
+
+// match 返回请求适用的最具体规则及该规则维度下的调用方标识。
+// 调用方缺少规则的维度 (例如未登录时的 user) 时跳过该规则，没有规则匹配时返回默认规则和 "ip:<ip>"。
+func (pol *rateLimitPolicy) match(c *gin.Context, ip string) (*rateLimitRule, string) {
+	method, urlPath := c.Request.Method, c.Request.URL.Path
+	for _, rule := range pol.rules {
+		if rule.pattern != nil && !rule.pattern.Match(urlPath) {
+			continue
+		}
+		if rule.methods != nil {
+			if _, ok := rule.methods[method]; !ok {
+				continue
+			}
+		}
+		if len(rule.permissions) > 0 && len(PermissionsFrom(c).Missing(rule.permissions)) > 0 {
+			continue
+		}
+		if id := rateLimitIdentity(c, rule.key, rule.header, ip); id != "" {
+			return rule, id
+		}
+	}
+	return pol.defaultRule, RateLimitKeyIP + ":" + ip
+}
+
+// allowlisted 判断调用方的 IP、用户或 API Key 是否在白名单中。
+func (pol *rateLimitPolicy) allowlisted(c *gin.Context, ip string) bool {
+	if len(pol.allowNets) > 0 {
+		if addr := net.ParseIP(ip); addr != nil {
+			for _, network := range pol.allowNets {
+				if network.Contains(addr) {
+					return true
+				}
+			}
+		}
+	}
+	if len(pol.allowKeys) > 0 {
+		for _, key := range []string{RateLimitKeyUser, RateLimitKeyAPIKey} {
+			if id := rateLimitIdentity(c, key, "", ip); id != "" {
+				if _, ok := pol.allowKeys[id]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// rateLimitIdentity 返回调用方在指定维度下的标识，例如 "user:7"、"apikey:<id>"、"header:<value>"，
+// 调用方缺少该维度时返回空字符串。user 和 apikey 依赖 auth 插件写入的 claims。
+func rateLimitIdentity(c *gin.Context, key, header, ip string) string {
+	switch key {
+	case RateLimitKeyUser:
+		if claims, ok := ClaimsFrom(c); ok && claims.TokenType != TokenTypeAPIKey {
+			return RateLimitKeyUser + ":" + strconv.FormatInt(claims.UserID, 10)
+		}
+	case RateLimitKeyAPIKey:
+		if claims, ok := ClaimsFrom(c); ok && claims.TokenType == TokenTypeAPIKey {
+			return RateLimitKeyAPIKey + ":" + claims.ID
+		}
+	case RateLimitKeyHeader:
+		if v := c.GetHeader(header); v != "" {
+			return RateLimitKeyHeader + ":" + v
+		}
+	default:
+		return RateLimitKeyIP + ":" + ip
+	}
+	return ""
+}
+
+// dailyKey 返回规则当日 (UTC) 配额计数的 key 及其清零时间。
+func dailyKey(rule *rateLimitRule, id string, now time.Time) (string, time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	return "daily:" + rule.name + ":" + id + ":" + day.Format("20060102"), day.Add(24 * time.Hour)
+}
@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"myGin/internal/conf"
+)
+
+// Factory 定义了创建插件实例的函数签名。
+type Factory func() Plugin
+
+// ConfigFunc 从应用总配置中取出插件自己的配置部分 (e.g., *conf.AuthConfig)，并返回插件是否启用。
+type ConfigFunc func(cfg *conf.Config) (moduleCfg interface{}, enabled bool)
+
+// Instance 是同一插件类型的一个具名实例及其配置。
+type Instance struct {
+	Name   string
+	Config interface{}
+}
+
+// InstancesFunc 从应用总配置中返回额外的具名实例 (例如按路由组使用不同参数的限流实例)。
+// 仅在插件启用 (ConfigFunc 返回 enabled=true) 时调用。
+type InstancesFunc func(cfg *conf.Config) []Instance
+
+// Registration 描述一个已注册的插件及其依赖声明。
+type Registration struct {
+	Name      string
+	Factory   Factory
+	Config    ConfigFunc
+	Instances InstancesFunc // 可选，每个实例都会通过 Factory 创建独立的插件对象
+
+	DependsOn []string // 强依赖的插件: 必须启用并先于本插件初始化，否则本插件被跳过
+	After     []string // 弱顺序: 若这些插件也启用，则在它们之后初始化
+	Before    []string // 弱顺序: 若这些插件也启用，则在它们之前初始化
+	Requires  []string // 必须存在的共享依赖项 (Deps 的 key, 例如 "redis", "db")
+}
+
+// Option 配置插件注册信息。
+type Option func(*Registration)
+
+// WithConfig 设置插件的配置读取函数。未设置时插件总是启用，且 Init 收到的 cfg 为 nil。
+func WithConfig(fn ConfigFunc) Option {
+	return func(r *Registration) { r.Config = fn }
+}
+
+// WithInstances 设置额外实例的读取函数。实例名称为 "<插件名>/<实例名>"，与默认实例共享排序位置。
+func WithInstances(fn InstancesFunc) Option {
+	return func(r *Registration) { r.Instances = fn }
+}
+
+// DependsOn 声明本插件强依赖的其他插件。
+func DependsOn(names ...string) Option {
+	return func(r *Registration) { r.DependsOn = append(r.DependsOn, names...) }
+}
+
+// After 声明本插件应在指定插件之后初始化和注册 (例如在认证之后再授权)。
+func After(names ...string) Option {
+	return func(r *Registration) { r.After = append(r.After, names...) }
+}
+
+// Before 声明本插件应在指定插件之前初始化和注册。
+func Before(names ...string) Option {
+	return func(r *Registration) { r.Before = append(r.Before, names...) }
+}
+
+// Requires 声明本插件必须的共享依赖项，缺失时插件被跳过。
+func Requires(deps ...string) Option {
+	return func(r *Registration) { r.Requires = append(r.Requires, deps...) }
+}
+
+// Registry 保存插件名称到注册信息的映射，并发安全。
+type Registry struct {
+	mu      sync.RWMutex
+	plugins map[string]*Registration
+}
+
+// NewRegistry 创建一个空的插件注册表。应用通常使用 Register 写入的默认注册表。
+func NewRegistry() *Registry {
+	return &Registry{plugins: make(map[string]*Registration)}
+}
+
+// defaultRegistry 是 Register 使用的全局注册表。
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局插件注册表。
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register 将插件注册到全局注册表，通常在插件所在包的 init 函数中调用:
+//
+//	func init() {
+//		plugin.Register("ratelimit", NewRateLimitPlugin,
+//			plugin.WithConfig(func(c *conf.Config) (interface{}, bool) { return &c.Modules.RateLimit, c.Modules.RateLimit.Enable }),
+//			plugin.After("auth"))
+//	}
+//
+// 与 database/sql.Register 一样，名称重复或 factory 为 nil 时 panic。
+func Register(name string, factory Factory, opts ...Option) {
+	defaultRegistry.Register(name, factory, opts...)
+}
+
+// Register 将插件注册到当前注册表。名称重复或 factory 为 nil 时 panic。
+func (r *Registry) Register(name string, factory Factory, opts ...Option) {
+	if name == "" || factory == nil {
+		panic("plugin: Register requires a name and a non-nil factory")
+	}
+	reg := &Registration{Name: name, Factory: factory}
+	for _, opt := range opts {
+		opt(reg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.plugins[name]; dup {
+		panic("plugin: Register called twice for plugin " + name)
+	}
+	r.plugins[name] = reg
+}
+
+// Lookup 按名称查找插件注册信息。
+func (r *Registry) Lookup(name string) (*Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.plugins[name]
+	return reg, ok
+}
+
+// Names 返回所有已注册插件的名称 (按字母排序)。
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.plugins))
+	for name := range r.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sort 按 DependsOn/After/Before 声明对指定插件进行拓扑排序。
+// 只考虑 names 内部的顺序关系 (未启用的插件不参与排序)；没有顺序约束的插件按名称排序，保证结果稳定。
+// 存在循环依赖或名称未注册时返回错误。
+func (r *Registry) Sort(names []string) ([]*Registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	selected := make(map[string]*Registration, len(names))
+	for _, name := range names {
+		reg, ok := r.plugins[name]
+		if !ok {
+			return nil, fmt.Errorf("plugin %q is not registered", name)
+		}
+		selected[name] = reg
+	}
+
+	// edges[a] 包含所有必须在 a 之后的插件
+	edges := make(map[string][]string, len(selected))
+	inDegree := make(map[string]int, len(selected))
+	addEdge := func(from, to string) {
+		if _, ok := selected[from]; !ok {
+			return
+		}
+		if _, ok := selected[to]; !ok {
+			return
+		}
+		edges[from] = append(edges[from], to)
+		inDegree[to]++
+	}
+	for name, reg := range selected {
+		inDegree[name] += 0
+		for _, dep := range reg.DependsOn {
+			addEdge(dep, name)
+		}
+		for _, dep := range reg.After {
+			addEdge(dep, name)
+		}
+		for _, next := range reg.Before {
+			addEdge(name, next)
+		}
+	}
+
+	// Kahn 算法，就绪队列按名称排序
+	var ready []string
+	for name, d := range inDegree {
+		if d == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sorted := make([]*Registration, 0, len(selected))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, selected[name])
+		for _, next := range edges[name] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if len(sorted) != len(selected) {
+		var cyclic []string
+		for name, d := range inDegree {
+			if d > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("plugin dependency cycle detected among: %s", strings.Join(cyclic, ", "))
+	}
+	return sorted, nil
+}
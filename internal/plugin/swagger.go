@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"myGin/internal/conf"
+	"myGin/internal/pkg/buildinfo"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/openapi"
+	"myGin/internal/pkg/pathmatch"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"go.uber.org/zap"
+)
+
+// 路由文档检查模式，对应配置 modules.swagger.check。
+const (
+	SwaggerCheckOff  = "off"
+	SwaggerCheckWarn = "warn"
+	SwaggerCheckFail = "fail"
+)
+
+// Swagger UI 路径下 OpenAPI 文档和错误码目录的文件名。
+const (
+	swaggerSpecFile   = "openapi.json"
+	swaggerErrorsFile = "errors.json"
+)
+
+func init() {
+	Register("swagger", NewSwaggerPlugin,
+		WithConfig(func(c *conf.Config) (interface{}, bool) {
+			return &c.Modules.Swagger, c.Modules.Swagger.Enable
+		}),
+	)
+}
+
+// swaggerIndex 是 Swagger UI 的入口页面，静态资源来自 github.com/swaggo/files/v2 内嵌的 swagger-ui dist。
+var swaggerIndex = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" type="text/css" href="./swagger-ui.css">
+  <link rel="stylesheet" type="text/css" href="./index.css">
+  <link rel="icon" type="image/png" href="./favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="./swagger-ui-bundle.js" charset="UTF-8"></script>
+  <script src="./swagger-ui-standalone-preset.js" charset="UTF-8"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({
+        url: {{.SpecURL}},
+        dom_id: "#swagger-ui",
+        deepLinking: true,
+        presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>
+`))
+
+// SwaggerPlugin 提供 OpenAPI 3 文档 (<path>/openapi.json)、错误码目录 (<path>/errors.json) 和内嵌的 Swagger UI (<path>/)。
+// 文档由 openapi.Default() 中声明的路由生成，请求和响应的 schema 来自 dto 结构体的 json 和 binding 标签；
+// 每次请求时重新生成，因此包含插件之后注册的业务路由。
+// Start 时按 check 配置检查已注册的 Gin 路由是否都有文档。
+type SwaggerPlugin struct {
+	swaggerCfg *conf.SwaggerConfig
+	logger     *zap.Logger
+	info       openapi.Info
+	checked    *pathmatch.Matcher // 需要检查文档的路由 (排除 checkExclude)
+	engine     *gin.Engine
+	assets     http.Handler
+}
+
+// NewSwaggerPlugin 创建一个新的 SwaggerPlugin 实例。
+func NewSwaggerPlugin() Plugin {
+	return &SwaggerPlugin{}
+}
+
+// Init 读取文档信息并编译检查时排除的路由模式。
+func (p *SwaggerPlugin) Init(cfg interface{}, deps Deps) error {
+	swaggerCfg, ok := cfg.(*conf.SwaggerConfig)
+	if !ok {
+		return fmt.Errorf("swagger plugin init failed: expected config type *conf.SwaggerConfig, but got %T", cfg)
+	}
+	p.swaggerCfg = swaggerCfg
+	p.logger = deps.Logger()
+	if p.swaggerCfg.Path == "" {
+		p.swaggerCfg.Path = "/swagger"
+	}
+	p.swaggerCfg.Path = strings.TrimSuffix(p.swaggerCfg.Path, "/")
+
+	p.info = openapi.Info{Title: p.swaggerCfg.Title, Version: p.swaggerCfg.Version, Description: p.swaggerCfg.Description}
+	if appCfg, ok := deps.Config(); ok && p.info.Title == "" {
+		p.info.Title = appCfg.App.Name
+	}
+	if p.info.Version == "" {
+		p.info.Version = buildinfo.Version
+	}
+
+	checked, err := pathmatch.NewMatcher(nil, p.swaggerCfg.CheckExclude)
+	if err != nil {
+		return fmt.Errorf("swagger plugin init failed: %w", err)
+	}
+	p.checked = checked
+	p.assets = http.StripPrefix(p.swaggerCfg.Path, http.FileServer(http.FS(swaggerFiles.FS)))
+
+	p.logger.Info("Swagger Plugin initialized", zap.String("path", p.swaggerCfg.Path), zap.String("check", p.swaggerCfg.Check))
+	return nil
+}
+
+// Register 注册 GET <path>/*file，提供 Swagger UI 页面、静态资源和 OpenAPI 文档。
+func (p *SwaggerPlugin) Register(r *gin.Engine) error {
+	p.engine = r
+	r.GET(p.swaggerCfg.Path+"/*file", p.serve)
+	p.logger.Info("Swagger UI registered", zap.String("path", p.swaggerCfg.Path+"/"))
+	return nil
+}
+
+// Start 检查 Register 之后注册的全部路由是否都有文档，check 为 fail 且存在缺失时返回错误。
+func (p *SwaggerPlugin) Start(ctx context.Context) error {
+	if p.swaggerCfg.Check == SwaggerCheckOff || p.engine == nil {
+		return nil
+	}
+	missing := p.Missing(p.engine.Routes())
+	if len(missing) == 0 {
+		return nil
+	}
+	if p.swaggerCfg.Check == SwaggerCheckFail {
+		return fmt.Errorf("swagger plugin start failed: routes without OpenAPI spec: %s", strings.Join(missing, ", "))
+	}
+	p.logger.Warn("Routes without OpenAPI spec", zap.Strings("routes", missing))
+	return nil
+}
+
+// Document 生成当前的 OpenAPI 文档。
+func (p *SwaggerPlugin) Document() *openapi.Document {
+	return openapi.Default().Build(p.info)
+}
+
+// Missing 返回没有文档的路由 ("<METHOD> <path>")，不包括插件自己的路由和 checkExclude 中的路由。
+func (p *SwaggerPlugin) Missing(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, p.swaggerCfg.Path+"/") || !p.checked.Match(route.Path) {
+			continue
+		}
+		if !openapi.Default().Has(route.Method, route.Path) {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	return missing
+}
+
+// serve 按文件名分发: "/" 或 "/index.html" 为 UI 页面，"/openapi.json" 为文档，"/errors.json" 为错误码目录，其余为 swagger-ui 静态资源。
+func (p *SwaggerPlugin) serve(c *gin.Context) {
+	switch c.Param("file") {
+	case "/", "/index.html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		err := swaggerIndex.Execute(c.Writer, map[string]string{
+			"Title":   p.info.Title,
+			"SpecURL": p.swaggerCfg.Path + "/" + swaggerSpecFile,
+		})
+		if err != nil {
+			p.logger.Error("Failed to render Swagger UI", zap.Error(err))
+		}
+	case "/" + swaggerSpecFile:
+		c.JSON(http.StatusOK, p.Document())
+	case "/" + swaggerErrorsFile:
+		c.JSON(http.StatusOK, errs.Catalog())
+	default:
+		p.assets.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,305 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"myGin/internal/conf"
+	"myGin/internal/pkg/requestid"
+	"myGin/internal/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 链路追踪导出器，对应配置 modules.tracing.exporter。
+const (
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// ginLoggerKey 是请求级 logger 在 gin.Context 中的键，与 bootstrap.ContextKeyLogger 相同。
+const ginLoggerKey = "logger"
+
+// tracingSpanKey 是 GORM 语句中保存当前 span 的 key。
+const tracingSpanKey = "tracing:span"
+
+func init() {
+	// 服务端 span 需要包住其他插件的中间件，认证和限流中的 Redis 调用才会成为它的子 span
+	Register("tracing", NewTracingPlugin,
+		WithConfig(func(c *conf.Config) (interface{}, bool) {
+			return &c.Modules.Tracing, c.Modules.Tracing.Enable
+		}),
+		Before("metrics", "auth", "ratelimit"),
+	)
+}
+
+// TracingPlugin 使用 OpenTelemetry 记录链路: 每个请求一个服务端 span (沿用入站的 W3C traceparent，
+// 并在响应头中写回)，GORM 语句和 Redis 命令作为它的子 span。
+// 请求级 logger 会带上 trace_id 和 span_id，service 层可以通过 tracing.LogFields 获取同样的字段。
+// 插件同时设置 otel 的全局 TracerProvider 和 propagator，tracing.Start 创建的 span 也会被导出。
+type TracingPlugin struct {
+	tracingCfg *conf.TracingConfig
+	logger     *zap.Logger
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	file       *os.File // file 导出器写入的文件，其他导出器为 nil
+}
+
+// NewTracingPlugin 创建一个新的 TracingPlugin 实例。
+func NewTracingPlugin() Plugin {
+	return &TracingPlugin{}
+}
+
+// Init 创建导出器和 TracerProvider，并挂载 GORM 回调和 Redis 钩子 (对应依赖存在时)。
+func (p *TracingPlugin) Init(cfg interface{}, deps Deps) error {
+	tracingCfg, ok := cfg.(*conf.TracingConfig)
+	if !ok {
+		return fmt.Errorf("tracing plugin init failed: expected config type *conf.TracingConfig, but got %T", cfg)
+	}
+	p.tracingCfg = tracingCfg
+	p.logger = deps.Logger()
+
+	exporter, err := p.newExporter(deps)
+	if err != nil {
+		return fmt.Errorf("tracing plugin init failed: %w", err)
+	}
+
+	serviceName := p.tracingCfg.ServiceName
+	if appCfg, ok := deps.Config(); ok && serviceName == "" {
+		serviceName = appCfg.App.Name
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.tracingCfg.SampleRatio))),
+	}
+	if p.tracingCfg.Exporter == TracingExporterOTLP {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	} else {
+		// 本地调试时立即输出，不等待批量发送
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	}
+	p.provider = sdktrace.NewTracerProvider(opts...)
+	p.tracer = p.provider.Tracer(tracing.InstrumentationName)
+	p.propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTracerProvider(p.provider)
+	otel.SetTextMapPropagator(p.propagator)
+
+	if db, ok := deps.DB(); ok {
+		if err := instrumentDB(db, "tracing", p.startDBSpan, p.endDBSpan); err != nil {
+			return fmt.Errorf("tracing plugin init failed: %w", err)
+		}
+	}
+	if rdb, ok := deps.Redis(); ok {
+		rdb.AddHook(redisTracingHook{tracer: p.tracer})
+	}
+
+	p.logger.Info("Tracing Plugin initialized",
+		zap.String("serviceName", serviceName),
+		zap.String("exporter", p.tracingCfg.Exporter),
+		zap.Float64("sampleRatio", p.tracingCfg.SampleRatio))
+	return nil
+}
+
+// newExporter 根据配置创建 span 导出器，deps 中注入了 DepSpanExporter 时直接使用它。
+func (p *TracingPlugin) newExporter(deps Deps) (sdktrace.SpanExporter, error) {
+	if deps.Has(DepSpanExporter) {
+		return Dep[sdktrace.SpanExporter](deps, DepSpanExporter)
+	}
+	switch p.tracingCfg.Exporter {
+	case TracingExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case TracingExporterFile:
+		f, err := os.OpenFile(p.tracingCfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		p.file = f
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(p.tracingCfg.Endpoint)}
+		if p.tracingCfg.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(p.tracingCfg.URLPath))
+		}
+		if p.tracingCfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(p.tracingCfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(p.tracingCfg.Headers))
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	}
+}
+
+// Register 不注册路由。
+func (p *TracingPlugin) Register(r *gin.Engine) error {
+	return nil
+}
+
+// Stop 导出缓冲中剩余的 span 并关闭导出器。
+func (p *TracingPlugin) Stop(ctx context.Context) error {
+	err := p.provider.Shutdown(ctx)
+	if p.file != nil {
+		err = errors.Join(err, p.file.Close())
+	}
+	return err
+}
+
+// Middleware 返回为每个请求创建服务端 span 的中间件。
+// span 名称为 "<METHOD> <路由模板>"，并带有路由模板、状态码等属性；状态码为 5xx 时 span 标记为失败。
+func (p *TracingPlugin) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := p.propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := p.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			))
+		defer span.End()
+		if route != "" {
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		if id := requestid.FromGin(c); id != "" {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		p.propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+		c.Set(ginLoggerKey, p.requestLogger(c).With(tracing.LogFields(ctx)...))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if err := c.Errors.Last(); err != nil {
+			span.RecordError(err.Err)
+		}
+	}
+}
+
+// requestLogger 返回上下文中的请求级 logger (带 request_id)，不存在时返回插件的 logger。
+func (p *TracingPlugin) requestLogger(c *gin.Context) *zap.Logger {
+	if v, ok := c.Get(ginLoggerKey); ok {
+		if l, ok := v.(*zap.Logger); ok {
+			return l
+		}
+	}
+	return p.logger
+}
+
+// loggerFrom 返回请求级 logger，供其他插件的中间件记录请求相关的日志，与 bootstrap.LoggerFromContext 相同 (plugin 包不能依赖 bootstrap)。
+// 请求级 logger 带有 request_id，tracing 插件启用时还带有 trace_id / span_id；上下文中没有时返回附加了 trace 字段的 fallback。
+func loggerFrom(c *gin.Context, fallback *zap.Logger) *zap.Logger {
+	if v, ok := c.Get(ginLoggerKey); ok {
+		if l, ok := v.(*zap.Logger); ok {
+			return l
+		}
+	}
+	return fallback.With(tracing.LogFields(c.Request.Context())...)
+}
+
+// startDBSpan 为 GORM 语句创建子 span。只有通过 db.WithContext(ctx) 传入请求 context 时才能关联到请求的链路。
+func (p *TracingPlugin) startDBSpan(tx *gorm.DB, operation string) {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := p.tracer.Start(ctx, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient))
+	tx.Statement.Context = ctx
+	tx.InstanceSet(tracingSpanKey, span)
+}
+
+// endDBSpan 记录 SQL (不含参数值)、表名和影响行数后结束 span。
+func (p *TracingPlugin) endDBSpan(tx *gorm.DB, operation string) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(
+		semconv.DBSystemKey.String(tx.Dialector.Name()),
+		semconv.DBOperationName(operation),
+		semconv.DBCollectionName(tx.Statement.Table),
+		semconv.DBQueryText(tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	err := tx.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// redisTracingHook 是为 Redis 命令创建子 span 的 go-redis 钩子。
+// 命令参数可能包含敏感数据，span 中只记录命令名。
+type redisTracingHook struct {
+	tracer trace.Tracer
+}
+
+// DialHook 不记录连接建立。
+func (h redisTracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 为单个命令创建 span。
+func (h redisTracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := strings.ToLower(cmd.Name())
+		ctx, span := h.tracer.Start(ctx, "redis."+name, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(name)))
+		err := next(ctx, cmd)
+		tracing.End(span, redisError(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook 为整个流水线 (包括 MULTI/EXEC 事务) 创建一个 span。
+func (h redisTracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, attribute.Int("db.operation.batch.size", len(cmds))))
+		err := next(ctx, cmds)
+		tracing.End(span, redisError(err))
+		return err
+	}
+}
+
+// redisError 返回需要记录的错误，redis.Nil (key 不存在) 不视为失败。
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -9,9 +9,12 @@ import (
 
 	// "myGin/internal/conf" // 移除了未使用的导入
 	"myGin/internal/dto"
+	"myGin/internal/pkg/requestid"
 	"myGin/internal/pkg/tongchengapi" // 更新了导入路径
+	"myGin/internal/pkg/tracing"
 
 	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -62,6 +65,18 @@ func NewFlightService(logger *zap.Logger, apiClient TongchengAPIClient /*, cfg *
 	}
 }
 
+// loggerFor 返回带有请求 ID 和链路 ID (启用 tracing 插件时) 的 logger，便于将 service 日志与访问日志和链路关联。
+func (s *flightService) loggerFor(ctx context.Context) *zap.Logger {
+	logger := s.logger
+	if id := requestid.FromContext(ctx); id != "" {
+		logger = logger.With(zap.String("request_id", id))
+	}
+	if fields := tracing.LogFields(ctx); fields != nil {
+		logger = logger.With(fields...)
+	}
+	return logger
+}
+
 // 从 context 获取令牌的辅助函数
 func getAuthTokensFromContext(ctx context.Context) (userID, tcSecToken, secToken, deviceID string, err error) {
 	userIDVal := ctx.Value(CtxKeyTcUserID)
@@ -104,18 +119,19 @@ func getAuthTokensFromContext(ctx context.Context) (userID, tcSecToken, secToken
 
 // Search 通过调用现有的 api.Get_airline_message 实现航班搜索逻辑。
 func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.SearchResult, error) {
-	s.logger.Info("Search request received", zap.Any("options", opt))
+	logger := s.loggerFor(ctx)
+	logger.Info("Search request received", zap.Any("options", opt))
 
 	// --- 获取认证令牌 ---
 	userID, tcSecToken, _, _, err := getAuthTokensFromContext(ctx)
 	if err != nil {
 		// 仅检查 Search 所需的基本令牌
 		if strings.Contains(err.Error(), string(CtxKeyTcUserID)) || strings.Contains(err.Error(), string(CtxKeyTcSecToken)) {
-			s.logger.Error("Authentication tokens missing for flight search", zap.Error(err))
+			logger.Error("Authentication tokens missing for flight search", zap.Error(err))
 			return nil, fmt.Errorf("authentication required for flight search: %w", err)
 		}
 		// 如果需要，将其他缺失的令牌记录为警告，但继续进行 Search
-		s.logger.Warn("Non-essential auth tokens missing, proceeding with search", zap.Error(err))
+		logger.Warn("Non-essential auth tokens missing, proceeding with search", zap.Error(err))
 	}
 
 	// --- 1. 为 api.Get_airline_message 准备 api.Options ---
@@ -124,21 +140,23 @@ func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.
 	apiOpts.Set("tcsectoken", tcSecToken)
 
 	// --- 2. 通过注入的客户端调用 API 函数 ---
+	_, span := tracing.Start(ctx, "tongcheng.Get_airline_message", trace.WithSpanKind(trace.SpanKindClient))
 	resultJson, err := s.apiClient.Get_airline_message(&apiOpts) // 传递指针
+	tracing.End(span, err)
 	if err != nil {
-		s.logger.Error("apiClient.Get_airline_message call failed", zap.Error(err))
+		logger.Error("apiClient.Get_airline_message call failed", zap.Error(err))
 		return nil, fmt.Errorf("flight search API call failed: %w", err) // 包装错误
 	}
 	if resultJson == "" {
-		s.logger.Warn("api.Get_airline_message returned empty result")
+		logger.Warn("api.Get_airline_message returned empty result")
 		return &dto.SearchResult{Flights: []dto.FlightInfo{}, Total: 0}, nil // 返回空结果集
 	}
 
-	s.logger.Debug("api.Get_airline_message raw result", zap.String("json", resultJson))
+	logger.Debug("api.Get_airline_message raw result", zap.String("json", resultJson))
 
 	// --- 3. 解析 JSON 结果并映射到 DTO ---
 	if !gjson.Valid(resultJson) {
-		s.logger.Error("Invalid JSON received from flight search API", zap.String("result", resultJson))
+		logger.Error("Invalid JSON received from flight search API", zap.String("result", resultJson))
 		return nil, fmt.Errorf("invalid response format from flight search API")
 	}
 	jsonParse := gjson.Parse(resultJson)
@@ -151,7 +169,7 @@ func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.
 		if errMsg == "" {
 			errMsg = "Unknown API error" // 如果路径缺失则使用默认消息
 		}
-		s.logger.Error("Flight search API indicated failure", zap.String("message", errMsg), zap.String("rawResponse", resultJson))
+		logger.Error("Flight search API indicated failure", zap.String("message", errMsg), zap.String("rawResponse", resultJson))
 		return nil, fmt.Errorf("flight search failed: %s", errMsg)
 	}
 
@@ -162,7 +180,7 @@ func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.
 	// 提取航班列表 - 已验证路径：'data.fl'（注意：原始注释中为 'data.fls'）
 	flightsArray := jsonParse.Get("data.fl")
 	if !flightsArray.Exists() || !flightsArray.IsArray() {
-		s.logger.Warn("No flight array ('data.fl') found in API response or invalid format.")
+		logger.Warn("No flight array ('data.fl') found in API response or invalid format.")
 		return searchResult, nil // 未找到航班不视为错误
 	}
 
@@ -179,7 +197,7 @@ func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.
 		arrTime, arrErr := time.Parse(layout, arrTimeStr)
 
 		if depErr != nil || arrErr != nil {
-			s.logger.Warn("Failed to parse flight times, skipping flight",
+			logger.Warn("Failed to parse flight times, skipping flight",
 				zap.Int("index", i),
 				zap.String("flightNum", flightJson.Get("fn").String()), // fn 路径已验证
 				zap.String("depTimeStr", depTimeStr),
@@ -205,19 +223,20 @@ func (s *flightService) Search(ctx context.Context, opt dto.SearchOption) (*dto.
 		searchResult.Flights = append(searchResult.Flights, flight)
 	}
 
-	s.logger.Info("Flight search successful", zap.Int("flightsFound", len(searchResult.Flights)), zap.Int("totalReported", searchResult.Total))
+	logger.Info("Flight search successful", zap.Int("flightsFound", len(searchResult.Flights)), zap.Int("totalReported", searchResult.Total))
 	return searchResult, nil
 }
 
 // CreateOrder 通过调用 api.CreateOrder 实现航班订单创建逻辑
 func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (*dto.OrderResponse, error) {
-	s.logger.Info("CreateOrder request received", zap.Any("request", req))
+	logger := s.loggerFor(ctx)
+	logger.Info("CreateOrder request received", zap.Any("request", req))
 
 	// --- 获取认证令牌 ---
 	userID, tcSecToken, secToken, deviceID, err := getAuthTokensFromContext(ctx)
 	if err != nil {
 		// 根据 requtst.go 分析，CreateOrder 流程可能需要所有令牌
-		s.logger.Error("Authentication or session tokens missing for order creation", zap.Error(err))
+		logger.Error("Authentication or session tokens missing for order creation", zap.Error(err))
 		// 检查具体缺少哪些令牌以提供更精确的错误
 		var requiredMissing []string
 		if userID == "" {
@@ -237,7 +256,7 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 			return nil, fmt.Errorf("missing required tokens for order creation: %s", strings.Join(requiredMissing, ", "))
 		}
 		// 如果 err 不为 nil 但令牌似乎存在，则记录为警告
-		s.logger.Warn("Error retrieving tokens, but proceeding", zap.Error(err))
+		logger.Warn("Error retrieving tokens, but proceeding", zap.Error(err))
 	}
 
 	// --- 1. 为 api.CreateOrder 准备 Options
@@ -267,12 +286,12 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 	var accumulatedErrors []string // 存储错误用于日志记录/摘要
 
 	if len(req.Passengers) == 0 {
-		s.logger.Error("No passengers provided in the request for CreateOrder")
+		logger.Error("No passengers provided in the request for CreateOrder")
 		return nil, fmt.Errorf("at least one passenger is required to create an order")
 	}
 
 	for i, p := range req.Passengers {
-		s.logger.Info("Processing passenger", zap.Int("index", i), zap.String("name", p.Name))
+		logger.Info("Processing passenger", zap.Int("index", i), zap.String("name", p.Name))
 
 		// 为每个乘客创建一个 *新的* api.Options 以避免覆盖
 		passengerApiOpts := tongchengapi.NewOptions()
@@ -303,7 +322,7 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 		// logOpts := passengerApiOpts.Clone()
 		// logOpts.Delete("tcsectoken")
 		// logOpts.Delete("sec_token")
-		// s.logger.Debug("Calling api.CreateOrder for passenger", zap.String("passengerName", p.Name), zap.Any("apiOptions", logOpts))
+		// logger.Debug("Calling api.CreateOrder for passenger", zap.String("passengerName", p.Name), zap.Any("apiOptions", logOpts))
 
 		// --- 通过注入的客户端为当前乘客调用 API ---
 		resultJson, err := s.apiClient.CreateOrder(&passengerApiOpts) // 传递指针
@@ -312,19 +331,19 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 		}
 
 		if err != nil {
-			s.logger.Error("api.CreateOrder call failed for passenger", zap.String("passengerName", p.Name), zap.Error(err))
+			logger.Error("api.CreateOrder call failed for passenger", zap.String("passengerName", p.Name), zap.Error(err))
 			passengerResult.Success = false
 			passengerResult.ErrorMessage = fmt.Sprintf("API call failed: %v", err)
 			accumulatedErrors = append(accumulatedErrors, fmt.Sprintf("%s: %s", p.Name, passengerResult.ErrorMessage))
 		} else if resultJson == "" {
-			s.logger.Warn("api.CreateOrder returned empty result for passenger", zap.String("passengerName", p.Name))
+			logger.Warn("api.CreateOrder returned empty result for passenger", zap.String("passengerName", p.Name))
 			passengerResult.Success = false
 			passengerResult.ErrorMessage = "API returned empty response"
 			accumulatedErrors = append(accumulatedErrors, fmt.Sprintf("%s: %s", p.Name, passengerResult.ErrorMessage))
 		} else {
-			s.logger.Debug("api.CreateOrder raw result for passenger", zap.String("passengerName", p.Name), zap.String("json", resultJson))
+			logger.Debug("api.CreateOrder raw result for passenger", zap.String("passengerName", p.Name), zap.String("json", resultJson))
 			if !gjson.Valid(resultJson) {
-				s.logger.Error("Invalid JSON received from create order API for passenger", zap.String("passengerName", p.Name), zap.String("result", resultJson))
+				logger.Error("Invalid JSON received from create order API for passenger", zap.String("passengerName", p.Name), zap.String("result", resultJson))
 				passengerResult.Success = false
 				passengerResult.ErrorMessage = "Invalid API response format"
 				accumulatedErrors = append(accumulatedErrors, fmt.Sprintf("%s: %s", p.Name, passengerResult.ErrorMessage))
@@ -335,7 +354,7 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 					// 尝试解析 OrderID - 路径需要验证
 					passengerResult.OrderID = jsonParse.Get("data.OrderInfo.OrderId").String()
 					successfulOrders++
-					s.logger.Info("Order creation successful for passenger", zap.String("passengerName", p.Name), zap.String("orderId", passengerResult.OrderID))
+					logger.Info("Order creation successful for passenger", zap.String("passengerName", p.Name), zap.String("orderId", passengerResult.OrderID))
 				} else {
 					passengerResult.Success = false
 					// 尝试获取错误消息
@@ -346,7 +365,7 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 					if passengerResult.ErrorMessage == "" {
 						passengerResult.ErrorMessage = "API reported failure (unknown reason)"
 					}
-					s.logger.Error("Order creation API reported failure for passenger",
+					logger.Error("Order creation API reported failure for passenger",
 						zap.String("passengerName", p.Name),
 						zap.String("message", passengerResult.ErrorMessage),
 						zap.String("rawResponse", resultJson))
@@ -370,7 +389,7 @@ func (s *flightService) CreateOrder(ctx context.Context, req dto.OrderRequest) (
 		// return nil, fmt.Errorf("未能为部分乘客创建订单：%s", strings.Join(accumulatedErrors, "; "))
 	}
 
-	s.logger.Info("Finished processing CreateOrder request",
+	logger.Info("Finished processing CreateOrder request",
 		zap.Int("totalPassengers", len(req.Passengers)),
 		zap.Int("successfulOrders", successfulOrders),
 		zap.Bool("overallSuccess", orderResponse.Success))
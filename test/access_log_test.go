@@ -0,0 +1,136 @@
+package main_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+)
+
+// setupAccessLogServer 创建挂载了 ZapLogger 的测试引擎，并返回日志观察器。
+// ctxLogger 会在 /users/:id 处理函数中被赋值为上下文里的请求级 logger。
+func setupAccessLogServer(cfg conf.AccessLogConfig, ctxLogger *interface{}) (*gin.Engine, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(bootstrap.ZapLogger(zap.New(core), cfg))
+	router.GET("/users/:id", func(c *gin.Context) {
+		if ctxLogger != nil {
+			*ctxLogger, _ = c.Get("logger")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	router.GET("/boom", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	return router, logs
+}
+
+func TestZapLogger_LogsRequestFields(t *testing.T) {
+	var ctxLogger interface{}
+	router, logs := setupAccessLogServer(conf.AccessLogConfig{}, &ctxLogger)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?x=1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	requestID := w.Header().Get(bootstrap.RequestIDHeader)
+	assert.Len(t, requestID, 32, "应生成 32 位十六进制请求 ID")
+	assert.IsType(t, &zap.Logger{}, ctxLogger, "上下文中应存在请求级 logger")
+
+	entries := logs.FilterMessage("HTTP request").All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, requestID, fields["request_id"])
+		assert.Equal(t, int64(http.StatusOK), fields["status"])
+		assert.Equal(t, "/users/42", fields["path"])
+		assert.Equal(t, "/users/:id", fields["route"])
+		assert.Equal(t, "test-agent", fields["user_agent"])
+		assert.Equal(t, int64(2), fields["bytes"])
+		assert.Contains(t, fields, "latency")
+	}
+}
+
+func TestZapLogger_PropagatesInboundRequestID(t *testing.T) {
+	router, logs := setupAccessLogServer(conf.AccessLogConfig{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(bootstrap.RequestIDHeader, "upstream-id-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "upstream-id-123", w.Header().Get(bootstrap.RequestIDHeader))
+	assert.Equal(t, "upstream-id-123", logs.All()[0].ContextMap()["request_id"])
+}
+
+func TestZapLogger_SkipPathsAndSampling(t *testing.T) {
+	router, logs := setupAccessLogServer(conf.AccessLogConfig{
+		SkipPaths: []string{"/livez"},
+		Sampling: []conf.SamplingRule{
+			{Route: "/ping", Rate: 0},
+			{Route: "/fail", Rate: 0},
+		},
+	}, nil)
+
+	for _, path := range []string{"/livez", "/ping", "/fail"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.FilterMessage("HTTP request").All()
+	if assert.Len(t, entries, 1, "跳过路径和采样率为 0 的成功请求不应记录，错误请求总是记录") {
+		assert.Equal(t, "/fail", entries[0].ContextMap()["path"])
+	}
+}
+
+func TestZapLogger_LevelByStatus(t *testing.T) {
+	router, logs := setupAccessLogServer(conf.AccessLogConfig{}, nil)
+
+	for _, path := range []string{"/users/1", "/fail", "/boom"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.FilterMessage("HTTP request").All()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+		assert.Equal(t, zapcore.WarnLevel, entries[1].Level, "4xx 使用 Warn")
+		assert.Equal(t, zapcore.ErrorLevel, entries[2].Level, "5xx 使用 Error")
+		assert.Empty(t, entries[2].Stack, "访问日志不附带堆栈")
+	}
+}
+
+func TestErrorLogger_LogsOnlyAttachedErrors(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(bootstrap.ZapLogger(zap.New(core), conf.AccessLogConfig{}), bootstrap.ErrorLoggerMiddleware(zap.New(core)))
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	router.GET("/upstream", func(c *gin.Context) {
+		_ = c.Error(errors.New("upstream timeout"))
+		c.Status(http.StatusBadGateway)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, 1, logs.Len(), "没有附加错误的失败请求只记录访问日志")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/upstream", nil))
+	entries := logs.FilterMessage("Request completed with errors").All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "Error #1: upstream timeout", fields["errors"])
+		assert.Equal(t, w.Header().Get(bootstrap.RequestIDHeader), fields["request_id"], "通过 request_id 与访问日志关联")
+		assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	}
+}
@@ -0,0 +1,128 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/buildinfo"
+	"myGin/internal/plugin"
+)
+
+const adminToken = "admin-test-token"
+
+// newAdminServer 创建管理端口: 主服务注册 GET /items/:id，插件 store 正常加载并启动，cache 因缺少 Redis 加载失败。
+func newAdminServer(t *testing.T) *bootstrap.AdminServer {
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	var events []string
+	reg := plugin.NewRegistry()
+	reg.Register("store", recording("store", &events))
+	reg.Register("cache", recording("cache", &events), plugin.Requires(plugin.DepRedis))
+
+	cfg := validConfig()
+	cfg.Admin = conf.AdminConfig{Enable: true, Addr: "127.0.0.1:0", Token: adminToken, Pprof: true}
+	engine := gin.New()
+	m := bootstrap.NewPluginManager(reg)
+	require.NoError(t, m.Attach(engine, cfg, plugin.Deps{}))
+	require.NoError(t, m.Start(context.Background()))
+	engine.GET("/items/:id", func(c *gin.Context) {})
+
+	admin, err := bootstrap.NewAdminServer(cfg, engine, m)
+	require.NoError(t, err)
+	return admin
+}
+
+// adminRequest 以指定 token 请求管理端口，token 为空时不带 Authorization 头。
+func adminRequest(admin *bootstrap.AdminServer, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	admin.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	admin := newAdminServer(t)
+
+	for _, path := range []string{"/admin/routes", "/debug/vars", "/debug/pprof/cmdline"} {
+		assert.Equal(t, http.StatusUnauthorized, adminRequest(admin, http.MethodGet, path, "", "").Code, path)
+		assert.Equal(t, http.StatusUnauthorized, adminRequest(admin, http.MethodGet, path, "wrong", "").Code, path)
+		assert.Equal(t, http.StatusOK, adminRequest(admin, http.MethodGet, path, adminToken, "").Code, path)
+	}
+
+	_, err := bootstrap.NewAdminServer(validConfig(), gin.New(), nil)
+	assert.EqualError(t, err, "admin token is required")
+}
+
+func TestAdmin_IntrospectionEndpoints(t *testing.T) {
+	admin := newAdminServer(t)
+
+	w := adminRequest(admin, http.MethodGet, "/admin/routes", adminToken, "")
+	var routes []bootstrap.AdminRoute
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &routes))
+	require.Len(t, routes, 1)
+	assert.Equal(t, "GET", routes[0].Method)
+	assert.Equal(t, "/items/:id", routes[0].Path)
+	assert.Contains(t, routes[0].Handler, "newAdminServer")
+
+	w = adminRequest(admin, http.MethodGet, "/admin/plugins", adminToken, "")
+	var plugins []bootstrap.PluginStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plugins))
+	require.Len(t, plugins, 2)
+	assert.Equal(t, "store", plugins[0].Name)
+	assert.Equal(t, bootstrap.PluginStatusRunning, plugins[0].Status)
+	assert.Equal(t, "cache", plugins[1].Name)
+	assert.Equal(t, bootstrap.PluginStatusFailed, plugins[1].Status)
+	assert.Contains(t, plugins[1].Error, `requires dependency "redis"`)
+
+	w = adminRequest(admin, http.MethodGet, "/admin/config", adminToken, "")
+	var cfg map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+	assert.Equal(t, "******", cfg["admin"]["token"], "token 已脱敏")
+	assert.Equal(t, ":8080", cfg["server"]["addr"])
+
+	w = adminRequest(admin, http.MethodGet, "/admin/buildinfo", adminToken, "")
+	var info buildinfo.Info
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, buildinfo.Version, info.Version)
+	assert.NotEmpty(t, info.GoVersion)
+
+	w = adminRequest(admin, http.MethodGet, "/debug/vars", adminToken, "")
+	assert.Contains(t, w.Body.String(), `"buildinfo"`)
+	assert.Contains(t, w.Body.String(), `"memstats"`)
+
+	w = adminRequest(admin, http.MethodGet, "/debug/pprof/", adminToken, "")
+	assert.Contains(t, w.Body.String(), "goroutine")
+}
+
+func TestAdmin_LogLevelSwitch(t *testing.T) {
+	admin := newAdminServer(t)
+	t.Cleanup(func() { _ = bootstrap.SetLogLevel("fatal") })
+
+	w := adminRequest(admin, http.MethodGet, "/admin/loglevel", adminToken, "")
+	assert.JSONEq(t, `{"level":"fatal"}`, w.Body.String())
+
+	w = adminRequest(admin, http.MethodPut, "/admin/loglevel", adminToken, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	assert.Equal(t, "debug", bootstrap.LogLevel().String())
+
+	w = adminRequest(admin, http.MethodPut, "/admin/loglevel", adminToken, `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "debug", bootstrap.LogLevel().String(), "无效级别不改变当前级别")
+}
@@ -0,0 +1,195 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"myGin/internal/conf"
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/plugin"
+)
+
+const (
+	expiredAPIKey = "mgk_00000000000000e1_expired"
+	officeAPIKey  = "mgk_00000000000000c1_office"
+)
+
+// newAPIKeyEngine 启用 API Key: alice 拥有 apikeys:manage 管理权限，配置中预置一个已过期的 Key 和一个只允许 10.0.0.0/8 的 Key。
+func newAPIKeyEngine(t *testing.T) *gin.Engine {
+	engine := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.Users[0].Scopes = []string{"apikeys:manage"}
+		a.APIKeys = conf.APIKeyConfig{
+			Enable: true, Header: "X-API-Key", Prefix: "mgk", Store: "memory", AdminPermission: "apikeys:manage",
+			Keys: []conf.APIKeyEntryConfig{
+				{ID: "00000000000000e1", Name: "old", Hash: plugin.HashAPIKey(expiredAPIKey), ExpiresAt: "2020-01-01T00:00:00Z"},
+				{ID: "00000000000000c1", Name: "office", Hash: plugin.HashAPIKey(officeAPIKey), AllowedCIDRs: []string{"10.0.0.0/8"}},
+			},
+		}
+	})
+	engine.GET("/api/v1/whoami", func(c *gin.Context) {
+		claims, _ := plugin.ClaimsFrom(c)
+		c.JSON(http.StatusOK, gin.H{"username": claims.Username, "type": claims.TokenType, "subject": claims.Subject})
+	})
+	engine.GET("/api/v1/flights/search", plugin.RequirePermission("flights:search"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+// getWithAPIKey 使用 X-API-Key 头请求。
+func getWithAPIKey(engine *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// issueKey 以 admin 身份签发 API Key。
+func issueKey(t *testing.T, engine *gin.Engine, admin string, req dto.CreateAPIKeyRequest) dto.APIKeyResponse {
+	w := postJSON(engine, "/auth/apikeys", admin, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp dto.APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Key)
+	return resp
+}
+
+func TestAPIKey_IssueUseAndRevoke(t *testing.T) {
+	engine := newAPIKeyEngine(t)
+	admin := loginAs(t, engine, "alice", "secret123")
+
+	issued := issueKey(t, engine, admin, dto.CreateAPIKeyRequest{Name: "billing", Scopes: []string{"flights:search"}})
+	assert.Regexp(t, `^mgk_[0-9a-f]{16}_[0-9a-f]{64}$`, issued.Key)
+
+	// X-API-Key 和 Bearer 两种方式写入相同的 claims
+	w := getWithAPIKey(engine, "/api/v1/whoami", issued.Key)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"username":"apikey:billing","type":"api_key","subject":"apikey:`+issued.ID+`"}`, w.Body.String())
+	assert.Equal(t, http.StatusOK, getWithToken(engine, "/api/v1/whoami", issued.Key))
+	assert.Equal(t, http.StatusOK, getWithAPIKey(engine, "/api/v1/flights/search", issued.Key).Code, "Key 自带的 scope")
+
+	limited := issueKey(t, engine, admin, dto.CreateAPIKeyRequest{Name: "reports"})
+	assert.Equal(t, http.StatusForbidden, getWithAPIKey(engine, "/api/v1/flights/search", limited.Key).Code)
+	assert.Equal(t, http.StatusForbidden, postJSON(engine, "/auth/apikeys", limited.Key, dto.CreateAPIKeyRequest{Name: "x"}).Code,
+		"没有 apikeys:manage 的 Key 不能签发新 Key")
+
+	// 列表不包含明文并带有最近使用时间
+	req := httptest.NewRequest(http.MethodGet, "/auth/apikeys", nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var keys []dto.APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	byID := map[string]dto.APIKeyResponse{}
+	for _, k := range keys {
+		assert.Empty(t, k.Key)
+		byID[k.ID] = k
+	}
+	require.Contains(t, byID, issued.ID)
+	assert.NotNil(t, byID[issued.ID].LastUsedAt)
+
+	// 吊销后立即失效
+	req = httptest.NewRequest(http.MethodDelete, "/auth/apikeys/"+issued.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, getWithAPIKey(engine, "/api/v1/whoami", issued.Key).Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/auth/apikeys/unknown", nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIKey_ConfiguredKeysRejected(t *testing.T) {
+	engine := newAPIKeyEngine(t)
+
+	assert.Equal(t, http.StatusUnauthorized, getWithAPIKey(engine, "/api/v1/whoami", expiredAPIKey).Code, "已过期")
+	assert.Equal(t, http.StatusForbidden, getWithAPIKey(engine, "/api/v1/whoami", officeAPIKey).Code, "来源地址不在 allowedCidrs 中")
+	assert.Equal(t, http.StatusUnauthorized, getWithAPIKey(engine, "/api/v1/whoami", "mgk_00000000000000c1_wrong").Code, "哈希不匹配")
+	assert.Equal(t, http.StatusUnauthorized, getWithAPIKey(engine, "/api/v1/whoami", "not-a-key").Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/whoami", nil)
+	req.Header.Set("X-API-Key", officeAPIKey)
+	req.RemoteAddr = "10.1.2.3:4567"
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestAPIKey_FailuresUseCatalogCodes(t *testing.T) {
+	engine := newAPIKeyEngine(t)
+	cases := []struct {
+		key  string
+		want *errs.APIError
+	}{
+		{expiredAPIKey, plugin.ErrAPIKeyExpired},
+		{officeAPIKey, plugin.ErrAPIKeyIPDenied},
+		{"mgk_00000000000000c1_wrong", plugin.ErrAPIKeyInvalid},
+		{"not-a-key", plugin.ErrAPIKeyMalformed},
+	}
+	for _, tc := range cases {
+		w := getWithAPIKey(engine, "/api/v1/whoami", tc.key)
+		require.Equal(t, tc.want.HTTPStatus, w.Code, tc.key)
+		var body errs.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, tc.want.Code, body.Code, tc.key)
+		assert.Equal(t, tc.want.Message, body.Message, tc.key)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/whoami", nil)
+	req.Header.Set("X-API-Key", expiredAPIKey)
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var body errs.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "API key expired", body.Message, "按 Accept-Language 返回英文")
+}
+
+func TestGormAPIKeyStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	store := plugin.NewGormAPIKeyStore(db)
+	ctx := context.Background()
+	require.NoError(t, store.Migrate(ctx))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, store.Create(ctx, &plugin.APIKey{
+		ID: "k1", Name: "billing", Hash: plugin.HashAPIKey("mgk_k1_x"),
+		Scopes: []string{"a", "b"}, AllowedCIDRs: []string{"10.0.0.0/8"}, CreatedAt: now,
+	}))
+
+	key, err := store.Get(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, key.Scopes)
+	assert.Equal(t, []string{"10.0.0.0/8"}, key.AllowedCIDRs)
+	assert.Nil(t, key.RevokedAt)
+
+	require.NoError(t, store.Touch(ctx, "k1", now))
+	require.NoError(t, store.Revoke(ctx, "k1", now))
+	require.NoError(t, store.Revoke(ctx, "k1", now.Add(time.Hour)), "重复吊销保留首次时间")
+	key, err = store.Get(ctx, "k1")
+	require.NoError(t, err)
+	require.NotNil(t, key.LastUsedAt)
+	require.NotNil(t, key.RevokedAt)
+	assert.True(t, key.RevokedAt.Equal(now))
+
+	assert.ErrorIs(t, store.Revoke(ctx, "missing", now), plugin.ErrAPIKeyNotFound)
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, plugin.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,201 @@
+package main_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/jwks"
+	"myGin/internal/plugin"
+)
+
+// writeKeyPair 生成 alg 对应的密钥对，写入 PKCS#8 私钥和 PKIX 公钥 PEM 文件。
+func writeKeyPair(t *testing.T, alg, id string) conf.AuthKeyConfig {
+	var signer crypto.Signer
+	var err error
+	switch alg {
+	case jwks.RS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwks.ES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwks.EdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	key := conf.AuthKeyConfig{
+		ID:             id,
+		PrivateKeyFile: filepath.Join(dir, id+".pem"),
+		PublicKeyFile:  filepath.Join(dir, id+".pub.pem"),
+	}
+	require.NoError(t, os.WriteFile(key.PrivateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, os.WriteFile(key.PublicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600))
+	return key
+}
+
+// tokenKid 返回 Token 头部的 kid (不验证签名)。
+func tokenKid(t *testing.T, token string) string {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func fetchJWKS(t *testing.T, handler http.Handler) jwks.Set {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var set jwks.Set
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	return set
+}
+
+func TestAuthPlugin_AsymmetricAlgorithms(t *testing.T) {
+	for _, alg := range []string{jwks.RS256, jwks.ES256, jwks.EdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			key := writeKeyPair(t, alg, "k1")
+			key.PublicKeyFile = "" // 公钥由私钥导出
+			engine := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+				a.Secret, a.Algorithm, a.Keys = "", alg, []conf.AuthKeyConfig{key}
+			})
+
+			tokens := login(t, engine)
+			assert.Equal(t, "k1", tokenKid(t, tokens.AccessToken))
+			assert.Equal(t, http.StatusOK, getWithToken(engine, "/api/v1/me", tokens.AccessToken))
+
+			set := fetchJWKS(t, engine)
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, "k1", set.Keys[0].Kid)
+			assert.Equal(t, alg, set.Keys[0].Alg)
+		})
+	}
+}
+
+func TestAuthPlugin_KeyRotationKeepsOldTokensValid(t *testing.T) {
+	oldKey := writeKeyPair(t, jwks.ES256, "old")
+	newKey := writeKeyPair(t, jwks.ES256, "new")
+
+	before := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.Algorithm, a.Keys = jwks.ES256, []conf.AuthKeyConfig{oldKey}
+	})
+	oldTokens := login(t, before)
+
+	// 轮换: 使用新密钥签名，旧密钥只保留公钥用于验证
+	oldKey.PrivateKeyFile = ""
+	after := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.Algorithm, a.SigningKeyID = jwks.ES256, "new"
+		a.Keys = []conf.AuthKeyConfig{oldKey, newKey}
+	})
+	newTokens := login(t, after)
+	assert.Equal(t, "new", tokenKid(t, newTokens.AccessToken))
+	assert.Equal(t, http.StatusOK, getWithToken(after, "/api/v1/me", oldTokens.AccessToken), "旧密钥签发的 Token 仍然有效")
+	assert.Equal(t, http.StatusOK, getWithToken(after, "/api/v1/me", newTokens.AccessToken))
+	assert.Len(t, fetchJWKS(t, after).Keys, 2)
+}
+
+func TestAuthPlugin_VerifyModeUsesRemoteJWKS(t *testing.T) {
+	key := writeKeyPair(t, jwks.RS256, "issuer-1")
+	issuer := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.Algorithm, a.Keys = jwks.RS256, []conf.AuthKeyConfig{key}
+	})
+	remote := httptest.NewServer(issuer)
+	t.Cleanup(remote.Close)
+
+	verifier := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.Secret, a.Algorithm, a.Mode = "", jwks.RS256, plugin.AuthModeVerify
+		a.JWKSURL, a.JWKSRefresh = remote.URL+"/.well-known/jwks.json", "1m"
+	})
+
+	tokens := login(t, issuer)
+	assert.Equal(t, http.StatusOK, getWithToken(verifier, "/api/v1/me", tokens.AccessToken))
+	assert.Equal(t, http.StatusNotFound, postJSON(verifier, "/auth/login", "", nil).Code, "verify 模式不注册登录接口")
+
+	// 使用 HS256 和公开信息伪造的 Token 被拒绝 (只接受配置的算法)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, plugin.MyCustomClaims{
+		UserID: 7, TokenType: plugin.TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "test"},
+	}).SignedString([]byte("guess"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(verifier, "/api/v1/me", forged))
+}
+
+func TestRemoteJWKS_ServesCachedKeysWhileFetching(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwk, err := jwks.NewKey("k1", jwks.ES256, priv.Public())
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release // 第一次之后的获取一直阻塞，模拟缓慢的 JWKS 服务
+		}
+		_ = json.NewEncoder(w).Encode(jwks.Set{Keys: []jwks.Key{jwk}})
+	}))
+	t.Cleanup(server.Close)
+
+	remote := jwks.NewRemote(server.URL, time.Minute, nil)
+	ctx := context.Background()
+	require.NoError(t, remote.Refresh(ctx))
+
+	refreshed := make(chan error, 1)
+	go func() { refreshed <- remote.Refresh(ctx) }()
+	require.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	pub, alg, err := remote.Key(ctx, "k1")
+	require.NoError(t, err, "刷新进行中仍然返回已缓存的密钥")
+	assert.Equal(t, jwks.ES256, alg)
+	assert.Equal(t, priv.Public(), pub)
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, _, err = remote.Key(short, "rotated")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "未知 kid 等待进行中的获取，可以被 context 取消")
+	assert.ErrorIs(t, remote.Refresh(short), context.DeadlineExceeded, "并发的刷新等待同一次获取")
+
+	release <- struct{}{}
+	require.NoError(t, <-refreshed)
+	assert.Equal(t, int32(2), fetches.Load(), "同一时刻只有一次获取")
+}
+
+func TestValidateConfig_AsymmetricAuthDoesNotRequireSecret(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.Auth.Enable = true
+	cfg.Modules.Auth.Algorithm = jwks.EdDSA
+	assert.NoError(t, bootstrap.ValidateConfig(cfg))
+
+	cfg.Modules.Auth.Mode = plugin.AuthModeVerify
+	cfg.Modules.Auth.Keys = []conf.AuthKeyConfig{{ID: "k"}}
+	err := bootstrap.ValidateConfig(cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `modules.auth.jwksUrl: is required when enable is "true" and mode is "verify"`)
+		assert.Contains(t, err.Error(), "modules.auth.keys[0].publicKeyFile: is required when privateKeyFile is not set")
+	}
+}
@@ -0,0 +1,204 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/plugin"
+)
+
+// newAuthEngine 启用 auth 插件 (memory 用户存储中有用户 alice/secret123) 并注册受保护的测试路由。
+// opts 可以在加载插件前修改认证配置。
+func newAuthEngine(t *testing.T, deps plugin.Deps, opts ...func(*conf.AuthConfig)) *gin.Engine {
+	return newAuthEngineWithConfig(t, deps, func(cfg *conf.Config) {
+		for _, opt := range opts {
+			opt(&cfg.Modules.Auth)
+		}
+	})
+}
+
+// newAuthEngineWithConfig 与 newAuthEngine 相同，但可以修改完整配置 (例如同时启用其他插件)。
+func newAuthEngineWithConfig(t *testing.T, deps plugin.Deps, opt func(*conf.Config)) *gin.Engine {
+	hash, err := plugin.HashPassword("secret123")
+	require.NoError(t, err)
+
+	cfg := validConfig()
+	cfg.Modules.Auth = conf.AuthConfig{
+		Enable: true, Secret: "test-secret", Expire: 60, RefreshExpire: 600, Issuer: "test",
+		RoutePrefix: "/auth", UserStore: "memory", JWKSPath: "/.well-known/jwks.json",
+		Users:      []conf.AuthUserConfig{{ID: 7, Username: "alice", PasswordHash: hash}},
+		RouteScope: conf.RouteScope{Include: []string{"/api/**"}},
+	}
+	opt(cfg)
+
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	_, err = bootstrap.AttachPlugins(engine, cfg, deps)
+	require.NoError(t, err)
+	engine.GET("/api/v1/me", func(c *gin.Context) {
+		claims, ok := plugin.ClaimsFrom(c)
+		require.True(t, ok)
+		c.JSON(http.StatusOK, gin.H{"username": claims.Username})
+	})
+	return engine
+}
+
+// postJSON 发送 JSON 请求，token 非空时携带 Authorization 头。
+func postJSON(engine *gin.Engine, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// getWithToken 使用访问 Token 请求受保护的路由。
+func getWithToken(engine *gin.Engine, path, token string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+func login(t *testing.T, engine *gin.Engine) dto.TokenResponse {
+	w := postJSON(engine, "/auth/login", "", dto.LoginRequest{Username: "alice", Password: "secret123"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tokens dto.TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	return tokens
+}
+
+func TestAuthPlugin_Login(t *testing.T) {
+	engine := newAuthEngine(t, plugin.Deps{})
+
+	tokens := login(t, engine)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, int64(60), tokens.ExpiresIn)
+	assert.Equal(t, http.StatusOK, getWithToken(engine, "/api/v1/me", tokens.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(engine, "/api/v1/me", tokens.RefreshToken), "刷新 Token 不能用于访问接口")
+
+	w := postJSON(engine, "/auth/login", "", dto.LoginRequest{Username: "alice", Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(engine, "/auth/login", "", dto.LoginRequest{Username: "bob", Password: "secret123"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(engine, "/auth/login", "", map[string]string{"username": "alice"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthPlugin_RefreshRotatesToken(t *testing.T) {
+	engine := newAuthEngine(t, plugin.Deps{})
+	tokens := login(t, engine)
+
+	w := postJSON(engine, "/auth/refresh", "", dto.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rotated dto.TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+	assert.Equal(t, http.StatusOK, getWithToken(engine, "/api/v1/me", rotated.AccessToken))
+
+	// 旧的刷新 Token 已被轮换，不能再次使用
+	w = postJSON(engine, "/auth/refresh", "", dto.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	// 访问 Token 不能用于刷新
+	w = postJSON(engine, "/auth/refresh", "", dto.RefreshRequest{RefreshToken: rotated.AccessToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthPlugin_LogoutRevokesTokensInRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	engine := newAuthEngine(t, plugin.Deps{plugin.DepRedis: rdb})
+	tokens := login(t, engine)
+
+	assert.Equal(t, http.StatusUnauthorized, postJSON(engine, "/auth/logout", "", nil).Code)
+	w := postJSON(engine, "/auth/logout", tokens.AccessToken, dto.LogoutRequest{RefreshToken: tokens.RefreshToken})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(engine, "/api/v1/me", tokens.AccessToken), "登出后访问 Token 失效")
+	w = postJSON(engine, "/auth/refresh", "", dto.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "登出后刷新 Token 失效")
+	var body errs.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, plugin.ErrTokenRevoked.Code, body.Code)
+
+	var revoked []string
+	for _, key := range mr.Keys() {
+		if strings.HasPrefix(key, "auth:revoked:") {
+			revoked = append(revoked, key)
+			assert.Greater(t, mr.TTL(key).Seconds(), 0.0, "吊销记录随 Token 过期")
+		}
+	}
+	assert.Len(t, revoked, 2)
+}
+
+func TestMemoryRevocationStore_RevokeOnce(t *testing.T) {
+	store := plugin.NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	first, err := store.Revoke(ctx, "jti-1", 0)
+	require.NoError(t, err)
+	assert.True(t, first)
+	again, err := store.Revoke(ctx, "jti-1", 0)
+	require.NoError(t, err)
+	assert.False(t, again, "同一个 jti 只能成功吊销一次")
+
+	revoked, _ := store.IsRevoked(ctx, "jti-1")
+	assert.True(t, revoked)
+	revoked, _ = store.IsRevoked(ctx, "jti-2")
+	assert.False(t, revoked)
+}
+
+func TestGormUserStore_BcryptPasswords(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	store := plugin.NewGormUserStore(db)
+	ctx := context.Background()
+	require.NoError(t, store.Migrate(ctx))
+
+	created, err := store.Create(ctx, plugin.User{Username: "carol", Roles: []string{"agent", "auditor"}}, "p@ssw0rd")
+	require.NoError(t, err)
+
+	var record plugin.UserRecord
+	require.NoError(t, db.First(&record, created.ID).Error)
+	assert.True(t, strings.HasPrefix(record.PasswordHash, "$2a$"), "数据库中只保存 bcrypt 哈希")
+
+	user, err := store.Authenticate(ctx, "carol", "p@ssw0rd")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Equal(t, []string{"agent", "auditor"}, user.Roles)
+	_, err = store.Authenticate(ctx, "carol", "wrong")
+	assert.ErrorIs(t, err, plugin.ErrInvalidCredentials)
+	_, err = store.Authenticate(ctx, "nobody", "p@ssw0rd")
+	assert.ErrorIs(t, err, plugin.ErrInvalidCredentials)
+
+	found, err := store.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "carol", found.Username)
+	_, err = store.FindByID(ctx, created.ID+100)
+	assert.ErrorIs(t, err, plugin.ErrUserNotFound)
+}
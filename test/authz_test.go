@@ -0,0 +1,110 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/conf"
+	"myGin/internal/dto"
+	"myGin/internal/pkg/authz"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/plugin"
+)
+
+const testPolicy = `
+roles:
+  admin: ["*"]
+  agent: ["flights:*"]
+rules:
+  - path: /api/v1/flights/tickets/order
+    methods: [POST]
+    permissions: ["flights:order"]
+  - path: /api/v1/admin/**
+    permissions: ["admin:manage"]
+`
+
+// newAuthzEngine 使用 testPolicy 启用认证和授权: alice 是 agent，bob 只有 flights:search scope。
+func newAuthzEngine(t *testing.T) *gin.Engine {
+	policyFile := filepath.Join(t.TempDir(), "policy.yml")
+	require.NoError(t, os.WriteFile(policyFile, []byte(testPolicy), 0600))
+	bobHash, err := plugin.HashPassword("bob-pass")
+	require.NoError(t, err)
+
+	engine := newAuthEngine(t, plugin.Deps{}, func(a *conf.AuthConfig) {
+		a.PolicyFile = policyFile
+		a.Users[0].Roles = []string{"agent"}
+		a.Users = append(a.Users, conf.AuthUserConfig{ID: 8, Username: "bob", PasswordHash: bobHash, Scopes: []string{"flights:search"}})
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.POST("/api/v1/flights/tickets/order", ok)
+	engine.GET("/api/v1/flights/tickets/order", ok)
+	engine.GET("/api/v1/admin/users", ok)
+	engine.GET("/api/v1/flights/search", plugin.RequirePermission("flights:search"), ok)
+	return engine
+}
+
+func loginAs(t *testing.T, engine *gin.Engine, username, password string) string {
+	w := postJSON(engine, "/auth/login", "", dto.LoginRequest{Username: username, Password: password})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tokens dto.TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	return tokens.AccessToken
+}
+
+func TestAuthPlugin_PolicyFileEnforcesPermissions(t *testing.T) {
+	engine := newAuthzEngine(t)
+	alice := loginAs(t, engine, "alice", "secret123")
+	bob := loginAs(t, engine, "bob", "bob-pass")
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/flights/tickets/order", alice).Code, "agent 角色拥有 flights:*")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/admin/users", alice).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/flights/search", bob).Code, "scope 直接授予权限")
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/flights/tickets/order", bob).Code, "规则只限制 POST")
+
+	w := do(http.MethodPost, "/api/v1/flights/tickets/order", bob)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	var body errs.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, plugin.ErrPermissionDenied.Code, body.Code)
+	assert.Equal(t, map[string]interface{}{"missing": []interface{}{"flights:order"}}, body.Details)
+}
+
+func TestRequirePermission_WithoutAuthReturnsUnauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/x", plugin.RequirePermission("flights:search"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthorizer_Wildcards(t *testing.T) {
+	a, err := (&authz.Policy{Roles: map[string][]string{"admin": {"*"}, "agent": {"flights:*"}}}).Compile()
+	require.NoError(t, err)
+
+	agent := a.Grant([]string{"agent"}, []string{"reports:read"})
+	assert.True(t, agent.Has("flights:order"))
+	assert.True(t, agent.Has("reports:read"))
+	assert.Equal(t, []string{"admin:manage"}, agent.Missing([]string{"flights:search", "admin:manage", "admin:manage"}))
+	assert.True(t, a.Grant([]string{"admin"}, nil).Has("anything"))
+	assert.False(t, a.Grant([]string{"unknown"}, nil).Has("flights:order"))
+
+	_, err = (&authz.Policy{Rules: []authz.Rule{{Path: "/x"}}}).Compile()
+	assert.Error(t, err, "规则必须声明权限")
+}
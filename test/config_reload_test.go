@@ -0,0 +1,118 @@
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/plugin"
+)
+
+// writeReloadConfig 写入热重载测试使用的配置文件。
+func writeReloadConfig(t *testing.T, file, level string, rate float64) {
+	content := fmt.Sprintf(`
+logger:
+  level: %q
+  file: %q
+modules:
+  ratelimit:
+    enable: true
+    rate: %v
+    burst: 1
+`, level, filepath.ToSlash(filepath.Join(filepath.Dir(file), "logs", "app.log")), rate)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+}
+
+func TestConfigWatcher_ReloadsOnChangeAndKeepsOldConfigWhenInvalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yml")
+	writeReloadConfig(t, file, "info", 5)
+	opts := bootstrap.ConfigOptions{ConfigFile: file}
+
+	cfg, err := bootstrap.LoadConfigWithOptions(opts)
+	require.NoError(t, err)
+	watcher, err := bootstrap.NewConfigWatcher(opts, cfg, nil)
+	require.NoError(t, err)
+
+	changes := make(chan [2]*conf.Config, 4)
+	watcher.Subscribe(func(old, new *conf.Config) { changes <- [2]*conf.Config{old, new} })
+	require.NoError(t, watcher.Start())
+	t.Cleanup(watcher.Stop)
+
+	// 修改文件后自动重载并通知订阅者
+	writeReloadConfig(t, file, "debug", 10)
+	select {
+	case c := <-changes:
+		assert.Equal(t, "info", c[0].Logger.Level)
+		assert.Equal(t, "debug", c[1].Logger.Level)
+		assert.Equal(t, 10.0, c[1].Modules.RateLimit.Rate)
+	case <-time.After(3 * time.Second):
+		t.Fatal("配置变更后未通知订阅者")
+	}
+	assert.Equal(t, "debug", watcher.Current().Logger.Level)
+
+	// 无效配置 (启用限流但 rate 为 0) 不会替换当前配置
+	writeReloadConfig(t, file, "warn", 0)
+	assert.Error(t, watcher.Reload())
+	assert.Equal(t, "debug", watcher.Current().Logger.Level)
+	assert.Equal(t, 10.0, watcher.Current().Modules.RateLimit.Rate)
+	select {
+	case <-changes:
+		t.Fatal("无效配置不应通知订阅者")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestLoggerConfigSubscriber_ChangesLevelLive(t *testing.T) {
+	initTestLogger()
+	original := bootstrap.LogLevel()
+	t.Cleanup(func() { _ = bootstrap.SetLogLevel(original.String()) })
+
+	old, next := validConfig(), validConfig()
+	old.Logger.Level, next.Logger.Level = original.String(), "debug"
+	bootstrap.LoggerConfigSubscriber(old, next)
+
+	assert.Equal(t, zapcore.DebugLevel, bootstrap.LogLevel())
+	assert.True(t, bootstrap.GetLogger().Core().Enabled(zapcore.DebugLevel), "已有 logger 应立即使用新级别")
+}
+
+func TestRateLimitPlugin_ReconfigureRetunesExistingLimiters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := plugin.NewRateLimitPlugin()
+	require.NoError(t, p.Init(&conf.RateLimitConfig{Enable: true, Rate: 0.001, Burst: 1}, map[string]interface{}{"logger": zap.NewNop()}))
+
+	engine := gin.New()
+	engine.Use(p.(plugin.MiddlewareProvider).Middleware())
+	engine.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, do())
+	assert.Equal(t, http.StatusTooManyRequests, do())
+
+	// 提高速率后，已存在的 IP 限流器无需重启即可放行
+	require.NoError(t, p.(plugin.Reconfigurable).Reconfigure(&conf.RateLimitConfig{Enable: true, Rate: 1000, Burst: 10}))
+	assert.Eventually(t, func() bool { return do() == http.StatusOK }, time.Second, 5*time.Millisecond)
+
+	// 热重载禁用后直接放行
+	require.NoError(t, p.(plugin.Reconfigurable).Reconfigure(&conf.RateLimitConfig{Enable: false}))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, http.StatusOK, do())
+	}
+}
@@ -0,0 +1,241 @@
+package main_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+)
+
+// validConfig 返回一份可以通过校验的最小配置。
+func validConfig() *conf.Config {
+	return &conf.Config{
+		App:    conf.AppConfig{Env: "development"},
+		Server: conf.ServerConfig{Addr: ":8080"},
+		Logger: conf.LoggerConfig{Level: "info", File: "logs/app.log"},
+		Modules: conf.ModulesConfig{
+			Auth: conf.AuthConfig{
+				Expire: 3600, RefreshExpire: 604800, RoutePrefix: "/auth", UserStore: "memory",
+				Algorithm: "HS256", Mode: "issuer", JWKSPath: "/.well-known/jwks.json", JWKSRefresh: "5m",
+				APIKeys: conf.APIKeyConfig{Header: "X-API-Key", Prefix: "mgk", Store: "memory", AdminPermission: "apikeys:manage"},
+			},
+		},
+		Database: conf.DatabaseConfig{Driver: "mysql"},
+	}
+}
+
+func TestValidateConfig_ValidConfigPasses(t *testing.T) {
+	assert.NoError(t, bootstrap.ValidateConfig(validConfig()))
+}
+
+func TestValidateConfig_ReportsEveryInvalidFieldPath(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 0, Burst: 10}
+	cfg.Modules.Auth.Enable = true // Secret 为空
+	cfg.Database.ConnMaxLifetime = "1 hour"
+	cfg.Logger.Level = "verbose"
+	cfg.Server.TLS = conf.TLSConfig{Enable: true, ClientAuth: "require_and_verify"}
+
+	err := bootstrap.ValidateConfig(cfg)
+
+	var cfgErr *bootstrap.ConfigError
+	if assert.True(t, errors.As(err, &cfgErr)) {
+		var paths []string
+		for _, f := range cfgErr.Fields {
+			paths = append(paths, f.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			"modules.ratelimit.rate",
+			"modules.auth.secret",
+			"database.connMaxLifetime",
+			"logger.level",
+			"server.tls.certFile",
+			"server.tls.keyFile",
+			"server.tls.clientCAFile",
+		}, paths)
+	}
+	assert.Contains(t, err.Error(), `modules.auth.secret: is required when enable is "true"`)
+}
+
+func TestValidateConfig_DisabledModulesAreNotChecked(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: false} // 未启用时 rate/burst 可以为 0
+	cfg.Redis = conf.RedisConfig{Enable: false}
+
+	assert.NoError(t, bootstrap.ValidateConfig(cfg))
+}
+
+func TestValidateConfig_DisabledFeaturesSkipSubFields(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.TLS = conf.TLSConfig{Enable: false, ClientAuth: "require_and_verify"} // 证书和 CA 为空
+	cfg.Modules.Auth = conf.AuthConfig{Enable: false}                                // 过期时间、算法等全部为空
+	cfg.Database = conf.DatabaseConfig{Enable: false}                                // driver 为空
+	assert.NoError(t, bootstrap.ValidateConfig(cfg))
+
+	cfg.Modules.Auth.Enable = true
+	cfg.Modules.Auth.Algorithm = "RS256"
+	err := bootstrap.ValidateConfig(cfg)
+	var cfgErr *bootstrap.ConfigError
+	if assert.True(t, errors.As(err, &cfgErr)) {
+		var paths []string
+		for _, f := range cfgErr.Fields {
+			paths = append(paths, f.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			"modules.auth.expire",
+			"modules.auth.refreshExpire",
+			"modules.auth.routePrefix",
+			"modules.auth.userStore",
+			"modules.auth.mode",
+			"modules.auth.jwksPath",
+		}, paths, "启用后子字段仍然必填")
+	}
+}
+
+// writeLayeredConfig 在临时目录中写入基础配置和 test 环境配置，返回基础配置文件路径。
+func writeLayeredConfig(t *testing.T) string {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+app:
+  env: "test"
+server:
+  addr: ":8080"
+  readTimeout: "15s"
+logger:
+  file: "`+filepath.ToSlash(filepath.Join(dir, "logs", "app.log"))+`"
+database:
+  dsn: "base-dsn"
+redis:
+  password: "base-password"
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.test.yml"), []byte(`
+server:
+  addr: ":9090"
+  writeTimeout: "20s"
+`), 0600))
+	return base
+}
+
+func TestLoadConfig_LayersOverrideInOrder(t *testing.T) {
+	base := writeLayeredConfig(t)
+	t.Setenv("APP_SERVER_READTIMEOUT", "30s")
+	t.Setenv("APP_DATABASE_DSN", "env-dsn")
+	t.Setenv("APP_SERVER_WRITETIMEOUT", "25s")
+
+	cfg, err := bootstrap.LoadConfigWithOptions(bootstrap.ConfigOptions{
+		ConfigFile: base,
+		Overrides:  []string{"server.writeTimeout=40s", "logger.level=debug"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", cfg.App.Env)
+	assert.Equal(t, ":9090", cfg.Server.Addr, "config.test.yml 应覆盖基础配置")
+	assert.Equal(t, "30s", cfg.Server.ReadTimeout, "环境变量应覆盖配置文件")
+	assert.Equal(t, "env-dsn", cfg.Database.DSN, "环境变量应覆盖配置文件")
+	assert.Equal(t, "40s", cfg.Server.WriteTimeout, "--set 优先级最高")
+	assert.Equal(t, "debug", cfg.Logger.Level)
+	assert.Equal(t, "10s", cfg.Server.ShutdownTimeout, "未配置的键使用默认值")
+}
+
+func TestLoadConfig_EnvFlagSelectsProfile(t *testing.T) {
+	base := writeLayeredConfig(t)
+
+	cfg, err := bootstrap.LoadConfigWithOptions(bootstrap.ConfigOptions{ConfigFile: base, Env: "production"})
+	require.NoError(t, err)
+	assert.Equal(t, "production", cfg.App.Env)
+	assert.Equal(t, ":8080", cfg.Server.Addr, "不存在 config.production.yml 时只使用基础配置")
+}
+
+func TestParseConfigFlags(t *testing.T) {
+	opts, err := bootstrap.ParseConfigFlags([]string{"--config", "a.yml", "--env", "test", "--set", "a.b=1", "--set", "c=2", "--print-config"})
+	require.NoError(t, err)
+	assert.Equal(t, "a.yml", opts.ConfigFile)
+	assert.Equal(t, "test", opts.Env)
+	assert.Equal(t, []string{"a.b=1", "c=2"}, opts.Overrides)
+	assert.True(t, opts.PrintConfig)
+
+	_, err = bootstrap.ParseConfigFlags([]string{"--set", "missing-equals"})
+	assert.Error(t, err)
+}
+
+func TestDumpConfig_RedactsSecrets(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.Auth.Secret = "jwt-secret"
+	cfg.Database.DSN = "root:pass@tcp(db)/app"
+	cfg.Modules.Auth.Users = []conf.AuthUserConfig{{ID: 1, Username: "admin", PasswordHash: "$2a$10$hash"}}
+
+	out, err := bootstrap.DumpConfig(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "jwt-secret")
+	assert.NotContains(t, out, "root:pass")
+	assert.NotContains(t, out, "$2a$10$hash", "结构体切片中的敏感字段同样脱敏")
+	assert.Contains(t, out, "username: admin")
+	assert.Contains(t, out, "******")
+	assert.Contains(t, out, "addr: :8080")
+
+	redacted := bootstrap.RedactedConfig(cfg)
+	assert.Equal(t, "", redacted["redis"].(map[string]interface{})["password"], "空的敏感字段保持为空")
+}
+
+func TestLoadConfig_ResolvesSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "jwt_secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+	t.Setenv("TEST_DB_PASSWORD", "p@ss")
+
+	base := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+logger:
+  file: "`+filepath.ToSlash(filepath.Join(dir, "logs", "app.log"))+`"
+modules:
+  auth:
+    enable: true
+    secret: "${file:`+filepath.ToSlash(secretFile)+`}"
+    issuer: "${base64:bXktaXNzdWVy}"
+database:
+  dsn: "app:${env:TEST_DB_PASSWORD}@tcp(db:3306)/app"
+`), 0600))
+
+	cfg, err := bootstrap.LoadConfigWithOptions(bootstrap.ConfigOptions{ConfigFile: base})
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Modules.Auth.Secret, "文件末尾的换行应被去掉")
+	assert.Equal(t, "my-issuer", cfg.Modules.Auth.Issuer)
+	assert.Equal(t, "app:p@ss@tcp(db:3306)/app", cfg.Database.DSN)
+
+	// 通过引用解析的值即使没有 secret 标签也会被脱敏
+	out, err := bootstrap.DumpConfig(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "from-file")
+	assert.NotContains(t, out, "my-issuer")
+	assert.NotContains(t, out, "p@ss")
+}
+
+func TestLoadConfig_UnresolvedSecretReferenceFails(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+logger:
+  file: "`+filepath.ToSlash(filepath.Join(dir, "logs", "app.log"))+`"
+modules:
+  auth:
+    secret: "${env:TEST_SECRET_THAT_IS_NOT_SET}"
+redis:
+  password: "${vault:secret/redis}"
+`), 0600))
+
+	_, err := bootstrap.LoadConfigWithOptions(bootstrap.ConfigOptions{ConfigFile: base})
+
+	var cfgErr *bootstrap.ConfigError
+	if assert.True(t, errors.As(err, &cfgErr)) {
+		assert.Len(t, cfgErr.Fields, 2)
+		assert.Contains(t, err.Error(), "modules.auth.secret: cannot resolve ${env:TEST_SECRET_THAT_IS_NOT_SET}")
+		assert.Contains(t, err.Error(), `redis.password: unknown secret reference "${vault:secret/redis}"`)
+	}
+}
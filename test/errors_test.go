@@ -0,0 +1,149 @@
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/dto"
+	"myGin/internal/handler"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/i18n"
+	"myGin/internal/pkg/requestid"
+)
+
+// newErrorEngine 返回一个在 GET /fail 上响应 apiErr 的引擎。
+func newErrorEngine(apiErr *errs.APIError) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(requestid.Middleware())
+	engine.GET("/fail", func(c *gin.Context) { apiErr.JSON(c) })
+	return engine
+}
+
+// getFail 以指定的 Accept 和 Accept-Language 请求 /fail。
+func getFail(engine *gin.Engine, accept, acceptLanguage string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Accept-Language", acceptLanguage)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrors_DefaultFormatLocalized(t *testing.T) {
+	engine := newErrorEngine(errs.NotFound)
+
+	w := getFail(engine, "application/json", "en-US")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	var resp errs.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errs.NotFound.Code, resp.Code)
+	assert.Equal(t, "Resource not found", resp.Message)
+	assert.NotEmpty(t, resp.RequestID)
+
+	w = getFail(engine, "", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errs.NotFound.Message, resp.Message, "默认中文")
+
+	custom := newErrorEngine(errs.NotFound.WrapWithMessage(nil, "航班 %s 不存在", "CA123"))
+	require.NoError(t, json.Unmarshal(getFail(custom, "", "en").Body.Bytes(), &resp))
+	assert.Equal(t, "航班 CA123 不存在", resp.Message, "自定义消息不翻译")
+}
+
+func TestErrors_ProblemJSON(t *testing.T) {
+	engine := newErrorEngine(errs.TooManyRequests.WithDetails(map[string]int{"retryAfter": 3}))
+
+	w := getFail(engine, "application/problem+json, application/json;q=0.5", "en")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, errs.ProblemContentType, w.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "urn:mygin:problem:too-many-requests", body["type"])
+	assert.Equal(t, "Too many requests", body["title"])
+	assert.Equal(t, float64(http.StatusTooManyRequests), body["status"])
+	assert.Equal(t, "Too many requests", body["detail"])
+	assert.Equal(t, "/fail", body["instance"])
+	assert.Equal(t, float64(errs.TooManyRequests.Code), body["code"])
+	assert.Equal(t, true, body["retryable"])
+	assert.Equal(t, w.Header().Get(requestid.HeaderName), body["requestId"])
+	assert.Equal(t, map[string]interface{}{"retryAfter": float64(3)}, body["details"])
+
+	w = getFail(engine, "application/problem+json;q=0", "")
+	assert.NotEqual(t, errs.ProblemContentType, w.Header().Get("Content-Type"), "q=0 表示不接受")
+
+	adHoc := newErrorEngine(errs.NewAPIError(http.StatusTeapot, 41800, "teapot"))
+	var problem errs.Problem
+	require.NoError(t, json.Unmarshal(getFail(adHoc, errs.ProblemContentType, "").Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, http.StatusText(http.StatusTeapot), problem.Title)
+	assert.Equal(t, "teapot", problem.Detail)
+}
+
+func TestErrors_OrderFailureUsesCatalogCode(t *testing.T) {
+	router, mockService := setupTestServer(t)
+	req := dto.OrderRequest{
+		FlightID:     "CA123",
+		Passengers:   []dto.Passenger{{Name: "Test User", IDType: "IDCard", IDNumber: "1"}},
+		ContactName:  "Test Contact",
+		ContactPhone: "13900139000",
+	}
+	mockService.On("CreateOrder", mock.Anything, req).Return(&dto.OrderResponse{Success: false, Message: "no seats"}, nil)
+
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/flights/tickets/order", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", errs.ProblemContentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var problem errs.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, handler.ErrOrderFailed.Code, problem.Code)
+	assert.Equal(t, "urn:mygin:problem:order-failed", problem.Type)
+	assert.Equal(t, "订单创建失败", problem.Title)
+	assert.Equal(t, "no seats", problem.Detail)
+}
+
+func TestErrors_Catalog(t *testing.T) {
+	catalog := errs.Catalog()
+	codes := make(map[int]bool)
+	for _, def := range catalog {
+		assert.False(t, codes[def.Code], "错误码 %d 重复", def.Code)
+		codes[def.Code] = true
+		assert.NotEmpty(t, def.Messages[i18n.Chinese], def.Name)
+		assert.NotEmpty(t, def.Messages[i18n.English], def.Name)
+	}
+	assert.True(t, codes[errs.BadRequest.Code])
+	assert.True(t, codes[handler.ErrOrderFailed.Code])
+
+	def, ok := errs.Lookup(errs.ServiceUnavailable.Code)
+	require.True(t, ok)
+	assert.True(t, def.Retryable)
+	assert.Equal(t, http.StatusServiceUnavailable, def.HTTPStatus)
+
+	out, err := errs.CatalogJSON()
+	require.NoError(t, err)
+	var exported []errs.Definition
+	require.NoError(t, json.Unmarshal(out, &exported))
+	assert.Equal(t, catalog, exported)
+
+	assert.Panics(t, func() {
+		errs.Define(errs.Definition{Code: errs.BadRequest.Code, Name: "duplicate", Messages: errs.Messages{i18n.Chinese: "重复"}})
+	})
+
+	engine, _ := newSwaggerEngine(t, nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/errors.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(out), w.Body.String())
+}
@@ -62,6 +62,7 @@ func setupTestServer(t *testing.T) (*gin.Engine, *MockFlightService) {
 	// 初始化日志记录器 - 对于使用 zap.L() 的中间件至关重要
 	logger := zap.NewNop()
 	zap.ReplaceGlobals(logger) // 设置全局日志记录器
+	initTestLogger()           // 中间件通过 bootstrap.GetLogger() 获取 logger
 
 	// 附加核心中间件 - 传递最小配置
 	bootstrap.AttachCoreMiddleware(router, config) // 传递非 nil 配置
@@ -0,0 +1,107 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/pkg/health"
+)
+
+// setupHealthServer 创建只注册了健康检查路由的测试引擎。
+func setupHealthServer(registry *health.Registry) *gin.Engine {
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	bootstrap.RegisterHealthRoutes(router, registry)
+	return router
+}
+
+func TestHealth_ReadyWhenAllCheckersUp(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 0)
+	registry.Register(health.NewChecker("database", func(ctx context.Context) error { return nil }))
+	router := setupHealthServer(registry)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Equal(t, health.StatusUp, report.Checks["database"].Status)
+}
+
+func TestHealth_DependencyDownFailsReadinessButNotLiveness(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 0)
+	registry.Register(health.NewChecker("database", func(ctx context.Context) error { return nil }))
+	registry.Register(health.NewChecker("redis", func(ctx context.Context) error { return errors.New("connection refused") }))
+	router := setupHealthServer(registry)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.StatusUp, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusDown, report.Checks["redis"].Status)
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "存活检查不应受依赖状态影响")
+}
+
+func TestHealth_CheckerTimeout(t *testing.T) {
+	registry := health.NewRegistry(20*time.Millisecond, 0)
+	registry.Register(health.NewChecker("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	report := registry.Check(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Contains(t, report.Checks["slow"].Error, "deadline exceeded")
+}
+
+func TestHealth_ResultIsCached(t *testing.T) {
+	var calls atomic.Int32
+	registry := health.NewRegistry(time.Second, time.Minute)
+	registry.Register(health.NewChecker("database", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	first := registry.Check(context.Background())
+	second := registry.Check(context.Background())
+	assert.Equal(t, int32(1), calls.Load(), "缓存有效期内不应重复探测")
+	assert.False(t, first.Checks["database"].Cached)
+	assert.True(t, second.Checks["database"].Cached)
+}
+
+func TestHealth_NotReadyDuringShutdown(t *testing.T) {
+	registry := health.NewRegistry(time.Second, 0)
+	registry.Register(health.NewChecker("database", func(ctx context.Context) error { return nil }))
+	router := setupHealthServer(registry)
+
+	registry.SetShuttingDown()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.ShuttingDown)
+}
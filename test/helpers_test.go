@@ -0,0 +1,19 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+)
+
+// initTestLogger 初始化 bootstrap 的全局 logger。
+// 日志写入临时目录，级别设为 fatal 以免测试输出被日志淹没。
+// InitializeLogger 内部使用 sync.Once，重复调用是安全的。
+func initTestLogger() {
+	bootstrap.InitializeLogger(conf.LoggerConfig{
+		Level: "fatal",
+		File:  filepath.Join(os.TempDir(), "mygin-test", "app.log"),
+	})
+}
@@ -0,0 +1,164 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/plugin"
+)
+
+// scrape 请求 /metrics 并返回 Prometheus 文本格式的响应体。
+func scrape(t *testing.T, engine *gin.Engine) string {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMetrics_HTTPAuthAndRateLimit(t *testing.T) {
+	engine := newAuthEngineWithConfig(t, plugin.Deps{}, func(cfg *conf.Config) {
+		cfg.Modules.Metrics = conf.MetricsConfig{Enable: true, Path: "/metrics"}
+		cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 0.001, Burst: 2,
+			RouteScope: conf.RouteScope{Include: []string{"/api/**"}}}
+	})
+	token := loginAs(t, engine, "alice", "secret123")
+
+	assert.Equal(t, http.StatusUnauthorized, getWithToken(engine, "/api/v1/me", ""))
+	assert.Equal(t, http.StatusOK, getWithToken(engine, "/api/v1/me", token))
+	assert.Equal(t, http.StatusTooManyRequests, getWithToken(engine, "/api/v1/me", token))
+	assert.Equal(t, http.StatusUnauthorized, postJSON(engine, "/auth/login", "", map[string]string{"username": "alice", "password": "wrong"}).Code)
+	assert.Equal(t, http.StatusNotFound, getWithToken(engine, "/nope/123", ""))
+
+	body := scrape(t, engine)
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/api/v1/me",status="200"} 1`,
+		`http_requests_total{method="GET",route="/api/v1/me",status="429"} 1`,
+		`http_requests_total{method="GET",route="/api/v1/me",status="401"} 1`,
+		`http_requests_total{method="POST",route="/auth/login",status="401"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/me",status="200"} 1`,
+		`http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		`ratelimit_rejected_total{dry_run="false",rule="default"} 1`,
+		`auth_failures_total{reason="unauthenticated"} 1`,
+		`auth_failures_total{reason="login"} 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+	assert.Contains(t, body, "go_goroutines ")
+	assert.Contains(t, body, "process_cpu_seconds_total ")
+}
+
+func TestMetrics_RateLimitStoreStats(t *testing.T) {
+	engine := newAuthEngineWithConfig(t, plugin.Deps{}, func(cfg *conf.Config) {
+		cfg.Modules.Metrics = conf.MetricsConfig{Enable: true, Path: "/metrics"}
+		cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 100, Burst: 100, MaxKeys: 1,
+			RouteScope: conf.RouteScope{Include: []string{"/api/**"}},
+			Groups: []conf.RateLimitGroupConfig{{Name: "login", RouteScope: conf.RouteScope{Include: []string{"/auth/login"}}}},
+		}
+	})
+
+	requestFrom(engine, http.MethodGet, "/api/v1/me", "", "10.0.0.1:1")
+	requestFrom(engine, http.MethodGet, "/api/v1/me", "", "10.0.0.2:1")
+	requestFrom(engine, http.MethodPost, "/auth/login", "", "10.0.0.3:1")
+
+	body := scrape(t, engine)
+	for _, line := range []string{
+		`ratelimit_store_keys{group="default"} 1`,
+		`ratelimit_store_evictions_total{group="default"} 1`,
+		`ratelimit_store_expirations_total{group="default"} 0`,
+		`ratelimit_store_keys{group="login"} 1`,
+		`ratelimit_store_evictions_total{group="login"} 0`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestMetrics_DBAndRedis(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	rdb := newRedisClient(t, miniredis.RunT(t))
+
+	cfg := validConfig()
+	cfg.Modules.Metrics = conf.MetricsConfig{Enable: true, Path: "/metrics", Namespace: "app"}
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	_, err = bootstrap.AttachPlugins(engine, cfg, plugin.Deps{plugin.DepDB: db, plugin.DepRedis: rdb})
+	require.NoError(t, err)
+
+	type item struct {
+		ID   int64
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+	var got item
+	require.NoError(t, db.First(&got).Error)
+	require.ErrorIs(t, db.First(&got, 42).Error, gorm.ErrRecordNotFound)
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "k", "v", 0).Err())
+	require.ErrorIs(t, rdb.Get(ctx, "missing").Err(), redis.Nil)
+	_, err = rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "n")
+		pipe.Incr(ctx, "n")
+		return nil
+	})
+	require.NoError(t, err)
+
+	body := scrape(t, engine)
+	for _, line := range []string{
+		`app_db_query_duration_seconds_count{operation="create",table="items"} 1`,
+		`app_db_query_duration_seconds_count{operation="query",table="items"} 2`,
+		`app_redis_command_duration_seconds_count{command="set",status="ok"} 1`,
+		`app_redis_command_duration_seconds_count{command="get",status="ok"} 1`,
+		`app_redis_command_duration_seconds_count{command="pipeline",status="ok"} 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+	assert.NotContains(t, body, "app_db_query_errors_total{", "record not found 不计为错误")
+}
+
+func TestMetrics_SeparateAddr(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	cfg := validConfig()
+	cfg.Modules.Metrics = conf.MetricsConfig{Enable: true, Path: "/metrics", Addr: addr}
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	plugins, err := bootstrap.AttachPlugins(engine, cfg, plugin.Deps{})
+	require.NoError(t, err)
+	require.NoError(t, plugins.Start(context.Background()))
+	t.Cleanup(func() { _ = plugins.Stop(context.Background()) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "配置了 addr 时不在主服务上注册")
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(data), "go_goroutines ")
+}
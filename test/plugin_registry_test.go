@@ -0,0 +1,161 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/plugin"
+)
+
+// recordingPlugin 记录生命周期事件，用于验证调用顺序。
+type recordingPlugin struct {
+	name        string
+	events      *[]string
+	startErr    error
+	registerErr error
+}
+
+func (p *recordingPlugin) Init(_ interface{}, deps plugin.Deps) error {
+	*p.events = append(*p.events, "init:"+p.name)
+	return nil
+}
+
+func (p *recordingPlugin) Register(*gin.Engine) error { return p.registerErr }
+
+func (p *recordingPlugin) Start(context.Context) error {
+	*p.events = append(*p.events, "start:"+p.name)
+	return p.startErr
+}
+
+func (p *recordingPlugin) Stop(context.Context) error {
+	*p.events = append(*p.events, "stop:"+p.name)
+	return nil
+}
+
+func recording(name string, events *[]string) plugin.Factory {
+	return func() plugin.Plugin { return &recordingPlugin{name: name, events: events} }
+}
+
+func TestRegistry_SortsByDependenciesAndOrdering(t *testing.T) {
+	reg := plugin.NewRegistry()
+	noop := func() plugin.Plugin { return nil }
+	reg.Register("ratelimit", noop, plugin.After("auth"))
+	reg.Register("auth", noop, plugin.DependsOn("session"))
+	reg.Register("session", noop)
+	reg.Register("metrics", noop, plugin.Before("auth"))
+	reg.Register("swagger", noop, plugin.After("not-enabled"))
+
+	sorted, err := reg.Sort([]string{"ratelimit", "auth", "session", "metrics", "swagger"})
+	require.NoError(t, err)
+	var names []string
+	for _, r := range sorted {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"metrics", "session", "auth", "ratelimit", "swagger"}, names)
+
+	// 未启用的插件不参与排序约束
+	sorted, err = reg.Sort([]string{"ratelimit", "metrics"})
+	require.NoError(t, err)
+	assert.Len(t, sorted, 2)
+}
+
+func TestRegistry_DetectsCyclesAndDuplicates(t *testing.T) {
+	reg := plugin.NewRegistry()
+	noop := func() plugin.Plugin { return nil }
+	reg.Register("a", noop, plugin.After("b"))
+	reg.Register("b", noop, plugin.After("c"))
+	reg.Register("c", noop, plugin.After("a"))
+
+	_, err := reg.Sort([]string{"a", "b", "c"})
+	assert.EqualError(t, err, "plugin dependency cycle detected among: a, b, c")
+
+	assert.Panics(t, func() { reg.Register("a", noop) })
+}
+
+func TestPluginManager_LifecycleOrderAndSkipsUnmetDependencies(t *testing.T) {
+	initTestLogger()
+	var events []string
+	reg := plugin.NewRegistry()
+	reg.Register("store", recording("store", &events))
+	reg.Register("api", recording("api", &events), plugin.DependsOn("store"))
+	reg.Register("cache", recording("cache", &events), plugin.Requires(plugin.DepRedis))
+	reg.Register("disabled", recording("disabled", &events),
+		plugin.WithConfig(func(*conf.Config) (interface{}, bool) { return nil, false }))
+	reg.Register("orphan", recording("orphan", &events), plugin.DependsOn("disabled"))
+
+	m := bootstrap.NewPluginManager(reg)
+	require.NoError(t, m.Attach(gin.New(), validConfig(), plugin.Deps{plugin.DepLogger: zap.NewNop()}))
+
+	assert.Equal(t, []string{"store", "api"}, m.Names(), "缺少 redis 的 cache 和依赖未启用插件的 orphan 应被跳过")
+
+	require.NoError(t, m.Start(context.Background()))
+	require.NoError(t, m.Stop(context.Background()))
+	require.NoError(t, m.Stop(context.Background()), "重复 Stop 不会再次调用插件")
+	assert.Equal(t, []string{"init:store", "init:api", "start:store", "start:api", "stop:api", "stop:store"}, events)
+}
+
+func TestPluginManager_StartFailureStopsStartedPlugins(t *testing.T) {
+	initTestLogger()
+	var events []string
+	reg := plugin.NewRegistry()
+	reg.Register("first", recording("first", &events))
+	reg.Register("second", func() plugin.Plugin {
+		return &recordingPlugin{name: "second", events: &events, startErr: errors.New("boom")}
+	}, plugin.After("first"))
+
+	m := bootstrap.NewPluginManager(reg)
+	require.NoError(t, m.Attach(gin.New(), validConfig(), plugin.Deps{}))
+
+	err := m.Start(context.Background())
+	assert.ErrorContains(t, err, "plugin second start failed: boom")
+	assert.Equal(t, []string{"init:first", "init:second", "start:first", "start:second", "stop:first"}, events)
+}
+
+func TestPluginManager_RegisterFailureAbortsAttach(t *testing.T) {
+	initTestLogger()
+	var events []string
+	reg := plugin.NewRegistry()
+	reg.Register("guard", func() plugin.Plugin {
+		return &recordingPlugin{name: "guard", events: &events, registerErr: errors.New("boom")}
+	})
+
+	m := bootstrap.NewPluginManager(reg)
+	err := m.Attach(gin.New(), validConfig(), plugin.Deps{})
+	assert.EqualError(t, err, "failed to register plugin guard: boom", "注册失败时启动失败，不会带着失效的中间件继续运行")
+}
+
+func TestDep_TypedLookup(t *testing.T) {
+	logger := zap.NewNop()
+	deps := plugin.Deps{plugin.DepLogger: logger, "count": 3}
+
+	got, err := plugin.Dep[*zap.Logger](deps, plugin.DepLogger)
+	require.NoError(t, err)
+	assert.Same(t, logger, got)
+
+	_, err = plugin.Dep[string](deps, "count")
+	assert.EqualError(t, err, "count dependency is not of type string, got int")
+
+	_, err = plugin.Dep[*zap.Logger](deps, "missing")
+	assert.EqualError(t, err, "missing dependency is missing")
+
+	_, ok := deps.Redis()
+	assert.False(t, ok)
+}
+
+func TestDefaultRegistry_ContainsBuiltinPlugins(t *testing.T) {
+	names := plugin.Default().Names()
+	assert.Contains(t, names, "auth")
+	assert.Contains(t, names, "ratelimit")
+
+	sorted, err := plugin.Default().Sort([]string{"auth", "ratelimit"})
+	require.NoError(t, err)
+	assert.Equal(t, "ratelimit", sorted[0].Name, "限流应在认证之前，未认证的请求同样被限流")
+}
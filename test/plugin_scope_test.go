@@ -0,0 +1,133 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/pathmatch"
+	"myGin/internal/plugin"
+)
+
+func TestPathPattern_Match(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/api/v1/**", "/api/v1", true},
+		{"/api/v1/**", "/api/v1/flights/tickets/search", true},
+		{"/api/v1/**", "/api/v2/ping", false},
+		{"/api/*/ping", "/api/v1/ping", true},
+		{"/api/*/ping", "/api/v1/x/ping", false},
+		{"/users/:id", "/users/42", true},
+		{"/users/:id", "/users", false},
+		{"/**/ping", "/api/v1/ping", true},
+		{"/files/*.json", "/files/a.json", true},
+		{"/api/v1/ping", "/api/v1/ping/", true},
+	}
+	for _, tc := range cases {
+		p, err := pathmatch.Compile(tc.pattern)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, p.Match(tc.path), "%s ~ %s", tc.pattern, tc.path)
+	}
+
+	_, err := pathmatch.Compile("api/**")
+	assert.Error(t, err)
+	_, err = pathmatch.Compile("/api/v1**")
+	assert.Error(t, err)
+}
+
+func TestValidateConfig_RejectsInvalidRoutePatterns(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.Auth.Exclude = []string{"no-leading-slash"}
+	cfg.Modules.RateLimit.Groups = []conf.RateLimitGroupConfig{{Name: "", Rate: 1, Burst: 1}}
+
+	err := bootstrap.ValidateConfig(cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "modules.auth.exclude[0]: invalid path pattern")
+		assert.Contains(t, err.Error(), "modules.ratelimit.groups[0].name: is required")
+	}
+}
+
+// newScopedEngine 使用全局插件注册表加载插件，并注册测试路由。
+func newScopedEngine(t *testing.T, cfg *conf.Config) *gin.Engine {
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	_, err := bootstrap.AttachPlugins(engine, cfg, plugin.Deps{})
+	require.NoError(t, err)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/livez", ok)
+	engine.GET("/api/v1/ping", ok)
+	engine.GET("/api/v1/orders", ok)
+	engine.POST("/api/v1/login", ok)
+	return engine
+}
+
+func serve(engine *gin.Engine, method, path string) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthPlugin_OnlyProtectsIncludedRoutes(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.Auth = conf.AuthConfig{
+		Enable: true, Secret: "s", Expire: 60,
+		RouteScope: conf.RouteScope{Include: []string{"/api/**"}, Exclude: []string{"/api/v1/ping"}},
+	}
+	engine := newScopedEngine(t, cfg)
+
+	assert.Equal(t, http.StatusOK, serve(engine, http.MethodGet, "/livez"), "未包含的路由不需要认证")
+	assert.Equal(t, http.StatusOK, serve(engine, http.MethodGet, "/api/v1/ping"), "排除的路由不需要认证")
+	assert.Equal(t, http.StatusUnauthorized, serve(engine, http.MethodGet, "/api/v1/orders"))
+}
+
+func TestRateLimitPlugin_GroupsUseSeparateSettings(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.RateLimit = conf.RateLimitConfig{
+		Enable: true, Rate: 1000, Burst: 1000,
+		RouteScope: conf.RouteScope{Exclude: []string{"/api/v1/login"}},
+		Groups: []conf.RateLimitGroupConfig{{
+			Name: "login", Rate: 0.001, Burst: 1,
+			RouteScope: conf.RouteScope{Include: []string{"/api/v1/login"}},
+		}},
+	}
+	engine := newScopedEngine(t, cfg)
+
+	assert.Equal(t, http.StatusOK, serve(engine, http.MethodPost, "/api/v1/login"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(engine, http.MethodPost, "/api/v1/login"), "login 组使用更严格的限制")
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(engine, http.MethodGet, "/api/v1/orders"), "其他路由使用默认实例")
+	}
+}
+
+func TestRateLimitPlugin_GroupInheritsZeroRateAndBurst(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: false, Groups: []conf.RateLimitGroupConfig{{Name: "login"}}}
+	require.NoError(t, bootstrap.ValidateConfig(cfg), "未启用时组的 rate/burst 可以为 0")
+
+	cfg.Modules.RateLimit = conf.RateLimitConfig{
+		Enable: true, Rate: 0.001, Burst: 1,
+		RouteScope: conf.RouteScope{Exclude: []string{"/api/v1/login"}},
+		Groups: []conf.RateLimitGroupConfig{{
+			Name:       "login",
+			RouteScope: conf.RouteScope{Include: []string{"/api/v1/login"}},
+		}},
+	}
+	require.NoError(t, bootstrap.ValidateConfig(cfg))
+	engine := newScopedEngine(t, cfg)
+
+	assert.Equal(t, http.StatusOK, serve(engine, http.MethodPost, "/api/v1/login"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(engine, http.MethodPost, "/api/v1/login"), "组使用顶层的 rate/burst")
+}
@@ -0,0 +1,201 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/plugin"
+)
+
+// newRuleEngine 同时启用认证 (alice 拥有 tier:premium) 和带有 rules 的限流插件，注册 /api/v1/me 和 /api/v1/other。
+// 未指定顶层 rate/burst 时使用 1000/1000，使默认规则不影响用例。
+func newRuleEngine(t *testing.T, rl conf.RateLimitConfig) *gin.Engine {
+	engine := newAuthEngineWithConfig(t, plugin.Deps{}, func(cfg *conf.Config) {
+		cfg.Modules.Auth.Users[0].Scopes = []string{"tier:premium"}
+		rl.Enable = true
+		if rl.Rate == 0 {
+			rl.Rate, rl.Burst = 1000, 1000
+		}
+		cfg.Modules.RateLimit = rl
+	})
+	engine.GET("/api/v1/other", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+// requestFrom 以指定客户端地址请求，token 非空时携带 Authorization 头。
+func requestFrom(engine *gin.Engine, method, path, token, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRules_UserKeyAndMostSpecificRule(t *testing.T) {
+	engine := newRuleEngine(t, conf.RateLimitConfig{Rules: []conf.RateLimitRuleConfig{
+		{Name: "api", Path: "/api/**", Key: "user", Rate: 0.001, Burst: 3},
+		{Name: "me", Path: "/api/v1/me", Methods: []string{"get"}, Key: "user", Rate: 0.001, Burst: 1},
+	}})
+	token := loginAs(t, engine, "alice", "secret123")
+
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.2:1").Code,
+		"按用户限流，换 IP 也共享配额；更具体的 me 规则生效")
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/other", token, "10.0.0.1:1").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/api/v1/other", token, "10.0.0.1:1").Code)
+}
+
+func TestRateLimitRules_PermissionTierAndDailyCap(t *testing.T) {
+	engine := newRuleEngine(t, conf.RateLimitConfig{Rules: []conf.RateLimitRuleConfig{
+		{Name: "free", Path: "/api/**", Key: "user", Rate: 0.001, Burst: 1},
+		{Name: "premium", Path: "/api/**", Key: "user", Permissions: []string{"tier:premium"}, Daily: 2},
+	}})
+	token := loginAs(t, engine, "alice", "secret123")
+
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/other", token, "10.0.0.1:1").Code,
+		"premium 规则更具体，不受 free 规则的速率限制")
+
+	w := requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var body errs.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, plugin.ErrDailyQuotaExceeded.Code, body.Code)
+	assert.Equal(t, "已超过每日请求配额", body.Message)
+}
+
+func TestRateLimitRules_AllowlistAndDryRun(t *testing.T) {
+	rules := []conf.RateLimitRuleConfig{{Name: "api", Path: "/api/**", Key: "user", Rate: 0.001, Burst: 1}}
+
+	engine := newRuleEngine(t, conf.RateLimitConfig{Rules: rules, Allowlist: []string{"user:7"}})
+	token := loginAs(t, engine, "alice", "secret123")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code)
+	}
+
+	engine = newRuleEngine(t, conf.RateLimitConfig{Rules: rules, Allowlist: []string{"192.168.0.0/16"}, DryRun: true})
+	token = loginAs(t, engine, "alice", "secret123")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code, "dry-run 只记录不拒绝")
+	}
+}
+
+func TestRateLimitRules_HeaderKey(t *testing.T) {
+	engine := newRateLimitEngine(t, plugin.Deps{}, conf.RateLimitConfig{Rate: 1000, Burst: 1000, Rules: []conf.RateLimitRuleConfig{
+		{Name: "tenant", Key: "header", Header: "X-Tenant", Rate: 0.001, Burst: 1},
+	}})
+	get := func(tenant string) int {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("a"))
+	assert.Equal(t, http.StatusTooManyRequests, get("a"))
+	assert.Equal(t, http.StatusOK, get("b"), "不同请求头取值各自计数")
+	assert.Equal(t, http.StatusOK, get(""), "缺少请求头时使用默认的 IP 限流")
+}
+
+func TestValidateConfig_RateLimitRules(t *testing.T) {
+	cfg := validConfig()
+	cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 1, Burst: 1, Rules: []conf.RateLimitRuleConfig{
+		{Name: "h", Key: "header", Rate: 1},
+	}}
+	err := bootstrap.ValidateConfig(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `modules.ratelimit.rules[0].header: is required when key is "header"`)
+	assert.Contains(t, err.Error(), "modules.ratelimit.rules[0].burst: is required when rate is set")
+}
+
+func TestRateLimitRules_UnauthenticatedFloodIsThrottled(t *testing.T) {
+	engine := newAuthEngineWithConfig(t, plugin.Deps{}, func(cfg *conf.Config) {
+		cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 0.001, Burst: 2, Rules: []conf.RateLimitRuleConfig{
+			{Name: "api", Path: "/api/**", Key: "user", Rate: 0.001, Burst: 1},
+		}}
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, requestFrom(engine, http.MethodGet, "/api/v1/me", "invalid", "10.0.0.9:1").Code)
+	assert.Equal(t, http.StatusUnauthorized, requestFrom(engine, http.MethodGet, "/api/v1/me", "", "10.0.0.9:1").Code)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/api/v1/me", "invalid", "10.0.0.9:1").Code,
+			"限流在认证之前执行，未认证的请求按 IP 计数")
+	}
+
+	token := loginAs(t, engine, "alice", "secret123")
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.2:1").Code,
+		"认证通过后按用户维度的规则仍然生效")
+}
+
+func TestRateLimitRules_AuthenticatedCallerChargedOnce(t *testing.T) {
+	rules := []conf.RateLimitRuleConfig{{Name: "premium", Path: "/api/**", Key: "user", Permissions: []string{"tier:premium"}, Rate: 1000, Burst: 1000}}
+
+	engine := newRuleEngine(t, conf.RateLimitConfig{Rate: 0.001, Burst: 2, Rules: rules, Allowlist: []string{"user:7"}})
+	token := loginAs(t, engine, "alice", "secret123")
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code,
+			"白名单中的用户不受顶层按 IP 的限流影响")
+	}
+
+	engine = newRuleEngine(t, conf.RateLimitConfig{Rate: 0.001, Burst: 2, Rules: rules})
+	token = loginAs(t, engine, "alice", "secret123")
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/api/v1/me", token, "10.0.0.1:1").Code,
+			"认证通过的请求只按 premium 规则计数，不再按顶层规则计数")
+	}
+	assert.Equal(t, http.StatusUnauthorized, requestFrom(engine, http.MethodGet, "/api/v1/me", "invalid", "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusUnauthorized, requestFrom(engine, http.MethodGet, "/api/v1/me", "invalid", "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/api/v1/me", "invalid", "10.0.0.1:1").Code,
+		"认证失败的请求在认证之后按 IP 计数")
+}
+
+func TestRateLimitRules_CredentialsOutsideAuthScope(t *testing.T) {
+	engine := newRuleEngine(t, conf.RateLimitConfig{Rate: 0.001, Burst: 2, Rules: []conf.RateLimitRuleConfig{
+		{Name: "api", Path: "/api/**", Key: "user", Rate: 1000, Burst: 1000},
+	}})
+	engine.GET("/public", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 0, 4)
+	for i := 0; i < 4; i++ {
+		codes = append(codes, requestFrom(engine, http.MethodGet, "/public", "anything", "10.0.0.3:1").Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes,
+		"路由不在认证范围内时，携带凭证的请求从第二个请求起在认证之前按 IP 计数")
+}
+
+func TestRateLimitRules_NestedAuthMiddlewareChargesOnce(t *testing.T) {
+	engine := newAuthEngineWithConfig(t, plugin.Deps{}, func(cfg *conf.Config) {
+		cfg.Modules.Auth.Users[0].Scopes = []string{"apikeys:manage"}
+		cfg.Modules.Auth.APIKeys = conf.APIKeyConfig{Enable: true, Header: "X-API-Key", Prefix: "mgk", Store: "memory", AdminPermission: "apikeys:manage"}
+		// 全局作用范围同时覆盖 Key 管理接口，与分组自带的认证中间件叠加
+		cfg.Modules.Auth.Include = []string{"/api/**", "/auth/apikeys"}
+		cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 1000, Burst: 1000, Rules: []conf.RateLimitRuleConfig{
+			{Name: "keys", Path: "/auth/apikeys", Key: "user", Rate: 0.001, Burst: 2},
+		}}
+	})
+	token := loginAs(t, engine, "alice", "secret123")
+
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/auth/apikeys", token, "10.0.0.1:1").Code)
+	assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "/auth/apikeys", token, "10.0.0.1:1").Code,
+		"认证中间件叠加时每个请求只消耗一个令牌")
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodGet, "/auth/apikeys", token, "10.0.0.1:1").Code)
+}
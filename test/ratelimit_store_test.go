@@ -0,0 +1,132 @@
+package main_test
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/pkg/ratelimit"
+)
+
+func TestMemoryLimiter_EvictsLeastRecentlyUsedKeys(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.StoreOptions{Shards: 1, MaxKeys: 3})
+	limit := ratelimit.Limit{Rate: 0.001, Burst: 1}
+	ctx := context.Background()
+	allow := func(key string) bool {
+		res, err := limiter.Allow(ctx, key, limit)
+		require.NoError(t, err)
+		return res.Allowed
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.True(t, allow(key))
+	}
+	assert.False(t, allow("a"), "a 最近被访问")
+	assert.True(t, allow("d"), "新 key 淘汰最久未使用的 b")
+	assert.True(t, allow("b"), "b 已被淘汰，重新获得满的令牌桶")
+	assert.False(t, allow("a"))
+
+	stats := limiter.Stats()
+	assert.Equal(t, 3, stats.ActiveKeys)
+	assert.Equal(t, uint64(2), stats.Evictions)
+}
+
+func TestMemoryLimiter_ExpiresIdleKeys(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.StoreOptions{IdleTTL: 20 * time.Millisecond})
+	for i := 0; i < 100; i++ {
+		_, err := limiter.Allow(context.Background(), strconv.Itoa(i), ratelimit.Limit{Rate: 1, Burst: 1})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 100, limiter.Stats().ActiveKeys)
+
+	time.Sleep(30 * time.Millisecond)
+	stats := limiter.Stats()
+	assert.Equal(t, 0, stats.ActiveKeys)
+	assert.Equal(t, uint64(100), stats.Expirations)
+}
+
+func TestMemoryCounter_StaysBoundedAndKeepsCountsUntilExpiry(t *testing.T) {
+	counter := ratelimit.NewMemoryCounter(ratelimit.StoreOptions{MaxKeys: 1000, IdleTTL: time.Nanosecond})
+	ctx := context.Background()
+	day := time.Now().Add(time.Hour)
+	for i := 0; i < 100000; i++ {
+		_, err := counter.Incr(ctx, "daily:"+strconv.Itoa(i), day)
+		require.NoError(t, err)
+	}
+	stats := counter.Stats()
+	assert.LessOrEqual(t, stats.ActiveKeys, 1000)
+	assert.Equal(t, uint64(100000-stats.ActiveKeys), stats.Evictions)
+
+	n, err := counter.Incr(ctx, "user:1", day)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	time.Sleep(time.Millisecond)
+	n, err = counter.Incr(ctx, "user:1", day)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n, "idleTTL 不清除未到期的计数")
+
+	n, err = counter.Incr(ctx, "expired", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = counter.Incr(ctx, "expired", day)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "到期后重新计数")
+}
+
+func TestMemoryLimiter_StaysBoundedUnderUniqueKeys(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.StoreOptions{MaxKeys: 1000})
+	limit := ratelimit.Limit{Rate: 10, Burst: 10}
+	for i := 0; i < 100000; i++ {
+		_, _ = limiter.Allow(context.Background(), "ip:"+strconv.Itoa(i), limit)
+	}
+	stats := limiter.Stats()
+	assert.LessOrEqual(t, stats.ActiveKeys, 1000)
+	assert.Equal(t, uint64(100000-stats.ActiveKeys), stats.Evictions)
+}
+
+// BenchmarkMemoryLimiter_UniqueKeys 每次请求使用不同的 key (模拟大量不同的客户端 IP)，
+// 报告结束时的 key 数和堆内存。key 数受 MaxKeys 限制，-benchtime=3000000x 与 1000000x 的 heap-MB 基本相同:
+//
+//	go test ./test -run '^$' -bench UniqueKeys -benchtime=3000000x
+func BenchmarkMemoryLimiter_UniqueKeys(b *testing.B) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.StoreOptions{MaxKeys: ratelimit.DefaultMaxKeys, IdleTTL: ratelimit.DefaultIdleTTL})
+	limit := ratelimit.Limit{Rate: 10, Burst: 20}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = limiter.Allow(ctx, "ip:"+strconv.Itoa(i), limit)
+	}
+	b.StopTimer()
+	reportStoreMemory(b, limiter)
+}
+
+// BenchmarkMemoryLimiter_Parallel 并发访问固定数量的 key，衡量分片锁的竞争。
+func BenchmarkMemoryLimiter_Parallel(b *testing.B) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.StoreOptions{MaxKeys: ratelimit.DefaultMaxKeys})
+	limit := ratelimit.Limit{Rate: 1e6, Burst: 1e6}
+	ctx := context.Background()
+	var seq atomic.Uint64
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = limiter.Allow(ctx, "ip:"+strconv.FormatUint(seq.Add(1)%10000, 10), limit)
+		}
+	})
+}
+
+func reportStoreMemory(b *testing.B, limiter *ratelimit.MemoryLimiter) {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	stats := limiter.Stats()
+	b.ReportMetric(float64(stats.ActiveKeys), "keys")
+	b.ReportMetric(float64(stats.Evictions), "evictions")
+	b.ReportMetric(float64(m.HeapInuse)/(1<<20), "heap-MB")
+}
@@ -0,0 +1,200 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/ratelimit"
+	"myGin/internal/plugin"
+)
+
+// newRedisClient 返回连接到 miniredis 的客户端。
+func newRedisClient(t *testing.T, mr *miniredis.Miniredis) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+// newRateLimitEngine 启用限流插件并注册 GET /ping。
+func newRateLimitEngine(t *testing.T, deps plugin.Deps, rl conf.RateLimitConfig) *gin.Engine {
+	cfg := validConfig()
+	rl.Enable = true
+	cfg.Modules.RateLimit = rl
+
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	_, err := bootstrap.AttachPlugins(engine, cfg, deps)
+	require.NoError(t, err)
+	engine.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func ping(engine *gin.Engine) int {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	return w.Code
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+	limiter := ratelimit.NewRedisTokenBucket(newRedisClient(t, mr), "rl:")
+	limit := ratelimit.Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "ip:1.2.3.4", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+	res, err := limiter.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.ResetAfter)
+
+	res, err = limiter.Allow(ctx, "ip:5.6.7.8", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "不同 key 互不影响")
+
+	mr.SetTime(now.Add(1500 * time.Millisecond))
+	res, err = limiter.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "1.5 秒后补充了 1 个令牌")
+	assert.True(t, mr.Exists("rl:ip:1.2.3.4"))
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+	limiter := ratelimit.NewRedisSlidingWindow(newRedisClient(t, mr), "rl:")
+	limit := ratelimit.Limit{Rate: 2, Window: time.Second} // 窗口内最多 2 个请求
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		mr.SetTime(now.Add(time.Duration(i) * 400 * time.Millisecond))
+		res, err := limiter.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+	mr.SetTime(now.Add(900 * time.Millisecond))
+	res, err := limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter, "最早的请求 100ms 后移出窗口")
+
+	mr.SetTime(now.Add(1001 * time.Millisecond))
+	res, err = limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	res, err = limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed, "400ms 和 1001ms 的请求仍在窗口内")
+}
+
+func TestRateLimitPlugin_RedisQuotaSharedAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	// 1 分钟窗口内最多 2 个请求
+	rl := conf.RateLimitConfig{Rate: 2.0 / 60, Burst: 1, Backend: "redis", Algorithm: "sliding_window", Window: "1m", KeyPrefix: "ratelimit:"}
+	a := newRateLimitEngine(t, plugin.Deps{plugin.DepRedis: newRedisClient(t, mr)}, rl)
+	b := newRateLimitEngine(t, plugin.Deps{plugin.DepRedis: newRedisClient(t, mr)}, rl)
+
+	assert.Equal(t, http.StatusOK, ping(a))
+	assert.Equal(t, http.StatusOK, ping(b))
+	assert.Equal(t, http.StatusTooManyRequests, ping(a), "两个实例共享同一窗口配额")
+	assert.True(t, mr.Exists("ratelimit:ip:192.0.2.1"))
+}
+
+func TestRateLimitPlugin_FallsBackToMemoryWhenRedisFails(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := newRedisClient(t, mr)
+	engine := newRateLimitEngine(t, plugin.Deps{plugin.DepRedis: rdb}, conf.RateLimitConfig{Rate: 0.001, Burst: 1, Backend: "redis"})
+	mr.Close()
+
+	assert.Equal(t, http.StatusOK, ping(engine), "Redis 不可用时不拒绝所有请求")
+	assert.Equal(t, http.StatusTooManyRequests, ping(engine), "降级后仍按进程内令牌桶限流")
+
+	// Redis 未启用时直接使用进程内限流
+	engine = newRateLimitEngine(t, plugin.Deps{}, conf.RateLimitConfig{Rate: 0.001, Burst: 1, Backend: "redis"})
+	assert.Equal(t, http.StatusOK, ping(engine))
+	assert.Equal(t, http.StatusTooManyRequests, ping(engine))
+}
+
+func TestRateLimitPlugin_SlidingWindowRequiresRedisBackend(t *testing.T) {
+	p := plugin.NewRateLimitPlugin()
+	err := p.Init(&conf.RateLimitConfig{Enable: true, Rate: 1, Burst: 1, Backend: "memory", Algorithm: "sliding_window"},
+		plugin.Deps{plugin.DepLogger: zap.NewNop()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires backend redis")
+}
+
+func TestRateLimitPlugin_ResponseHeaders(t *testing.T) {
+	engine := newRateLimitEngine(t, plugin.Deps{}, conf.RateLimitConfig{Rate: 1, Burst: 2})
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		return w
+	}
+
+	w := get()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(plugin.HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(plugin.HeaderRateLimitRemaining))
+	assert.Equal(t, "1", w.Header().Get(plugin.HeaderRateLimitReset))
+	assert.Empty(t, w.Header().Get(plugin.HeaderRetryAfter))
+
+	w = get()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(plugin.HeaderRateLimitRemaining))
+	assert.Equal(t, "2", w.Header().Get(plugin.HeaderRateLimitReset))
+
+	w = get()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(plugin.HeaderRetryAfter))
+	assert.Equal(t, "0", w.Header().Get(plugin.HeaderRateLimitRemaining))
+
+	var body struct {
+		Code    int                     `json:"code"`
+		Details plugin.RateLimitDetails `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errs.TooManyRequests.Code, body.Code)
+	assert.Equal(t, plugin.RateLimitDetails{Limit: 2, Remaining: 0, RetryAfter: 1, Reset: 2, ResetAt: body.Details.ResetAt}, body.Details)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), body.Details.ResetAt, 1500*time.Millisecond)
+}
+
+func TestRateLimitPlugin_DailyCapHeaders(t *testing.T) {
+	engine := newRateLimitEngine(t, plugin.Deps{}, conf.RateLimitConfig{Rate: 1000, Burst: 1000, Rules: []conf.RateLimitRuleConfig{
+		{Name: "daily", Daily: 1},
+	}})
+	assert.Equal(t, http.StatusOK, ping(engine))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(plugin.HeaderRateLimitLimit))
+	midnight := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	retryAfter, err := strconv.Atoi(w.Header().Get(plugin.HeaderRetryAfter))
+	require.NoError(t, err)
+	assert.InDelta(t, time.Until(midnight).Seconds(), retryAfter, 2, "每日配额在 UTC 零点重置")
+}
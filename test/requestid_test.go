@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/requestid"
+)
+
+// setupRequestIDServer 创建挂载了请求 ID 中间件的测试引擎。
+// /ctx 返回 context.Context 中的请求 ID，/err 返回一个标准错误响应。
+func setupRequestIDServer() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestid.Middleware())
+	router.GET("/ctx", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c.Request.Context()))
+	})
+	router.GET("/err", func(c *gin.Context) {
+		errs.NotFound.JSON(c)
+	})
+	return router
+}
+
+func TestRequestID_GeneratedAndStoredInContext(t *testing.T) {
+	router := setupRequestIDServer()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ctx", nil))
+
+	id := w.Header().Get(requestid.HeaderName)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, w.Body.String(), "context.Context 中的请求 ID 应与响应头一致")
+}
+
+func TestRequestID_FromTraceparent(t *testing.T) {
+	router := setupRequestIDServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/ctx", nil)
+	req.Header.Set(requestid.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(requestid.HeaderName))
+}
+
+func TestRequestID_InvalidInboundHeaderIsReplaced(t *testing.T) {
+	router := setupRequestIDServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/ctx", nil)
+	req.Header.Set(requestid.HeaderName, "bad id with spaces")
+	req.Header.Set(requestid.TraceparentHeader, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	id := w.Header().Get(requestid.HeaderName)
+	assert.NotEqual(t, "bad id with spaces", id)
+	assert.Len(t, id, 32)
+}
+
+func TestRequestID_IncludedInErrorResponse(t *testing.T) {
+	router := setupRequestIDServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/err", nil)
+	req.Header.Set(requestid.HeaderName, "support-ticket-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var errResp errs.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, errs.NotFound.Code, errResp.Code)
+	assert.Equal(t, "support-ticket-42", errResp.RequestID)
+}
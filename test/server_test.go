@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+)
+
+func TestNewHTTPServer_AppliesTimeouts(t *testing.T) {
+	initTestLogger()
+	hs, err := bootstrap.NewHTTPServer(conf.ServerConfig{
+		Addr:              ":0",
+		ReadTimeout:       "15s",
+		ReadHeaderTimeout: "5s",
+		WriteTimeout:      "20s",
+		IdleTimeout:       "1m",
+		MaxHeaderBytes:    4096,
+	}, http.NotFoundHandler())
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	srv := hs.Server
+	assert.Equal(t, 15*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 5*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 20*time.Second, srv.WriteTimeout)
+	assert.Equal(t, time.Minute, srv.IdleTimeout)
+	assert.Equal(t, 4096, srv.MaxHeaderBytes)
+	assert.Equal(t, 10*time.Second, hs.Settings.ShutdownTimeout, "未配置时使用默认关停超时")
+}
+
+func TestParseServerConfig_ReportsAllInvalidFields(t *testing.T) {
+	_, err := bootstrap.ParseServerConfig(conf.ServerConfig{
+		ReadTimeout:     "15",
+		WriteTimeout:    "-1s",
+		ShutdownTimeout: "soon",
+		MaxHeaderBytes:  -1,
+	})
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "server.readTimeout")
+		assert.Contains(t, err.Error(), "server.writeTimeout")
+		assert.Contains(t, err.Error(), "server.shutdownTimeout")
+		assert.Contains(t, err.Error(), "server.maxHeaderBytes")
+	}
+}
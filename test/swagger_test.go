@@ -0,0 +1,133 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/handler"
+	"myGin/internal/pkg/openapi"
+	"myGin/internal/plugin"
+)
+
+// newSwaggerEngine 清空全局 OpenAPI 注册表并启用 swagger 插件 (opt 可调整配置)，返回引擎和插件管理器，业务路由由调用方注册。
+func newSwaggerEngine(t *testing.T, opt func(*conf.Config)) (*gin.Engine, *bootstrap.PluginManager) {
+	openapi.Reset() // 只检查本引擎声明的路由
+	cfg := validConfig()
+	cfg.Modules.Swagger = conf.SwaggerConfig{Enable: true, Path: "/swagger", Title: "test", Check: plugin.SwaggerCheckFail}
+	if opt != nil {
+		opt(cfg)
+	}
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	plugins, err := bootstrap.AttachPlugins(engine, cfg, plugin.Deps{plugin.DepLogger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = plugins.Stop(context.Background()) })
+	return engine, plugins
+}
+
+func TestSwagger_ServesSpecFromDTOs(t *testing.T) {
+	engine, _ := newSwaggerEngine(t, nil)
+	handler.NewFlightHandler(&MockFlightService{}, zap.NewNop()).RegisterRoutes(engine.Group("/api/v1"))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	assert.Equal(t, "test", doc.Info.Title)
+	search := doc.Paths["/api/v1/flights/tickets/search"]["post"]
+	require.NotNil(t, search)
+	assert.Equal(t, "搜索机票", search.Summary)
+	assert.Equal(t, "#/components/schemas/SearchOption", search.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/SearchResult", search.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/ErrorResponse", search.Responses["400"].Content["application/json"].Schema.Ref)
+	order := doc.Paths["/api/v1/flights/tickets/order"]["post"]
+	require.NotNil(t, order)
+	assert.Equal(t, []map[string][]string{{openapi.SecurityBearer: {}}}, order.Security)
+
+	schemas := doc.Components.Schemas
+	opt := schemas["SearchOption"]
+	require.NotNil(t, opt)
+	assert.ElementsMatch(t, []string{"from", "to", "date"}, opt.Required, "binding:\"required\" 对应必填字段")
+	assert.Equal(t, 3, *opt.Properties["from"].MinLength)
+	assert.Equal(t, 3, *opt.Properties["from"].MaxLength)
+	assert.Equal(t, "date", opt.Properties["date"].Format)
+
+	orderReq := schemas["OrderRequest"]
+	require.NotNil(t, orderReq)
+	assert.Equal(t, 1, *orderReq.Properties["passengers"].MinItems)
+	assert.Equal(t, "#/components/schemas/Passenger", orderReq.Properties["passengers"].Items.Ref)
+	assert.Equal(t, []interface{}{0.0, 1.0, 2.0}, schemas["Passenger"].Properties["gender"].Enum)
+	assert.Equal(t, "date-time", schemas["FlightInfo"].Properties["departureTime"].Format)
+}
+
+func TestSwagger_ServesEmbeddedUI(t *testing.T) {
+	engine, _ := newSwaggerEngine(t, nil)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url: "/swagger/openapi.json"`)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/swagger-ui-bundle.js", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotZero(t, w.Body.Len())
+}
+
+func TestSwagger_CheckFailsOnUndocumentedRoutes(t *testing.T) {
+	engine, plugins := newSwaggerEngine(t, nil)
+	engine.GET("/internal/undocumented/:id", func(c *gin.Context) {})
+
+	err := plugins.Start(context.Background())
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "routes without OpenAPI spec: GET /internal/undocumented/:id")
+
+	engine, plugins = newSwaggerEngine(t, func(cfg *conf.Config) {
+		cfg.Modules.Swagger.CheckExclude = []string{"/internal/**"}
+	})
+	engine.GET("/internal/undocumented/:id", func(c *gin.Context) {})
+	assert.NoError(t, plugins.Start(context.Background()), "checkExclude 中的路由不需要文档")
+}
+
+func TestSwagger_CheckIgnoresRoutesDeclaredByEarlierEngines(t *testing.T) {
+	openapi.Register(openapi.Route{Method: http.MethodGet, Path: "/leftover", Summary: "之前的引擎声明的路由"})
+
+	engine, plugins := newSwaggerEngine(t, nil)
+	engine.GET("/leftover", func(c *gin.Context) {})
+	assert.ErrorContains(t, plugins.Start(context.Background()), "GET /leftover", "注册表已清空，当前引擎没有声明该路由")
+}
+
+// TestSwagger_ApplicationRoutesAreDocumented 注册应用的全部路由和插件路由，以 check: fail 确认都有文档。
+func TestSwagger_ApplicationRoutesAreDocumented(t *testing.T) {
+	openapi.Reset() // 之前的测试声明的路由不能掩盖缺失的文档
+	cfg := validConfig()
+	cfg.Modules.Swagger = conf.SwaggerConfig{Enable: true, Path: "/swagger", Check: plugin.SwaggerCheckFail}
+	cfg.Modules.Metrics = conf.MetricsConfig{Enable: true, Path: "/metrics"}
+	cfg.Modules.Auth.Enable = true
+	cfg.Modules.Auth.Secret = "test-secret"
+	cfg.Modules.Auth.APIKeys.Enable = true
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	plugins, err := bootstrap.AttachPlugins(engine, cfg, plugin.Deps{plugin.DepLogger: zap.NewNop()})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"metrics", "auth", "swagger"}, plugins.Names())
+	bootstrap.RegisterRoutes(engine, cfg, bootstrap.InitHealth(cfg, nil, nil))
+
+	require.NoError(t, plugins.Start(context.Background()))
+	t.Cleanup(func() { _ = plugins.Stop(context.Background()) })
+}
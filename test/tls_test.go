@@ -0,0 +1,245 @@
+package main_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/pkg/tlsutil"
+)
+
+// testCA 是测试中生成的自签名 CA，用于签发服务端和客户端证书。
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发一个叶子证书，返回 PEM 格式的证书和私钥。
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+// startTestServer 在随机端口上启动 HTTPServer，返回访问地址。
+func startTestServer(t *testing.T, cfg conf.ServerConfig) string {
+	initTestLogger()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.WriteHeader(http.StatusOK)
+	})
+	hs, err := bootstrap.NewHTTPServer(cfg, handler)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = hs.Serve(ln) }()
+	t.Cleanup(func() { _ = hs.Shutdown(context.Background()) })
+	return ln.Addr().String()
+}
+
+func TestTLS_ServesHTTP2WithReloadedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	certPEM, keyPEM := ca.issue(t, "server-v1", 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	addr := startTestServer(t, conf.ServerConfig{TLS: conf.TLSConfig{
+		Enable:         true,
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		MinVersion:     "1.2",
+		ReloadInterval: "20ms",
+	}})
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+	newClient := func() *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	resp, err := newClient().Get("https://" + addr)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"), "TLS 模式下应协商 HTTP/2")
+	assert.Equal(t, "server-v1", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	// 轮换证书: 写入新证书后无需重启即可生效
+	certPEM, keyPEM = ca.issue(t, "server-v2", 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	future := time.Now().Add(time.Second) // 确保修改时间变化，避免文件系统时间精度问题
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	assert.Eventually(t, func() bool {
+		resp, err := newClient().Get("https://" + addr)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName == "server-v2"
+	}, 2*time.Second, 20*time.Millisecond, "证书应被自动重载")
+}
+
+func TestTLS_MutualTLSRequiresClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	certPEM, keyPEM := ca.issue(t, "server", 20, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	addr := startTestServer(t, conf.ServerConfig{TLS: conf.TLSConfig{
+		Enable:       true,
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientAuth:   "require_and_verify",
+		ClientCAFile: caFile,
+	}})
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+
+	// 未携带客户端证书应握手失败
+	anon := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err := anon.Get("https://" + addr)
+	assert.Error(t, err)
+
+	// 携带由同一 CA 签发的客户端证书应成功
+	clientCertPEM, clientKeyPEM := ca.issue(t, "client", 21, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.NoError(t, err)
+	authed := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	resp, err := authed.Get("https://" + addr)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestH2C_ServesPlaintextHTTP2(t *testing.T) {
+	addr := startTestServer(t, conf.ServerConfig{H2C: true})
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get("http://" + addr)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	handler := bootstrap.RedirectToHTTPS(":8443")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com:8080/api/v1/ping?x=1", nil))
+
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://example.com:8443/api/v1/ping?x=1", w.Header().Get("Location"))
+}
+
+func TestParseServerConfig_InvalidTLS(t *testing.T) {
+	_, err := bootstrap.ParseServerConfig(conf.ServerConfig{TLS: conf.TLSConfig{
+		Enable:       true,
+		MinVersion:   "1.0",
+		CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+		ClientAuth:   "require_and_verify",
+	}})
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "certFile and keyFile are required")
+		assert.Contains(t, err.Error(), "server.tls.minVersion")
+		assert.Contains(t, err.Error(), "server.tls.cipherSuites")
+		assert.Contains(t, err.Error(), "server.tls.clientCAFile")
+	}
+}
+
+func TestReloader_KeepsOldCertificateOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	certPEM, keyPEM := ca.issue(t, "server", 30, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	reloader, err := tlsutil.NewReloader(certFile, keyFile, "", nil)
+	require.NoError(t, err)
+	before, _ := reloader.GetCertificate(nil)
+
+	writeFile(t, certFile, []byte("not a certificate"))
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	reloaded, err := reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	after, _ := reloader.GetCertificate(nil)
+	assert.Same(t, before, after, "加载失败时应继续使用旧证书")
+}
@@ -0,0 +1,145 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"myGin/internal/bootstrap"
+	"myGin/internal/conf"
+	"myGin/internal/plugin"
+)
+
+// newTracingEngine 启用 tracing 插件 (span 写入内存导出器)，注册 GET /items/:id (查询数据库和 Redis) 和 GET /fail。
+// 访问日志、插件日志和 SQL 日志都写入返回的 ObservedLogs。
+// configure 可以在启动插件前调整配置，例如同时启用限流插件。
+func newTracingEngine(t *testing.T, configure ...func(*conf.Config)) (*gin.Engine, *tracetest.InMemoryExporter, *observer.ObservedLogs) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	type item struct {
+		ID   int64
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Create(&item{ID: 1, Name: "a"}).Error)
+	rdb := newRedisClient(t, miniredis.RunT(t))
+
+	core, logs := observer.New(zap.InfoLevel)
+	db = db.Session(&gorm.Session{Logger: bootstrap.NewGormLogger(zap.New(core), gormlogger.Config{LogLevel: gormlogger.Info})})
+
+	exporter := tracetest.NewInMemoryExporter()
+	cfg := validConfig()
+	cfg.Modules.Tracing = conf.TracingConfig{Enable: true, Exporter: "stdout", SampleRatio: 1}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	initTestLogger()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(bootstrap.ZapLogger(zap.New(core), conf.AccessLogConfig{}))
+	plugins, err := bootstrap.AttachPlugins(engine, cfg, plugin.Deps{
+		plugin.DepDB: db, plugin.DepRedis: rdb, plugin.DepSpanExporter: exporter,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = plugins.Stop(context.Background()) })
+
+	engine.GET("/items/:id", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var it item
+		require.NoError(t, db.WithContext(ctx).First(&it, c.Param("id")).Error)
+		_ = rdb.Get(ctx, "item:"+c.Param("id")).Err()
+		bootstrap.LoggerFromContext(c, zap.NewNop()).Info("handler")
+		c.JSON(http.StatusOK, it)
+	})
+	engine.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	return engine, exporter, logs
+}
+
+func TestTracing_ServerSpanWithDBAndRedisChildren(t *testing.T) {
+	engine, exporter, logs := newTracingEngine(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736", "响应头写回 traceparent")
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	server, ok := spans["GET /items/:id"]
+	require.True(t, ok, "服务端 span 以路由模板命名")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String(), "沿用入站 trace-id")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	attrs := map[string]interface{}{}
+	for _, kv := range server.Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, "/items/:id", attrs["http.route"])
+	assert.Equal(t, int64(200), attrs["http.response.status_code"])
+
+	for _, name := range []string{"gorm.query", "redis.get"} {
+		child, ok := spans[name]
+		require.True(t, ok, name)
+		assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID(), "%s 是服务端 span 的子 span", name)
+		assert.NotEqual(t, codes.Error, child.Status.Code, "%s: record not found / redis.Nil 不视为失败", name)
+	}
+
+	traceID := server.SpanContext.TraceID().String()
+	for _, msg := range []string{"handler", "HTTP request", "[GORM] Trace"} {
+		entries := logs.FilterMessage(msg).All()
+		require.Len(t, entries, 1, msg)
+		assert.Equal(t, traceID, entries[0].ContextMap()["trace_id"], "%s 日志带有 trace_id", msg)
+		assert.NotEmpty(t, entries[0].ContextMap()["span_id"])
+	}
+}
+
+func TestTracing_PluginLogsCarryTraceID(t *testing.T) {
+	engine, _, logs := newTracingEngine(t, func(cfg *conf.Config) {
+		cfg.Modules.RateLimit = conf.RateLimitConfig{Enable: true, Rate: 0.001, Burst: 1}
+	})
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		require.Equal(t, want, w.Code, "第 %d 个请求", i+1)
+	}
+
+	entries := logs.FilterMessage("RateLimit middleware: Too many requests").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"], "限流插件的日志带有 trace_id")
+	assert.NotEmpty(t, fields["span_id"])
+	assert.NotEmpty(t, fields["request_id"])
+}
+
+func TestTracing_ServerErrorMarksSpan(t *testing.T) {
+	engine, exporter, _ := newTracingEngine(t)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /fail", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.False(t, spans[0].Parent.IsValid(), "没有 traceparent 时开始新的链路")
+}
@@ -0,0 +1,105 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myGin/internal/dto"
+	"myGin/internal/pkg/errs"
+	"myGin/internal/pkg/validation"
+)
+
+// validationResponse 是带字段级明细的 400 响应。
+type validationResponse struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Details []validation.FieldError `json:"details"`
+}
+
+// postInvalid 向测试服务发送 JSON 请求体并解析 400 响应。
+func postInvalid(t *testing.T, path, body, acceptLanguage string) validationResponse {
+	router, _ := setupTestServer(t)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp validationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errs.BadRequest.Code, resp.Code)
+	return resp
+}
+
+// detailFor 返回指定字段的错误明细。
+func detailFor(t *testing.T, details []validation.FieldError, field string) validation.FieldError {
+	for _, d := range details {
+		if d.Field == field {
+			return d
+		}
+	}
+	require.Failf(t, "missing field error", "field %q not in %+v", field, details)
+	return validation.FieldError{}
+}
+
+func TestValidation_FieldErrorsTranslated(t *testing.T) {
+	body := `{"from":"SH","date":"2025/01/01"}`
+
+	resp := postInvalid(t, "/api/v1/flights/tickets/search", body, "")
+	assert.Equal(t, "请求参数校验失败", resp.Message, "默认使用中文")
+	from := detailFor(t, resp.Details, "from")
+	assert.Equal(t, "len", from.Rule)
+	assert.Equal(t, "3", from.Param)
+	assert.Contains(t, from.Message, "长度必须是3个字符")
+	assert.Equal(t, "required", detailFor(t, resp.Details, "to").Rule)
+	assert.Equal(t, "datetime", detailFor(t, resp.Details, "date").Rule)
+
+	resp = postInvalid(t, "/api/v1/flights/tickets/search", body, "en-US,en;q=0.9,zh;q=0.5")
+	assert.Equal(t, "request validation failed", resp.Message)
+	assert.Equal(t, "from must be 3 characters in length", detailFor(t, resp.Details, "from").Message)
+	assert.Equal(t, "to is a required field", detailFor(t, resp.Details, "to").Message)
+}
+
+func TestValidation_NestedFieldPath(t *testing.T) {
+	type order struct {
+		Passengers []dto.Passenger `json:"passengers" binding:"required,dive"`
+	}
+	err := binding.Validator.ValidateStruct(&order{Passengers: []dto.Passenger{{IDType: "IDCard", IDNumber: "1", Gender: 3}}})
+	details := validation.Details(err, "en")
+
+	assert.Equal(t, "required", detailFor(t, details, "passengers[0].name").Rule)
+	gender := detailFor(t, details, "passengers[0].gender")
+	assert.Equal(t, "oneof", gender.Rule)
+	assert.Equal(t, "0 1 2", gender.Param)
+}
+
+func TestValidation_JSONSyntaxAndTypeErrors(t *testing.T) {
+	resp := postInvalid(t, "/api/v1/flights/tickets/search", `{"from":"SHA",}`, "en")
+	assert.Equal(t, "malformed request body", resp.Message)
+	require.Len(t, resp.Details, 1)
+	assert.Equal(t, validation.RuleJSON, resp.Details[0].Rule)
+	assert.Equal(t, int64(15), resp.Details[0].Offset)
+
+	body := `{"flightId":"F1","contactName":"a","contactPhone":"1","passengers":[{"name":"a","gender":"male"}]}`
+	resp = postInvalid(t, "/api/v1/flights/tickets/order", body, "")
+	require.Len(t, resp.Details, 1)
+	d := resp.Details[0]
+	assert.Equal(t, "passengers[0].gender", d.Field)
+	assert.Equal(t, validation.RuleType, d.Rule)
+	assert.Equal(t, "number", d.Param)
+	assert.NotZero(t, d.Offset)
+	assert.Equal(t, "请求参数校验失败", resp.Message)
+
+	resp = postInvalid(t, "/api/v1/flights/tickets/search", ``, "en")
+	require.Len(t, resp.Details, 1)
+	assert.Equal(t, validation.RuleEmpty, resp.Details[0].Rule)
+}